@@ -0,0 +1,363 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// Parámetros de la simulación en lazo cerrado
+const (
+	closedLoopSamples    = 4000    // Muestras devueltas del horizonte simulado
+	stepsPerTimeConstant = 10      // Pasos de integración por la constante de tiempo más rápida
+	stepsPerDelay        = 20      // Pasos de integración por el retardo de la planta
+	maxClosedLoopSteps   = 2000000 // Pasos de integración máximos de una simulación
+	padeOrder            = 3       // Orden de la aproximación de Padé del retardo para los polos
+)
+
+// ClosedLoopResponse es la respuesta simulada del lazo de realimentación unitaria
+// r → C(s) → (+ perturbación) → G(s) → y
+type ClosedLoopResponse struct {
+	Time    []float64 `json:"time"`
+	Output  []float64 `json:"output"`  // y
+	Control []float64 `json:"control"` // Esfuerzo de control u (salida del controlador)
+}
+
+// SimulateClosedLoop simula el lazo cerrado de la planta con el controlador durante horizon
+// segundos, con un escalón de referencia de amplitud reference y una perturbación de carga en
+// la entrada de la planta de amplitud disturbance, ambos en t = 0. La planta y el controlador
+// se integran como un único sistema aumentado, discretizado de forma exacta; sin retardo la
+// simulación es exacta y con retardo solo la entrada retrasada de la planta, tomada de un
+// registro de desplazamiento, se interpola linealmente en cada paso. El paso es una fracción de
+// la constante de tiempo más rápida de la planta, del controlador (incluido el filtro de la
+// derivada) y del lazo cerrado, y del retardo, por lo que cada muestra devuelta puede abarcar
+// varios pasos.
+func SimulateClosedLoop(plant, controller TransferFunction, horizon, reference, disturbance float64) (*ClosedLoopResponse, error) {
+	if horizon <= 0 || math.IsNaN(horizon) || math.IsInf(horizon, 0) {
+		return nil, errors.New("el horizonte de simulación debe ser positivo")
+	}
+	if controller.Delay != 0 {
+		return nil, errors.New("el controlador no puede tener retardo")
+	}
+
+	sample := horizon / float64(closedLoopSamples-1)
+	substeps := 1
+	if plant.Delay > 0 {
+		substeps = closedLoopSubsteps(plant, controller, sample)
+		if substeps*closedLoopSamples > maxClosedLoopSteps {
+			return nil, errors.New("la dinámica del lazo es demasiado rápida para simularla en este horizonte")
+		}
+	}
+	h := sample / float64(substeps)
+	delaySteps := int(math.Round(plant.Delay / h))
+
+	loop, err := newAugmentedLoop(plant, controller, delaySteps > 0)
+	if err != nil {
+		return nil, err
+	}
+	phi, gamma0, gamma1 := discretizeFOH(loop.a, loop.b, h)
+
+	// Estado z = [xp, xc, r, d]: la referencia y la perturbación son estados constantes
+	z, next := make([]float64, len(loop.b)), make([]float64, len(loop.b))
+	z[len(z)-2], z[len(z)-1] = reference, disturbance
+	buffer := make([]float64, delaySteps) // u[j] de los últimos pasos, en el índice j mod delaySteps
+	response := &ClosedLoopResponse{
+		Time:    make([]float64, closedLoopSamples),
+		Output:  make([]float64, closedLoopSamples),
+		Control: make([]float64, closedLoopSamples),
+	}
+
+	for k := 0; k < closedLoopSamples; k++ {
+		for step := 0; step < substeps; step++ {
+			// La entrada retrasada varía linealmente de u[j-D] a u[j-D+1] en el paso j; antes
+			// de t = 0 la salida del controlador es nula, lo que conserva su salto inicial
+			j := k*substeps + step
+			var start, end float64
+			if delaySteps > 0 && j >= delaySteps {
+				start = buffer[j%delaySteps]
+			}
+			y := dot(loop.y, z) + loop.yDelayed*start
+			u := dot(loop.u, z) + loop.uDelayed*start
+			if math.IsNaN(y) || math.IsInf(y, 0) || math.IsNaN(u) || math.IsInf(u, 0) {
+				return nil, errors.New("la simulación en lazo cerrado diverge")
+			}
+			if step == 0 {
+				response.Time[k], response.Output[k], response.Control[k] = float64(k)*sample, y, u
+			}
+			if delaySteps > 0 {
+				buffer[j%delaySteps] = u
+				if j >= delaySteps {
+					end = buffer[(j+1)%delaySteps]
+				}
+			}
+			stepState(phi, gamma0, z, next, start)
+			for i := range next {
+				next[i] += gamma1[i] * end
+			}
+			z, next = next, z
+		}
+	}
+	return response, nil
+}
+
+// augmentedLoop es el lazo con estado z = [xp, xc, r, d] y entrada w, la salida del
+// controlador retrasada: ż = A·z + B·w, y = Y·z + yw·w, u = U·z + uw·w. Sin retardo el lazo
+// se cierra en el mismo instante y no hay entrada.
+type augmentedLoop struct {
+	a                  matrix
+	b                  []float64
+	y, u               []float64
+	yDelayed, uDelayed float64
+}
+
+// newAugmentedLoop construye el sistema aumentado de la planta y el controlador
+func newAugmentedLoop(plant, controller TransferFunction, delayed bool) (*augmentedLoop, error) {
+	ap, bp, cp, dp := plant.stateSpace()
+	ac, bc, cc, dc := controller.stateSpace()
+	np, nc := len(bp), len(bc)
+	n := np + nc + 2
+	ir, id := np+nc, np+nc+1
+
+	// Salida de la planta y entrada de la planta v como combinación de z y w
+	loop := &augmentedLoop{a: newMatrix(n, n), b: make([]float64, n), y: make([]float64, n), u: make([]float64, n)}
+	v := make([]float64, n)
+	var vDelayed float64
+	if delayed {
+		// v = w + d, y = Cp·xp + Dp·v
+		copy(loop.y, cp)
+		loop.y[id], loop.yDelayed = dp, dp
+		v[id], vDelayed = 1, 1
+	} else {
+		// y = Cp·xp + Dp·(u + d) con u = Cc·xc + Dc·(r - y)
+		den := 1 + dp*dc
+		if den == 0 {
+			return nil, errors.New("el lazo cerrado está mal definido (1 + Dp·Dc = 0)")
+		}
+		copy(loop.y, cp)
+		for j := 0; j < nc; j++ {
+			loop.y[np+j] = dp * cc[j]
+		}
+		loop.y[ir], loop.y[id] = dp*dc, dp
+		for j := range loop.y {
+			loop.y[j] /= den
+		}
+	}
+	// u = Cc·xc + Dc·(r - y)
+	for j := 0; j < nc; j++ {
+		loop.u[np+j] = cc[j]
+	}
+	loop.u[ir] += dc
+	for j := range loop.u {
+		loop.u[j] -= dc * loop.y[j]
+	}
+	loop.uDelayed = -dc * loop.yDelayed
+	if !delayed {
+		copy(v, loop.u)
+		v[id]++
+	}
+
+	// ẋp = Ap·xp + Bp·v, ẋc = Ac·xc + Bc·(r - y)
+	for i := 0; i < np; i++ {
+		copy(loop.a[i], ap[i])
+		for j := range v {
+			loop.a[i][j] += bp[i] * v[j]
+		}
+		loop.b[i] = bp[i] * vDelayed
+	}
+	for i := 0; i < nc; i++ {
+		row := loop.a[np+i]
+		copy(row[np:np+nc], ac[i])
+		row[ir] += bc[i]
+		for j := range loop.y {
+			row[j] -= bc[i] * loop.y[j]
+		}
+		loop.b[np+i] = -bc[i] * loop.yDelayed
+	}
+	return loop, nil
+}
+
+// closedLoopSubsteps devuelve los pasos de integración por muestra devuelta necesarios para
+// resolver la constante de tiempo más rápida del lazo y el retardo de la planta
+func closedLoopSubsteps(plant, controller TransferFunction, sample float64) int {
+	limit := math.Inf(1)
+	poles := append(append(plant.Poles(), controller.Poles()...), ClosedLoopPoles(plant, controller)...)
+	for _, p := range poles {
+		if w := cmplx.Abs(p); w > 1e-12 {
+			limit = math.Min(limit, 1/(w*stepsPerTimeConstant))
+		}
+	}
+	if plant.Delay > 0 {
+		limit = math.Min(limit, plant.Delay/stepsPerDelay)
+	}
+	if limit >= sample {
+		return 1
+	}
+	return int(math.Ceil(sample / limit))
+}
+
+// stepState avanza un paso next = Φ·x + Γ·u
+func stepState(phi matrix, gamma, x, next []float64, u float64) {
+	for r := range x {
+		v := gamma[r] * u
+		for k := range x {
+			v += phi[r][k] * x[k]
+		}
+		next[r] = v
+	}
+}
+
+// dot calcula el producto escalar de dos vectores de la misma longitud
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// LoopTransferFunction devuelve L(s) = C(s)·G(s), con el retardo de la planta
+func LoopTransferFunction(plant, controller TransferFunction) (TransferFunction, error) {
+	return NewTransferFunction(polyMul(controller.Num, plant.Num), polyMul(controller.Den, plant.Den), plant.Delay+controller.Delay)
+}
+
+// ClosedLoopPoles devuelve los polos del lazo cerrado 1 + C(s)·G(s) = 0. El retardo se
+// sustituye por su aproximación de Padé de orden 3, por lo que los polos de alta frecuencia
+// que introduce son solo aproximados.
+func ClosedLoopPoles(plant, controller TransferFunction) []complex128 {
+	num, den := polyMul(controller.Num, plant.Num), polyMul(controller.Den, plant.Den)
+	if delay := plant.Delay + controller.Delay; delay > 0 {
+		padeNum, padeDen := padeDelay(delay, padeOrder)
+		num, den = polyMul(num, padeNum), polyMul(den, padeDen)
+	}
+	return polyRoots(polyAdd(den, num))
+}
+
+// padeDelay devuelve la aproximación de Padé (order, order) de e^(-θs)
+func padeDelay(theta float64, order int) ([]float64, []float64) {
+	// Coeficientes c_k = (2n-k)!·n! / ((2n)!·k!·(n-k)!) en potencias ascendentes de θs
+	num, den := make([]float64, order+1), make([]float64, order+1)
+	c := 1.0
+	for k := 0; k <= order; k++ {
+		if k > 0 {
+			c *= float64(order-k+1) / float64(k*(2*order-k+1))
+		}
+		term := c * math.Pow(theta, float64(k))
+		den[order-k] = term
+		num[order-k] = term
+		if k%2 == 1 {
+			num[order-k] = -term
+		}
+	}
+	return num, den
+}
+
+// ClosedLoopHorizon propone un horizonte de simulación: diez veces la suma de las constantes
+// de tiempo de los polos estables de la planta más su retardo
+func ClosedLoopHorizon(plant TransferFunction) float64 {
+	var sum float64
+	for _, p := range plant.Poles() {
+		if re := math.Abs(real(p)); re > 1e-9 {
+			sum += 1 / re
+		} else if w := cmplx.Abs(p); w > 1e-9 {
+			sum += 1 / w
+		}
+	}
+	horizon := 10 * (sum + plant.Delay)
+	if horizon <= 0 {
+		horizon = 10
+	}
+	return horizon
+}
+
+// ClosedLoopPrediction resume el comportamiento previsto del lazo cerrado ante un escalón de
+// referencia. Las métricas se omiten si el lazo es inestable o no se pudo simular.
+type ClosedLoopPrediction struct {
+	Stable          bool         `json:"stable"`
+	Poles           []Complex    `json:"poles"`
+	Metrics         *StepMetrics `json:"metrics,omitempty"`
+	MaxControl      float64      `json:"max_control"` // Máximo |u| ante el escalón de referencia unitario
+	GainMargin      *float64     `json:"gain_margin_db,omitempty"`
+	PhaseMargin     *float64     `json:"phase_margin_deg,omitempty"`
+	SettlingBand    float64      `json:"settling_band"`
+	SimulationError string       `json:"simulation_error,omitempty"` // Motivo si no se pudo simular
+}
+
+// PredictClosedLoop simula la respuesta del lazo cerrado a un escalón unitario de referencia
+// y calcula sus métricas con la banda de establecimiento indicada. Si la simulación falla, la
+// predicción conserva los polos y los márgenes e indica el motivo en SimulationError.
+func PredictClosedLoop(plant, controller TransferFunction, horizon, band float64) (*ClosedLoopPrediction, *ClosedLoopResponse, error) {
+	if band <= 0 {
+		band = DefaultSettlingBand
+	}
+	prediction := &ClosedLoopPrediction{Stable: true, SettlingBand: band}
+	for _, p := range ClosedLoopPoles(plant, controller) {
+		prediction.Poles = append(prediction.Poles, FromComplex(p))
+		if real(p) >= 0 {
+			prediction.Stable = false
+		}
+	}
+	if loop, err := LoopTransferFunction(plant, controller); err == nil {
+		if margins, err := Margins(loop); err == nil {
+			prediction.GainMargin, prediction.PhaseMargin = margins.GainMarginDB, margins.PhaseMarginDeg
+		}
+	}
+
+	response, err := SimulateClosedLoop(plant, controller, horizon, 1, 0)
+	if err != nil {
+		prediction.SimulationError = err.Error()
+		return prediction, nil, nil
+	}
+	for _, u := range response.Control {
+		prediction.MaxControl = math.Max(prediction.MaxControl, math.Abs(u))
+	}
+	if prediction.Stable {
+		if metrics, err := StepResponseMetrics(response.Time, response.Output, 0, 1, band); err == nil {
+			prediction.Metrics = metrics
+		}
+	}
+	return prediction, response, nil
+}
+
+// DisturbanceMetrics son las métricas de la respuesta a una perturbación de carga en escalón:
+// la salida se aparta de la referencia y el controlador la devuelve
+type DisturbanceMetrics struct {
+	PeakDeviation  float64 `json:"peak_deviation"`  // Máximo apartamiento de la salida (con signo)
+	PeakTime       float64 `json:"peak_time"`       // Instante del máximo apartamiento
+	RecoveryTime   float64 `json:"recovery_time"`   // Desde el que la salida queda dentro de la banda
+	Recovered      bool    `json:"recovered"`       // false si termina fuera de la banda
+	FinalDeviation float64 `json:"final_deviation"` // Apartamiento al final del horizonte
+	RecoveryBand   float64 `json:"recovery_band"`   // Banda, como fracción del máximo apartamiento
+	IAE            float64 `json:"iae"`             // ∫|y - yref| dt
+}
+
+// DisturbanceResponseMetrics calcula las métricas del rechazo de una perturbación a partir de
+// la salida y (que debería volver a reference) con la banda indicada
+func DisturbanceResponseMetrics(t, y []float64, reference, band float64) (*DisturbanceMetrics, error) {
+	if len(t) < 3 || len(t) != len(y) {
+		return nil, errors.New("se necesitan al menos 3 muestras de tiempo y salida")
+	}
+	if band <= 0 {
+		band = DefaultSettlingBand
+	}
+	m := &DisturbanceMetrics{RecoveryBand: band, FinalDeviation: y[len(y)-1] - reference}
+	peak := 0
+	for i := range y {
+		if math.Abs(y[i]-reference) > math.Abs(y[peak]-reference) {
+			peak = i
+		}
+	}
+	m.PeakDeviation, m.PeakTime = y[peak]-reference, t[peak]-t[0]
+
+	limit := band * math.Abs(m.PeakDeviation)
+	m.Recovered = math.Abs(m.FinalDeviation) <= limit
+	for i := len(y) - 1; i >= 0; i-- {
+		if math.Abs(y[i]-reference) > limit {
+			m.RecoveryTime = t[min(i+1, len(t)-1)] - t[0]
+			break
+		}
+	}
+	for i := 1; i < len(t); i++ {
+		m.IAE += (t[i] - t[i-1]) * (math.Abs(y[i-1]-reference) + math.Abs(y[i]-reference)) / 2
+	}
+	return m, nil
+}
@@ -0,0 +1,115 @@
+package control
+
+import (
+	"math"
+	"testing"
+)
+
+// eulerPIDOnFOPDT integra con Euler explícito y paso fino h el lazo de un PID estándar con
+// derivada filtrada sobre K·e^(-θs)/(τs + 1) ante un escalón unitario de referencia, y
+// devuelve la salida en los instantes at
+func eulerPIDOnFOPDT(k, tau, theta float64, g PIDGains, at []float64, h float64) []float64 {
+	horizon := at[len(at)-1]
+	steps := int(math.Round(horizon / h))
+	delay := int(math.Round(theta / h))
+	tf := g.Td / g.N
+	buffer := make([]float64, delay)
+	var y, integral, filtered float64
+	out := make([]float64, 0, len(at))
+	for i := 0; i <= steps; i++ {
+		e := 1 - y
+		var derivative float64
+		if g.Td > 0 {
+			derivative = g.Td / tf * (e - filtered)
+			filtered += h * (e - filtered) / tf
+		}
+		u := g.Kp * (e + integral/g.Ti + derivative)
+		for len(out) < len(at) && at[len(out)] <= float64(i)*h+h/2 {
+			out = append(out, y)
+		}
+
+		v := u
+		if delay > 0 {
+			v = buffer[i%delay]
+			buffer[i%delay] = u
+		}
+		y += h * (k*v - y) / tau
+		integral += h * e
+	}
+	return out
+}
+
+func TestPredictClosedLoopPIDOnFOPDT(t *testing.T) {
+	cases := []struct {
+		name    string
+		theta   float64
+		gains   PIDGains
+		horizon float64 // 0 para el horizonte por defecto
+	}{
+		// PID de Ziegler–Nichols para θ/τ = 0.02: muy agresivo pero estable
+		{"ziegler_nichols_pid", 0.02, NewPIDGains(60, 0.04, 0.01), 0},
+		{"ziegler_nichols_pid_1s", 0.02, NewPIDGains(60, 0.04, 0.01), 1},
+		// PI de Ziegler–Nichols para θ/τ = 0.2
+		{"ziegler_nichols_pi", 0.2, NewPIDGains(4.5, 0.6, 0), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plant, err := NewTransferFunction([]float64{1}, []float64{1, 1}, tc.theta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			controller, err := tc.gains.TransferFunction()
+			if err != nil {
+				t.Fatal(err)
+			}
+			horizon := tc.horizon
+			if horizon == 0 {
+				horizon = ClosedLoopHorizon(plant)
+			}
+			prediction, response, err := PredictClosedLoop(plant, controller, horizon, DefaultSettlingBand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !prediction.Stable || prediction.SimulationError != "" || prediction.Metrics == nil {
+				t.Fatalf("se esperaba un lazo estable con métricas: %+v", prediction)
+			}
+
+			// Referencia muestreada en los mismos instantes que la simulación
+			ry := eulerPIDOnFOPDT(1, 1, tc.theta, tc.gains, response.Time, 1e-6)
+			reference, err := StepResponseMetrics(response.Time, ry, 0, 1, DefaultSettlingBand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(prediction.Metrics.Overshoot-reference.Overshoot) > 0.5 {
+				t.Errorf("sobrepico %.2f%%, se esperaba %.2f%%", prediction.Metrics.Overshoot, reference.Overshoot)
+			}
+			if math.Abs(prediction.Metrics.PeakTime-reference.PeakTime) > 2*horizon/(closedLoopSamples-1) {
+				t.Errorf("instante del pico %.4f, se esperaba %.4f", prediction.Metrics.PeakTime, reference.PeakTime)
+			}
+		})
+	}
+}
+
+func TestTunePIDSmallDelayKeepsPredictions(t *testing.T) {
+	plant, err := NewTransferFunction([]float64{1}, []float64{1, 1}, 0.005)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := &ProcessModel{Type: ProcessFOPDT, Gain: 1, Tau: 1, DeadTime: 0.005}
+	result, err := TunePID(TuningPlant{Model: plant, Process: process}, TuningOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tuning := range result.Tunings {
+		if tuning.Prediction == nil {
+			t.Errorf("%s %s: sin predicción", tuning.Rule, tuning.Gains.Controller)
+			continue
+		}
+		if tuning.Prediction.SimulationError != "" {
+			t.Errorf("%s %s: %s", tuning.Rule, tuning.Gains.Controller, tuning.Prediction.SimulationError)
+		}
+		if tuning.Prediction.Stable && tuning.Prediction.Metrics == nil {
+			t.Errorf("%s %s: lazo estable sin métricas", tuning.Rule, tuning.Gains.Controller)
+		}
+	}
+}
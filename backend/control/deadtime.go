@@ -0,0 +1,152 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Métodos de estimación del tiempo muerto
+const (
+	DeadTimeThreshold = "threshold" // Primer cruce de un umbral sobre el ruido inicial
+	DeadTimeTangent   = "tangent"   // Intersección de la tangente de máxima pendiente con el nivel inicial
+)
+
+// Origen del instante de aplicación del escalón
+const (
+	StepTimeGiven   = "given"   // Indicado en la solicitud
+	StepTimeInput   = "input"   // Flanco de la entrada medida
+	StepTimeAssumed = "assumed" // Primer instante de la serie, como en StepResponseMetrics
+)
+
+// Límites del umbral del método del umbral, como fracción del salto
+const (
+	minDeadTimeThreshold = 0.02
+	maxDeadTimeThreshold = 0.1
+)
+
+// DeadTimeEstimate es el tiempo muerto estimado de una respuesta al escalón. Threshold es el
+// retardo hasta que la salida sale del ruido; Tangent es el tiempo muerto aparente del modelo
+// de primer orden con retardo (Ziegler–Nichols), que además incluye el retraso de las
+// dinámicas de orden superior y es el que usan las reglas de sintonía.
+type DeadTimeEstimate struct {
+	DeadTime       float64 `json:"dead_time"` // θ elegido (tangente si es válido, si no umbral)
+	Method         string  `json:"method"`    // Método de DeadTime
+	StepTime       float64 `json:"step_time"` // Instante del escalón en la base de tiempo del archivo
+	StepTimeSource string  `json:"step_time_source"`
+	Threshold      float64 `json:"threshold"`       // θ por cruce de umbral
+	Tangent        float64 `json:"tangent"`         // θ por el método de la tangente
+	MaxSlope       float64 `json:"max_slope"`       // Pendiente máxima (unidades de salida/s)
+	MaxSlopeTime   float64 `json:"max_slope_time"`  // Instante de la pendiente máxima
+	ThresholdLevel float64 `json:"threshold_level"` // Umbral usado, como fracción del salto
+}
+
+// EstimateDeadTime estima el tiempo muerto de la respuesta al escalón (t, y) que va del nivel
+// initial al final. stepTime es el instante del escalón si se conoce (NaN si no); si no, se
+// toma el primer instante de la serie, igual que StepResponseMetrics, y θ incluye el tramo
+// registrado antes del escalón real. Los dos métodos se miden desde ese instante.
+func EstimateDeadTime(t, y []float64, initial, final, stepTime float64, source string) (*DeadTimeEstimate, error) {
+	n := len(t)
+	if n < 10 || n != len(y) {
+		return nil, errors.New("datos insuficientes para estimar el tiempo muerto")
+	}
+	step := final - initial
+	if step == 0 || math.IsNaN(step) || math.IsInf(step, 0) {
+		return nil, errors.New("la salida no cambia de nivel")
+	}
+
+	yn := make([]float64, n)
+	for i, v := range y {
+		yn[i] = (v - initial) / step
+	}
+
+	// Umbral: el mayor entre el 2% del salto y tres desviaciones del ruido antes del escalón
+	// (o de las primeras muestras si el escalón no se conoce), hasta el 10% del salto
+	known := !math.IsNaN(stepTime)
+	before := max(n/50, 2)
+	if known {
+		before = 0
+		for before < n && t[before] < stepTime {
+			before++
+		}
+	}
+	level := minDeadTimeThreshold
+	if before >= 2 {
+		level = math.Min(math.Max(level, 3*stdDev(yn[:before])), maxDeadTimeThreshold)
+	}
+	from := 0
+	if known {
+		from = min(before, n-1)
+	}
+	crossing, ok := crossingTime(t[from:], yn[from:], level)
+	if !ok {
+		return nil, errors.New("la salida no supera el nivel de ruido inicial")
+	}
+	if !known {
+		stepTime, source = t[0], StepTimeAssumed
+	}
+
+	estimate := &DeadTimeEstimate{
+		StepTime:       stepTime,
+		StepTimeSource: source,
+		Threshold:      math.Max(0, crossing-stepTime),
+		ThresholdLevel: level,
+		DeadTime:       math.Max(0, crossing-stepTime),
+		Method:         DeadTimeThreshold,
+	}
+
+	// Tangente de máxima pendiente, con diferencias centradas sobre una ventana del 1% de la
+	// serie para no seguir el ruido
+	w := max(n/100, 1)
+	best := -1
+	bestSlope := 0.0
+	for i := w; i < n-w; i++ {
+		if t[i] < stepTime {
+			continue
+		}
+		dt := t[i+w] - t[i-w]
+		if dt <= 0 {
+			continue
+		}
+		if slope := (yn[i+w] - yn[i-w]) / dt; slope > bestSlope {
+			best, bestSlope = i, slope
+		}
+	}
+	if best >= 0 {
+		// La tangente yn(ti) + m·(t - ti) corta el nivel inicial (0) en ti - yn(ti)/m
+		intercept := t[best] - yn[best]/bestSlope
+		estimate.MaxSlope = bestSlope * step
+		estimate.MaxSlopeTime = t[best]
+		estimate.Tangent = math.Max(0, intercept-stepTime)
+		estimate.DeadTime = estimate.Tangent
+		estimate.Method = DeadTimeTangent
+	}
+	return estimate, nil
+}
+
+// stdDev es la desviación estándar poblacional
+func stdDev(x []float64) float64 {
+	m := mean(x)
+	var sum float64
+	for _, v := range x {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(x)))
+}
+
+// StepTimeFromInput localiza el escalón en la entrada medida u: el cruce del 50% entre sus
+// niveles inicial y final. Devuelve false si la entrada no cambia de nivel.
+func StepTimeFromInput(t, u []float64) (float64, bool) {
+	if len(u) != len(t) || len(u) < 2 {
+		return 0, false
+	}
+	initial, final := StepLevels(u)
+	step := final - initial
+	if step == 0 || math.Abs(step) < 1e-9*math.Max(math.Abs(initial), math.Abs(final)) {
+		return 0, false
+	}
+	un := make([]float64, len(u))
+	for i, v := range u {
+		un[i] = (v - initial) / step
+	}
+	return crossingTime(t, un, 0.5)
+}
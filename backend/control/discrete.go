@@ -0,0 +1,478 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Estructuras de modelo discreto (q⁻¹ es el operador de retardo de una muestra)
+const (
+	DiscreteARX   = "arx"   // A(q)·y(t) = B(q)·u(t-nk) + e(t)
+	DiscreteARMAX = "armax" // A(q)·y(t) = B(q)·u(t-nk) + C(q)·e(t)
+	DiscreteOE    = "oe"    // y(t) = B(q)/F(q)·u(t-nk) + e(t)
+)
+
+// Criterios de selección de orden
+const (
+	CriterionAIC = "aic" // N·ln V + 2p
+	CriterionBIC = "bic" // N·ln V + p·ln N
+)
+
+// Límites de la identificación discreta
+const (
+	DefaultDiscreteMaxOrder = 4   // Orden máximo de la selección automática
+	MaxDiscreteOrder        = 10  // Orden máximo admitido para na, nb y nc
+	MaxDiscreteDelay        = 200 // Retardo máximo admitido (muestras)
+	discreteIterations      = 100 // Iteraciones de Levenberg–Marquardt de ARMAX y OE
+	delaySearchWidth        = 2   // Muestras alrededor del retardo estimado que se prueban
+)
+
+// DiscreteOptions configura la identificación discreta. Los órdenes a 0 se seleccionan con el
+// criterio indicado entre 1 y MaxOrder; sin nk, el retardo se busca alrededor del tiempo muerto.
+type DiscreteOptions struct {
+	Structure string `json:"structure,omitempty"` // arx (por defecto), armax u oe
+	Na        int    `json:"na,omitempty"`        // Orden de A (de F en OE)
+	Nb        int    `json:"nb,omitempty"`        // Número de coeficientes de B
+	Nc        int    `json:"nc,omitempty"`        // Orden de C en ARMAX (por defecto igual a na)
+	Nk        *int   `json:"nk,omitempty"`        // Retardo de la entrada en muestras
+	Criterion string `json:"criterion,omitempty"` // aic o bic (por defecto)
+	MaxOrder  int    `json:"max_order,omitempty"` // Por defecto 4
+}
+
+// Validate comprueba la estructura, el criterio y los órdenes
+func (o DiscreteOptions) Validate() error {
+	switch o.structure() {
+	case DiscreteARX, DiscreteARMAX, DiscreteOE:
+	default:
+		return fmt.Errorf("estructura discreta desconocida: %s (arx, armax u oe)", o.Structure)
+	}
+	switch o.criterion() {
+	case CriterionAIC, CriterionBIC:
+	default:
+		return fmt.Errorf("criterio de selección desconocido: %s (aic o bic)", o.Criterion)
+	}
+	for _, order := range []int{o.Na, o.Nb, o.Nc, o.MaxOrder} {
+		if order < 0 || order > MaxDiscreteOrder {
+			return fmt.Errorf("los órdenes deben estar entre 0 y %d", MaxDiscreteOrder)
+		}
+	}
+	if o.Nc > 0 && o.structure() != DiscreteARMAX {
+		return errors.New("nc solo se aplica a la estructura armax")
+	}
+	if o.Nk != nil && (*o.Nk < 0 || *o.Nk > MaxDiscreteDelay) {
+		return fmt.Errorf("nk debe estar entre 0 y %d", MaxDiscreteDelay)
+	}
+	return nil
+}
+
+func (o DiscreteOptions) structure() string {
+	if o.Structure == "" {
+		return DiscreteARX
+	}
+	return o.Structure
+}
+
+func (o DiscreteOptions) criterion() string {
+	if o.Criterion == "" {
+		return CriterionBIC
+	}
+	return o.Criterion
+}
+
+func (o DiscreteOptions) maxOrder() int {
+	if o.MaxOrder == 0 {
+		return DefaultDiscreteMaxOrder
+	}
+	return o.MaxOrder
+}
+
+// DiscreteModel es un modelo discreto identificado. A = [1, a1, ..., ana] (F en OE),
+// B = [b1, ..., bnb] multiplica a u(t-nk), ..., u(t-nk-nb+1) y C = [1, c1, ..., cnc].
+type DiscreteModel struct {
+	Structure      string    `json:"structure"`
+	Na             int       `json:"na"`
+	Nb             int       `json:"nb"`
+	Nc             int       `json:"nc,omitempty"`
+	Nk             int       `json:"nk"`
+	A              []float64 `json:"a"`
+	B              []float64 `json:"b"`
+	C              []float64 `json:"c,omitempty"`
+	SamplingPeriod float64   `json:"sampling_period"`
+	NoiseVariance  float64   `json:"noise_variance"` // Varianza de los errores de predicción
+	AIC            float64   `json:"aic"`
+	BIC            float64   `json:"bic"`
+	FitPercent     float64   `json:"fit_percent"` // Ajuste de la simulación de B/A sobre la salida
+	Samples        int       `json:"samples"`     // Muestras usadas en el criterio
+}
+
+// Params devuelve el número de parámetros estimados
+func (m *DiscreteModel) Params() int {
+	return m.Na + m.Nb + m.Nc
+}
+
+// TransferFunction devuelve B(z)/A(z) en potencias descendentes de z, con los polos en el
+// origen que introduce el retardo nk
+func (m *DiscreteModel) TransferFunction() DiscreteTransferFunction {
+	n := max(m.Na, m.Nk+m.Nb-1)
+	den, num := make([]float64, n+1), make([]float64, n+1)
+	copy(den, m.A)
+	copy(num[m.Nk:], m.B)
+	return DiscreteTransferFunction{Num: trimPoly(num), Den: den, SamplingPeriod: m.SamplingPeriod}
+}
+
+// Simulate devuelve la salida de B(q)/A(q) ante u con condiciones iniciales nulas
+func (m *DiscreteModel) Simulate(u []float64) []float64 {
+	return filterDiscrete(m.A, m.B, m.Nk, u)
+}
+
+// criterion devuelve el valor del criterio de información indicado
+func (m *DiscreteModel) criterion(name string) float64 {
+	if name == CriterionAIC {
+		return m.AIC
+	}
+	return m.BIC
+}
+
+// DiscreteCandidate resume un modelo evaluado en la selección de orden
+type DiscreteCandidate struct {
+	Na         int     `json:"na"`
+	Nb         int     `json:"nb"`
+	Nc         int     `json:"nc,omitempty"`
+	Nk         int     `json:"nk"`
+	AIC        float64 `json:"aic"`
+	BIC        float64 `json:"bic"`
+	FitPercent float64 `json:"fit_percent"`
+}
+
+// DiscreteIdentification es el modelo elegido y los candidatos ordenados por el criterio
+type DiscreteIdentification struct {
+	Model      *DiscreteModel      `json:"model"`
+	Criterion  string              `json:"criterion"`
+	Candidates []DiscreteCandidate `json:"candidates"`
+}
+
+// IdentifyDiscrete identifica un modelo discreto a partir de la entrada u y la salida y
+// (variables de desviación) muestreadas con período ts. Los órdenes que las opciones dejan
+// libres se eligen minimizando AIC o BIC; si no se indica nk, se prueban el retardo
+// delayGuess (en muestras) y sus vecinos con modelos de orden 2 de la misma estructura y se
+// conserva el de menor error de predicción.
+func IdentifyDiscrete(y, u []float64, ts float64, options DiscreteOptions, delayGuess int) (*DiscreteIdentification, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if len(y) != len(u) {
+		return nil, errors.New("la entrada y la salida deben tener la misma longitud")
+	}
+	if ts <= 0 || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return nil, errors.New("el período de muestreo debe ser positivo")
+	}
+	structure, criterion := options.structure(), options.criterion()
+
+	estimate := func(na, nb, nk int) (*DiscreteModel, error) {
+		switch structure {
+		case DiscreteARMAX:
+			nc := options.Nc
+			if nc == 0 {
+				nc = na
+			}
+			return EstimateARMAX(y, u, na, nb, nc, nk)
+		case DiscreteOE:
+			return EstimateOE(y, u, na, nb, nk)
+		default:
+			return EstimateARX(y, u, na, nb, nk)
+		}
+	}
+
+	nk := 0
+	if options.Nk != nil {
+		nk = *options.Nk
+	} else {
+		na, nb := max(options.Na, 2), max(options.Nb, 2)
+		bestLoss := math.Inf(1)
+		for k := max(delayGuess-delaySearchWidth, 0); k <= min(delayGuess+delaySearchWidth, MaxDiscreteDelay); k++ {
+			m, err := estimate(na, nb, k)
+			if err == nil && m.NoiseVariance < bestLoss {
+				nk, bestLoss = k, m.NoiseVariance
+			}
+		}
+		if math.IsInf(bestLoss, 1) {
+			return nil, errors.New("no se pudo estimar el retardo: datos insuficientes o sin excitación")
+		}
+	}
+
+	naRange := orderRange(options.Na, 1, options.maxOrder())
+	identification := &DiscreteIdentification{Criterion: criterion}
+	var lastErr error
+	for _, na := range naRange {
+		for _, nb := range orderRange(options.Nb, 1, max(na, 1)) {
+			m, err := estimate(na, nb, nk)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			m.SamplingPeriod = ts
+			identification.Candidates = append(identification.Candidates, DiscreteCandidate{
+				Na: m.Na, Nb: m.Nb, Nc: m.Nc, Nk: m.Nk, AIC: m.AIC, BIC: m.BIC, FitPercent: m.FitPercent,
+			})
+			if identification.Model == nil || m.criterion(criterion) < identification.Model.criterion(criterion) {
+				identification.Model = m
+			}
+		}
+	}
+	if identification.Model == nil {
+		if lastErr == nil {
+			lastErr = errors.New("ningún orden es válido")
+		}
+		return nil, fmt.Errorf("no se pudo identificar el modelo discreto: %w", lastErr)
+	}
+
+	sort.SliceStable(identification.Candidates, func(i, j int) bool {
+		ci, cj := identification.Candidates[i], identification.Candidates[j]
+		if criterion == CriterionAIC {
+			return ci.AIC < cj.AIC
+		}
+		return ci.BIC < cj.BIC
+	})
+	return identification, nil
+}
+
+// orderRange devuelve [fixed] si el orden está fijado o el intervalo [low, high] si no
+func orderRange(fixed, low, high int) []int {
+	if fixed > 0 {
+		return []int{fixed}
+	}
+	var orders []int
+	for o := low; o <= high; o++ {
+		orders = append(orders, o)
+	}
+	return orders
+}
+
+// EstimateARX estima un modelo ARX por mínimos cuadrados (ecuaciones normales) con el
+// regresor φ(t) = [-y(t-1) ... -y(t-na), u(t-nk) ... u(t-nk-nb+1)]
+func EstimateARX(y, u []float64, na, nb, nk int) (*DiscreteModel, error) {
+	start, err := checkDiscreteOrders(len(y), na, nb, 0, nk)
+	if err != nil {
+		return nil, err
+	}
+	p := na + nb
+	phi := make([]float64, p)
+	lhs, rhs := newMatrix(p, p), make([]float64, p)
+	for t := start; t < len(y); t++ {
+		arxRegressor(phi, y, u, t, na, nb, nk)
+		for i := 0; i < p; i++ {
+			rhs[i] += phi[i] * y[t]
+			for j := i; j < p; j++ {
+				lhs[i][j] += phi[i] * phi[j]
+			}
+		}
+	}
+	for i := 0; i < p; i++ {
+		for j := 0; j < i; j++ {
+			lhs[i][j] = lhs[j][i]
+		}
+	}
+	theta, err := solveLinear(lhs, rhs)
+	if err != nil {
+		return nil, errors.New("el regresor ARX es singular: la entrada no excita el orden pedido")
+	}
+
+	residuals := make([]float64, 0, len(y)-start)
+	for t := start; t < len(y); t++ {
+		arxRegressor(phi, y, u, t, na, nb, nk)
+		e := y[t]
+		for i := range phi {
+			e -= phi[i] * theta[i]
+		}
+		residuals = append(residuals, e)
+	}
+
+	m := &DiscreteModel{
+		Structure: DiscreteARX, Na: na, Nb: nb, Nk: nk,
+		A: append([]float64{1}, theta[:na]...),
+		B: append([]float64(nil), theta[na:]...),
+	}
+	return m, m.finish(y, u, residuals)
+}
+
+// arxRegressor rellena φ(t); las muestras anteriores al inicio se toman nulas
+func arxRegressor(phi, y, u []float64, t, na, nb, nk int) {
+	for i := 1; i <= na; i++ {
+		phi[i-1] = -sampleAt(y, t-i)
+	}
+	for j := 0; j < nb; j++ {
+		phi[na+j] = sampleAt(u, t-nk-j)
+	}
+}
+
+// EstimateARMAX estima un modelo ARMAX por el método del error de predicción: parte del ARX
+// del mismo orden con C = 1 y minimiza los errores e = (A·y - B·u)/C con Levenberg–Marquardt
+func EstimateARMAX(y, u []float64, na, nb, nc, nk int) (*DiscreteModel, error) {
+	start, err := checkDiscreteOrders(len(y), na, nb, nc, nk)
+	if err != nil {
+		return nil, err
+	}
+	if nc < 1 {
+		return nil, errors.New("nc debe ser al menos 1 en un modelo ARMAX")
+	}
+	initial, err := EstimateARX(y, u, na, nb, nk)
+	if err != nil {
+		return nil, err
+	}
+	p0 := append(append(append([]float64(nil), initial.A[1:]...), initial.B...), make([]float64, nc)...)
+
+	residual := func(p []float64) []float64 {
+		a, b, c := p[:na], p[na:na+nb], p[na+nb:]
+		e := make([]float64, len(y))
+		for t := start; t < len(y); t++ {
+			v := y[t]
+			for i := 1; i <= na; i++ {
+				v += a[i-1] * sampleAt(y, t-i)
+			}
+			for j := 0; j < nb; j++ {
+				v -= b[j] * sampleAt(u, t-nk-j)
+			}
+			for k := 1; k <= nc; k++ {
+				v -= c[k-1] * sampleAt(e, t-k)
+			}
+			e[t] = v
+		}
+		return e[start:]
+	}
+	res, err := LevenbergMarquardt(residual, p0, discreteIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DiscreteModel{
+		Structure: DiscreteARMAX, Na: na, Nb: nb, Nc: nc, Nk: nk,
+		A: append([]float64{1}, res.Params[:na]...),
+		B: append([]float64(nil), res.Params[na:na+nb]...),
+		C: append([]float64{1}, res.Params[na+nb:]...),
+	}
+	if !discreteStable(m.C) {
+		return nil, errors.New("el polinomio C estimado no es estable")
+	}
+	return m, m.finish(y, u, res.Residuals)
+}
+
+// EstimateOE estima un modelo de error de salida minimizando y - B/F·u con
+// Levenberg–Marquardt a partir del ARX del mismo orden
+func EstimateOE(y, u []float64, nf, nb, nk int) (*DiscreteModel, error) {
+	start, err := checkDiscreteOrders(len(y), nf, nb, 0, nk)
+	if err != nil {
+		return nil, err
+	}
+	if nf < 1 {
+		return nil, errors.New("nf debe ser al menos 1 en un modelo OE")
+	}
+	initial, err := EstimateARX(y, u, nf, nb, nk)
+	if err != nil {
+		return nil, err
+	}
+	f0 := initial.A
+	if !discreteStable(f0) {
+		// Un ARX inestable no sirve para simular: se parte de polos en 0.5
+		f0 = polyFromRoots(repeatedRoot(0.5, nf))
+	}
+	p0 := append(append([]float64(nil), f0[1:]...), initial.B...)
+
+	residual := func(p []float64) []float64 {
+		yHat := filterDiscrete(append([]float64{1}, p[:nf]...), p[nf:], nk, u)
+		e := make([]float64, len(y)-start)
+		for t := start; t < len(y); t++ {
+			e[t-start] = y[t] - yHat[t]
+		}
+		return e
+	}
+	res, err := LevenbergMarquardt(residual, p0, discreteIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DiscreteModel{
+		Structure: DiscreteOE, Na: nf, Nb: nb, Nk: nk,
+		A: append([]float64{1}, res.Params[:nf]...),
+		B: append([]float64(nil), res.Params[nf:]...),
+	}
+	if !discreteStable(m.A) {
+		return nil, errors.New("el polinomio F estimado no es estable")
+	}
+	return m, m.finish(y, u, res.Residuals)
+}
+
+// checkDiscreteOrders valida los órdenes frente al número de muestras y devuelve la primera
+// muestra con el regresor completo
+func checkDiscreteOrders(n, na, nb, nc, nk int) (int, error) {
+	if na < 0 || nc < 0 || nb < 1 || nk < 0 {
+		return 0, errors.New("órdenes no válidos: nb debe ser al menos 1 y na, nc y nk no negativos")
+	}
+	start := max(na, nc, nk+nb-1)
+	if n-start <= 2*(na+nb+nc) {
+		return 0, errors.New("hay demasiado pocas muestras para los órdenes pedidos")
+	}
+	return start, nil
+}
+
+// finish calcula la varianza del ruido, los criterios de información y el ajuste en simulación
+func (m *DiscreteModel) finish(y, u, residuals []float64) error {
+	n := float64(len(residuals))
+	m.Samples = len(residuals)
+	m.NoiseVariance = sumSquares(residuals) / n
+	if math.IsNaN(m.NoiseVariance) || math.IsInf(m.NoiseVariance, 0) {
+		return errors.New("los errores de predicción no son finitos")
+	}
+	loss := n * math.Log(math.Max(m.NoiseVariance, 1e-300))
+	p := float64(m.Params())
+	m.AIC = loss + 2*p
+	m.BIC = loss + p*math.Log(n)
+
+	if quality, err := EvaluateFit(y, m.Simulate(u)); err == nil {
+		m.FitPercent = quality.FitPercent
+	} else {
+		m.FitPercent = math.Inf(-1)
+	}
+	if math.IsInf(m.FitPercent, 0) || math.IsNaN(m.FitPercent) {
+		// Un modelo que diverge en simulación se conserva con ajuste nulo
+		m.FitPercent = 0
+	}
+	return nil
+}
+
+// filterDiscrete calcula y = B(q)/A(q)·u(t-nk) con condiciones iniciales nulas
+func filterDiscrete(a, b []float64, nk int, u []float64) []float64 {
+	y := make([]float64, len(u))
+	for t := range u {
+		v := 0.0
+		for j := range b {
+			v += b[j] * sampleAt(u, t-nk-j)
+		}
+		for i := 1; i < len(a); i++ {
+			v -= a[i] * sampleAt(y, t-i)
+		}
+		y[t] = v
+	}
+	return y
+}
+
+// sampleAt devuelve x[t], o 0 antes del inicio del registro
+func sampleAt(x []float64, t int) float64 {
+	if t < 0 {
+		return 0
+	}
+	return x[t]
+}
+
+// discreteStable indica si las raíces del polinomio mónico en q⁻¹ [1, p1, ..., pn] (es
+// decir, de z^n + p1·z^(n-1) + ... + pn) están dentro del círculo unidad
+func discreteStable(p []float64) bool {
+	for _, r := range polyRoots(p) {
+		if cmplx.Abs(r) >= 1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Métodos de estimación del valor final
+const (
+	FinalValueTailAverage = "tail_average"        // Promedio del tramo final estacionario
+	FinalValueModel       = "model_extrapolation" // Valor final del modelo identificado
+)
+
+// maxStationaryDrift es el cambio máximo de la recta ajustada al tramo final, como fracción del
+// salto, para considerar que la respuesta llegó a su valor final
+const maxStationaryDrift = 0.02
+
+// FinalValueEstimate es el valor final estimado de una respuesta al escalón y la ganancia
+// estática que resulta de él
+type FinalValueEstimate struct {
+	InitialValue float64 `json:"initial_value"`
+	FinalValue   float64 `json:"final_value"`
+	TailAverage  float64 `json:"tail_average"` // Promedio del último 10% de las muestras
+	TailDrift    float64 `json:"tail_drift"`   // Cambio de la recta ajustada al tramo final (fracción del salto)
+	Stationary   bool    `json:"stationary"`   // El tramo final no tiene deriva apreciable
+	Method       string  `json:"method"`
+	DCGain       float64 `json:"dc_gain"` // K = (y∞ - y0) / u
+}
+
+// EstimateFinalValue estima el valor final de la respuesta al escalón (t, y) de amplitud u.
+// Si el último 10% de las muestras es estacionario (la recta ajustada cambia menos del 2% del
+// salto a lo largo del tramo) el valor final es su promedio; si no, la grabación terminó antes
+// del establecimiento y se extrapola con el valor final del modelo identificado, cuando existe.
+func EstimateFinalValue(t, y []float64, u float64, model *SecondOrderModel) (*FinalValueEstimate, error) {
+	if len(t) != len(y) || len(t) < 10 {
+		return nil, errors.New("datos insuficientes para estimar el valor final")
+	}
+	if u == 0 {
+		return nil, errors.New("la amplitud del escalón no puede ser cero")
+	}
+
+	initial, final := StepLevels(y)
+	estimate := &FinalValueEstimate{InitialValue: initial, FinalValue: final, TailAverage: final, Method: FinalValueTailAverage}
+
+	// Deriva del tramo final: pendiente de mínimos cuadrados por la duración del tramo
+	tail := max(len(y)/10, 2)
+	tt, ty := t[len(t)-tail:], y[len(y)-tail:]
+	if step := final - initial; step != 0 {
+		estimate.TailDrift = math.Abs(linearSlope(tt, ty)*(tt[len(tt)-1]-tt[0])) / math.Abs(step)
+	}
+	estimate.Stationary = estimate.TailDrift <= maxStationaryDrift
+
+	if !estimate.Stationary && model != nil && !math.IsNaN(model.FinalValue) && !math.IsInf(model.FinalValue, 0) {
+		estimate.FinalValue = model.FinalValue
+		estimate.Method = FinalValueModel
+	}
+	estimate.DCGain = (estimate.FinalValue - initial) / u
+	return estimate, nil
+}
+
+// linearSlope es la pendiente de la recta de mínimos cuadrados de (x, y)
+func linearSlope(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var sxy, sxx float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+	}
+	if sxx == 0 {
+		return 0
+	}
+	return sxy / sxx
+}
@@ -0,0 +1,353 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxFitOrder es el orden máximo del denominador admitido por FitTransferFunction
+const MaxFitOrder = 6
+
+// confidenceZ es el cuantil normal para intervalos de confianza del 95%
+const confidenceZ = 1.959964
+
+// ModelStructure describe la estructura de la función de transferencia a ajustar
+type ModelStructure struct {
+	NumOrder int  `json:"num_order"` // Grado del numerador
+	DenOrder int  `json:"den_order"` // Grado del denominador (orden del sistema)
+	Delay    bool `json:"delay"`     // Estimar un retardo puro
+}
+
+// Validate comprueba que la estructura sea propia y de orden razonable
+func (s ModelStructure) Validate() error {
+	if s.DenOrder < 1 || s.DenOrder > MaxFitOrder {
+		return fmt.Errorf("den_order debe estar entre 1 y %d", MaxFitOrder)
+	}
+	if s.NumOrder < 0 || s.NumOrder > s.DenOrder {
+		return errors.New("num_order debe estar entre 0 y den_order")
+	}
+	return nil
+}
+
+// numParams devuelve el número de parámetros libres de la estructura
+func (s ModelStructure) numParams() int {
+	n := s.DenOrder + s.NumOrder + 1
+	if s.Delay {
+		n++
+	}
+	return n
+}
+
+// FitParameter es un parámetro estimado con su error estándar e intervalo de confianza del 95%
+type FitParameter struct {
+	Name   string      `json:"name"`
+	Value  float64     `json:"value"`
+	StdErr *float64    `json:"std_err,omitempty"`
+	CI95   *[2]float64 `json:"ci95,omitempty"`
+}
+
+// TransferFunctionFit es el resultado del ajuste de una función de transferencia
+type TransferFunctionFit struct {
+	Structure      ModelStructure   `json:"structure"`
+	Model          TransferFunction `json:"model"`
+	Numerator      []FitParameter   `json:"numerator"`   // b0..bm (potencias descendentes)
+	Denominator    []FitParameter   `json:"denominator"` // a1..an (denominador mónico)
+	Delay          *FitParameter    `json:"delay,omitempty"`
+	Poles          []Complex        `json:"poles"`
+	Zeros          []Complex        `json:"zeros"`
+	DCGain         float64          `json:"dc_gain"`
+	Stable         bool             `json:"stable"`
+	StepTime       float64          `json:"step_time"` // Instante del escalón (origen del modelo)
+	InitialValue   float64          `json:"initial_value"`
+	InputAmplitude float64          `json:"input_amplitude,omitempty"` // Amplitud del escalón (0 si la entrada es medida)
+	ResidualRMS    float64          `json:"residual_rms"`
+	Iterations     int              `json:"iterations"`
+	Converged      bool             `json:"converged"`
+}
+
+// FitTransferFunction ajusta por Levenberg–Marquardt una función de transferencia con la
+// estructura dada a la respuesta (t, y) ante un escalón de amplitud u aplicado en t[0].
+// El ajuste se hace en tiempo normalizado por la duración del registro para que los
+// coeficientes queden bien escalados, y se prueban varias estimaciones iniciales.
+func FitTransferFunction(t, y []float64, u float64, structure ModelStructure) (*TransferFunctionFit, error) {
+	if err := structure.Validate(); err != nil {
+		return nil, err
+	}
+	if len(t) != len(y) || len(t) < structure.numParams()+5 {
+		return nil, errors.New("datos insuficientes para la estructura solicitada")
+	}
+	if u == 0 {
+		u = 1
+	}
+
+	duration := t[len(t)-1] - t[0]
+	if duration <= 0 {
+		return nil, errors.New("el vector de tiempo debe ser creciente")
+	}
+
+	initial, final := StepLevels(y)
+	gain := (final - initial) / u
+	if math.Abs(final-initial) < 1e-12 {
+		return nil, errors.New("la señal no presenta un cambio apreciable tras el escalón")
+	}
+
+	// Tiempo normalizado en [0, 1]
+	tn := make([]float64, len(t))
+	for i := range t {
+		tn[i] = (t[i] - t[0]) / duration
+	}
+
+	n := structure.DenOrder
+	residual := fitResidual(structure, y, func(tf TransferFunction) []float64 {
+		step := tf.StepResponse(tn)
+		for i := range step {
+			step[i] = initial + u*step[i]
+		}
+		return step
+	})
+
+	// Estimaciones iniciales: n polos reales iguales cuyo tiempo medio coincide con el
+	// tiempo al 63% de la respuesta, escalados por varios factores
+	yn := make([]float64, len(y))
+	for i := range y {
+		yn[i] = (y[i] - initial) / (final - initial)
+	}
+	onset := onsetTime(tn, yn)
+	t63 := 1.0 / 3
+	for i := range yn {
+		if yn[i] >= 0.632 {
+			t63 = tn[i]
+			break
+		}
+	}
+	lag := t63
+	if structure.Delay {
+		lag = t63 - onset
+	}
+	if lag <= 0 {
+		lag = 0.05
+	}
+
+	var starts [][]float64
+	for _, factor := range []float64{1, 0.5, 2} {
+		starts = append(starts, initialParams(structure, factor*float64(n)/lag, gain, onset))
+	}
+
+	best := bestLMFit(residual, starts)
+	if best == nil {
+		return nil, errors.New("no se pudo ajustar la función de transferencia")
+	}
+
+	return buildFitResult(best, structure, duration, t[0], initial, u), nil
+}
+
+// FitTransferFunctionInput ajusta una función de transferencia a la respuesta y ante una
+// entrada medida arbitraria u (PRBS, chirp, rampa...), ambas muestreadas en t. Se ajustan
+// las desviaciones respecto a los valores iniciales, suponiendo el sistema en reposo en t[0].
+func FitTransferFunctionInput(t, u, y []float64, structure ModelStructure) (*TransferFunctionFit, error) {
+	if err := structure.Validate(); err != nil {
+		return nil, err
+	}
+	if len(t) != len(y) || len(t) != len(u) || len(t) < structure.numParams()+5 {
+		return nil, errors.New("datos insuficientes para la estructura solicitada")
+	}
+	duration := t[len(t)-1] - t[0]
+	if duration <= 0 {
+		return nil, errors.New("el vector de tiempo debe ser creciente")
+	}
+
+	initial, _ := StepLevels(y)
+	u0 := u[0]
+	tn := make([]float64, len(t))
+	du := make([]float64, len(u))
+	excited := false
+	for i := range t {
+		tn[i] = (t[i] - t[0]) / duration
+		du[i] = u[i] - u0
+		if du[i] != 0 {
+			excited = true
+		}
+	}
+	if !excited {
+		return nil, errors.New("la entrada no presenta variaciones")
+	}
+
+	n := structure.DenOrder
+	residual := fitResidual(structure, y, func(tf TransferFunction) []float64 {
+		out := tf.Lsim(tn, du)
+		for i := range out {
+			out[i] += initial
+		}
+		return out
+	})
+
+	// Estimaciones iniciales: n polos reales iguales en una escala de velocidades, con la
+	// ganancia estimada por mínimos cuadrados para cada una; se refinan las dos mejores
+	type candidate struct {
+		params []float64
+		sse    float64
+	}
+	var candidates []candidate
+	for _, rate := range []float64{1, 3, 10, 30, 100, 300} {
+		c := rate * float64(n)
+		den := polyFromRoots(repeatedRoot(-c, n))
+		unit := TransferFunction{Num: []float64{den[n]}, Den: den}
+		sim := unit.Lsim(tn, du)
+
+		num, dot := 0.0, 0.0
+		for i := range sim {
+			num += (y[i] - initial) * sim[i]
+			dot += sim[i] * sim[i]
+		}
+		if dot == 0 {
+			continue
+		}
+		gain := num / dot
+		p0 := initialParams(structure, c, gain, 0)
+		candidates = append(candidates, candidate{params: p0, sse: sumSquares(residual(p0))})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].sse < candidates[j].sse })
+
+	var starts [][]float64
+	for i := 0; i < len(candidates) && i < 2; i++ {
+		starts = append(starts, candidates[i].params)
+	}
+
+	best := bestLMFit(residual, starts)
+	if best == nil {
+		return nil, errors.New("no se pudo ajustar la función de transferencia")
+	}
+
+	return buildFitResult(best, structure, duration, t[0], initial, 0), nil
+}
+
+// fitResidual construye la función de residuos para los parámetros normalizados
+// [a1..an, b0..bm, θ] a partir de una función que simula la salida del modelo
+func fitResidual(structure ModelStructure, y []float64, simulate func(TransferFunction) []float64) func([]float64) []float64 {
+	n, m := structure.DenOrder, structure.NumOrder
+	return func(p []float64) []float64 {
+		r := make([]float64, len(y))
+		delay := 0.0
+		if structure.Delay {
+			delay = p[n+m+1]
+		}
+		if delay < 0 || delay > 1 {
+			for i := range r {
+				r[i] = math.Inf(1)
+			}
+			return r
+		}
+		tf := TransferFunction{Num: p[n : n+m+1], Den: append([]float64{1}, p[:n]...), Delay: delay}
+		out := simulate(tf)
+		for i := range r {
+			r[i] = y[i] - out[i]
+		}
+		return r
+	}
+}
+
+// initialParams genera parámetros normalizados con n polos reales en -c, ganancia estática
+// gain (solo el término independiente del numerador) y retardo delay
+func initialParams(structure ModelStructure, c, gain, delay float64) []float64 {
+	n, m := structure.DenOrder, structure.NumOrder
+	den := polyFromRoots(repeatedRoot(-c, n))
+	p0 := make([]float64, 0, structure.numParams())
+	p0 = append(p0, den[1:]...)
+	for j := 0; j < m; j++ {
+		p0 = append(p0, 0)
+	}
+	p0 = append(p0, gain*den[n])
+	if structure.Delay {
+		p0 = append(p0, delay)
+	}
+	return p0
+}
+
+// bestLMFit ejecuta Levenberg–Marquardt desde cada punto inicial y conserva el de menor error
+func bestLMFit(residual func([]float64) []float64, starts [][]float64) *LMResult {
+	var best *LMResult
+	for _, p0 := range starts {
+		res, err := LevenbergMarquardt(residual, p0, 200)
+		if err != nil {
+			continue
+		}
+		if best == nil || res.SSE < best.SSE {
+			best = res
+		}
+	}
+	return best
+}
+
+// buildFitResult convierte los parámetros normalizados a unidades físicas
+func buildFitResult(res *LMResult, structure ModelStructure, duration, t0, initial, u float64) *TransferFunctionFit {
+	n, m := structure.DenOrder, structure.NumOrder
+
+	// Con t' = t/T, el coeficiente de sᵏ se escala por T^(n-k) respecto al denominador mónico
+	param := func(name string, idx int, scale float64) FitParameter {
+		fp := FitParameter{Name: name, Value: res.Params[idx] * scale}
+		if res.Covariance != nil && res.Covariance[idx][idx] >= 0 {
+			se := math.Sqrt(res.Covariance[idx][idx]) * math.Abs(scale)
+			fp.StdErr = &se
+			fp.CI95 = &[2]float64{fp.Value - confidenceZ*se, fp.Value + confidenceZ*se}
+		}
+		return fp
+	}
+
+	fit := &TransferFunctionFit{
+		Structure:      structure,
+		StepTime:       t0,
+		InitialValue:   initial,
+		InputAmplitude: u,
+		Iterations:     res.Iterations,
+		Converged:      res.Converged,
+		ResidualRMS:    math.Sqrt(res.SSE / float64(len(res.Residuals))),
+	}
+
+	den := []float64{1}
+	for k := 1; k <= n; k++ {
+		p := param(fmt.Sprintf("a%d", k), k-1, math.Pow(duration, -float64(k)))
+		fit.Denominator = append(fit.Denominator, p)
+		den = append(den, p.Value)
+	}
+
+	var num []float64
+	for j := 0; j <= m; j++ {
+		p := param(fmt.Sprintf("b%d", j), n+j, math.Pow(duration, -float64(n-m+j)))
+		fit.Numerator = append(fit.Numerator, p)
+		num = append(num, p.Value)
+	}
+
+	delay := 0.0
+	if structure.Delay {
+		p := param("theta", n+m+1, duration)
+		fit.Delay = &p
+		delay = p.Value
+	}
+
+	fit.Model = TransferFunction{Num: trimPoly(num), Den: den, Delay: delay}
+
+	for _, p := range fit.Model.Poles() {
+		fit.Poles = append(fit.Poles, FromComplex(p))
+	}
+	for _, z := range fit.Model.Zeros() {
+		fit.Zeros = append(fit.Zeros, FromComplex(z))
+	}
+	if fit.Zeros == nil {
+		fit.Zeros = []Complex{}
+	}
+	fit.DCGain = fit.Model.DCGain()
+	fit.Stable = fit.Model.IsStable()
+
+	return fit
+}
+
+// repeatedRoot devuelve n copias de la raíz r
+func repeatedRoot(r float64, n int) []complex128 {
+	roots := make([]complex128, n)
+	for i := range roots {
+		roots[i] = complex(r, 0)
+	}
+	return roots
+}
@@ -0,0 +1,221 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// FrequencyPoint es la respuesta en frecuencia G(jω) en una frecuencia
+type FrequencyPoint struct {
+	W           float64 `json:"w"`            // Frecuencia (rad/s)
+	MagnitudeDB float64 `json:"magnitude_db"` // 20·log10|G(jω)|
+	PhaseDeg    float64 `json:"phase_deg"`    // Fase desenrollada (grados)
+	Real        float64 `json:"real"`         // Parte real (diagrama de Nyquist)
+	Imag        float64 `json:"imag"`         // Parte imaginaria (diagrama de Nyquist)
+}
+
+// StabilityMargins son los márgenes de estabilidad del lazo abierto G(s) con realimentación unitaria.
+// Los campos son nil cuando el cruce correspondiente no existe.
+type StabilityMargins struct {
+	DCGainDB          *float64 `json:"dc_gain_db,omitempty"` // nil si hay polos o ceros en el origen
+	GainMarginDB      *float64 `json:"gain_margin_db,omitempty"`
+	PhaseCrossoverW   *float64 `json:"phase_crossover_w,omitempty"` // Frecuencia donde la fase cruza -180°
+	PhaseMarginDeg    *float64 `json:"phase_margin_deg,omitempty"`
+	GainCrossoverW    *float64 `json:"gain_crossover_w,omitempty"` // Frecuencia donde |G| = 0 dB
+	BandwidthW        *float64 `json:"bandwidth_w,omitempty"`      // Primera frecuencia con |G| 3 dB bajo la ganancia estática
+	StableClosedLoop  *bool    `json:"stable_closed_loop,omitempty"`
+	ResonantPeakDB    *float64 `json:"resonant_peak_db,omitempty"`
+	ResonantFrequency *float64 `json:"resonant_frequency,omitempty"`
+}
+
+// DefaultFrequencyRange propone un rango de frecuencias que cubre dos décadas por debajo y
+// por encima de los polos y ceros del sistema
+func DefaultFrequencyRange(tf TransferFunction) (wmin, wmax float64) {
+	lo, hi := math.Inf(1), 0.0
+	for _, r := range append(tf.Poles(), tf.Zeros()...) {
+		if m := cmplx.Abs(r); m > 1e-9 {
+			lo = math.Min(lo, m)
+			hi = math.Max(hi, m)
+		}
+	}
+	if tf.Delay > 0 {
+		lo = math.Min(lo, 1/tf.Delay)
+		hi = math.Max(hi, 1/tf.Delay)
+	}
+	if math.IsInf(lo, 1) {
+		return 0.01, 100
+	}
+	return math.Pow(10, math.Floor(math.Log10(lo))-2), math.Pow(10, math.Ceil(math.Log10(hi))+2)
+}
+
+// LogSpace genera n frecuencias espaciadas logarítmicamente entre wmin y wmax
+func LogSpace(wmin, wmax float64, n int) []float64 {
+	if n < 2 {
+		return []float64{wmin}
+	}
+	w := make([]float64, n)
+	lmin, lmax := math.Log10(wmin), math.Log10(wmax)
+	for i := range w {
+		w[i] = math.Pow(10, lmin+(lmax-lmin)*float64(i)/float64(n-1))
+	}
+	return w
+}
+
+// Bode evalúa G(jω) en las frecuencias dadas (en orden ascendente) con la fase desenrollada
+func Bode(tf TransferFunction, w []float64) []FrequencyPoint {
+	points := make([]FrequencyPoint, len(w))
+	prevPhase := 0.0
+	for i, wi := range w {
+		g := tf.Eval(complex(0, wi))
+		phase := cmplx.Phase(g) * 180 / math.Pi
+		if i == 0 {
+			phase = initialPhase(tf, g)
+		} else {
+			phase = unwrapDeg(prevPhase, phase)
+		}
+		prevPhase = phase
+
+		points[i] = FrequencyPoint{
+			W:           wi,
+			MagnitudeDB: 20 * math.Log10(cmplx.Abs(g)),
+			PhaseDeg:    phase,
+			Real:        real(g),
+			Imag:        imag(g),
+		}
+	}
+	return points
+}
+
+// initialPhase elige la rama de la fase a baja frecuencia: ±90° por cada cero o polo en el
+// origen y 180° de desfase si los coeficientes de menor orden tienen signos opuestos
+func initialPhase(tf TransferFunction, g complex128) float64 {
+	num, zeroOrder := lowestCoefficient(tf.Num)
+	den, poleOrder := lowestCoefficient(tf.Den)
+	expected := 90 * float64(zeroOrder-poleOrder)
+	if num*den < 0 {
+		expected -= 180
+	}
+	return unwrapDeg(expected, cmplx.Phase(g)*180/math.Pi)
+}
+
+// lowestCoefficient devuelve el coeficiente no nulo de menor potencia de s y esa potencia
+func lowestCoefficient(p []float64) (float64, int) {
+	for k := len(p) - 1; k >= 0; k-- {
+		if p[k] != 0 {
+			return p[k], len(p) - 1 - k
+		}
+	}
+	return 0, 0
+}
+
+// unwrapDeg devuelve el ángulo equivalente a phase más cercano a reference
+func unwrapDeg(reference, phase float64) float64 {
+	return phase + 360*math.Round((reference-phase)/360)
+}
+
+// Margins calcula los márgenes de ganancia y fase, las frecuencias de cruce, el ancho de
+// banda y el pico de resonancia sobre una malla densa con refinamiento por bisección
+func Margins(tf TransferFunction) (*StabilityMargins, error) {
+	if len(tf.Den) == 0 {
+		return nil, errors.New("función de transferencia vacía")
+	}
+
+	wmin, wmax := DefaultFrequencyRange(tf)
+	w := LogSpace(wmin, wmax, 2000)
+	pts := Bode(tf, w)
+
+	margins := &StabilityMargins{}
+	if dcGain := math.Abs(tf.DCGain()); dcGain > 0 && !math.IsInf(dcGain, 0) {
+		dc := 20 * math.Log10(dcGain)
+		margins.DCGainDB = &dc
+	}
+
+	// Cruce de ganancia: primer paso de |G| por 0 dB hacia abajo
+	for i := 1; i < len(pts); i++ {
+		if pts[i-1].MagnitudeDB >= 0 && pts[i].MagnitudeDB < 0 {
+			wc := bisect(w[i-1], w[i], func(x float64) float64 { return 20 * math.Log10(cmplx.Abs(tf.Eval(complex(0, x)))) })
+			pm := 180 + unwrapDeg(pts[i-1].PhaseDeg, cmplx.Phase(tf.Eval(complex(0, wc)))*180/math.Pi)
+			margins.GainCrossoverW = &wc
+			margins.PhaseMarginDeg = &pm
+			break
+		}
+	}
+
+	// Cruce de fase: primer paso de la fase por -180° (módulo 360°)
+phaseSearch:
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1].PhaseDeg, pts[i].PhaseDeg
+		if a == b {
+			continue
+		}
+		// Los dos valores -180° + k·360° que acotan la fase en el extremo izquierdo
+		below := -180 + 360*math.Floor((a+180)/360)
+		for _, target := range []float64{below, below + 360} {
+			if (a-target)*(b-target) > 0 {
+				continue
+			}
+			phaseAt := func(x float64) float64 {
+				return unwrapDeg(a, cmplx.Phase(tf.Eval(complex(0, x)))*180/math.Pi) - target
+			}
+			wp := bisect(w[i-1], w[i], phaseAt)
+			gm := -20 * math.Log10(cmplx.Abs(tf.Eval(complex(0, wp))))
+			margins.PhaseCrossoverW = &wp
+			margins.GainMarginDB = &gm
+			break phaseSearch
+		}
+	}
+
+	// Ancho de banda: primera frecuencia 3 dB por debajo de la ganancia estática
+	if margins.DCGainDB != nil {
+		ref := *margins.DCGainDB - 3
+		for i := 1; i < len(pts); i++ {
+			if pts[i-1].MagnitudeDB >= ref && pts[i].MagnitudeDB < ref {
+				bw := bisect(w[i-1], w[i], func(x float64) float64 { return 20*math.Log10(cmplx.Abs(tf.Eval(complex(0, x)))) - ref })
+				margins.BandwidthW = &bw
+				break
+			}
+		}
+	}
+
+	// Pico de resonancia (solo si supera la ganancia estática)
+	peak := 0
+	for i := range pts {
+		if pts[i].MagnitudeDB > pts[peak].MagnitudeDB {
+			peak = i
+		}
+	}
+	if margins.DCGainDB != nil && peak > 0 && peak < len(pts)-1 && pts[peak].MagnitudeDB > *margins.DCGainDB+0.01 {
+		mr, wr := pts[peak].MagnitudeDB, pts[peak].W
+		margins.ResonantPeakDB = &mr
+		margins.ResonantFrequency = &wr
+	}
+
+	// Estabilidad en lazo cerrado con realimentación unitaria (criterio de los márgenes,
+	// válido para plantas estables de fase mínima sin cruces múltiples)
+	if tf.IsStable() {
+		stable := (margins.GainMarginDB == nil || *margins.GainMarginDB > 0) &&
+			(margins.PhaseMarginDeg == nil || *margins.PhaseMarginDeg > 0)
+		margins.StableClosedLoop = &stable
+	}
+
+	return margins, nil
+}
+
+// bisect encuentra la raíz de f en [a, b] (con cambio de signo) en escala logarítmica
+func bisect(a, b float64, f func(float64) float64) float64 {
+	fa := f(a)
+	for i := 0; i < 60; i++ {
+		m := math.Sqrt(a * b)
+		fm := f(m)
+		if fa*fm <= 0 {
+			b = m
+		} else {
+			a, fa = m, fm
+		}
+		if b/a-1 < 1e-10 {
+			break
+		}
+	}
+	return math.Sqrt(a * b)
+}
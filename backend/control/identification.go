@@ -0,0 +1,359 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Métodos de identificación de segundo orden
+const (
+	MethodOvershootPeakTime = "overshoot_peak_time"
+	MethodTwoTimeConstants  = "two_time_constants"
+	MethodInputOutputFit    = "input_output_fit"
+)
+
+// Complex representa un número complejo (polo o cero) serializable a JSON
+type Complex struct {
+	Real float64 `json:"real"`
+	Imag float64 `json:"imag"`
+}
+
+// ToComplex convierte a complex128
+func (c Complex) ToComplex() complex128 {
+	return complex(c.Real, c.Imag)
+}
+
+// FromComplex convierte un complex128 a Complex
+func FromComplex(z complex128) Complex {
+	return Complex{Real: real(z), Imag: imag(z)}
+}
+
+// SecondOrderModel es un modelo K·ωn²/(s²+2ζωn·s+ωn²) identificado a partir de la respuesta al escalón
+type SecondOrderModel struct {
+	Method       string    `json:"method"`
+	Gain         float64   `json:"gain"`           // Ganancia estática K
+	Wn           float64   `json:"wn"`             // Frecuencia natural (rad/s)
+	Zeta         float64   `json:"zeta"`           // Factor de amortiguamiento
+	Tau1         float64   `json:"tau1,omitempty"` // Constante de tiempo lenta (solo sobreamortiguado)
+	Tau2         float64   `json:"tau2,omitempty"` // Constante de tiempo rápida (solo sobreamortiguado)
+	StepTime     float64   `json:"step_time"`      // Instante estimado de inicio de la respuesta
+	InitialValue float64   `json:"initial_value"`
+	FinalValue   float64   `json:"final_value"`
+	RMS          float64   `json:"rms"` // Error cuadrático medio del ajuste (unidades de salida)
+	Poles        []Complex `json:"poles"`
+}
+
+// IsUnderdamped indica si el modelo tiene polos complejos conjugados
+func (m *SecondOrderModel) IsUnderdamped() bool {
+	return m.Zeta < 1
+}
+
+// TransferFunction devuelve G(s) = K·ωn²/(s² + 2ζωn·s + ωn²) con los polos del ajuste
+func (m *SecondOrderModel) TransferFunction() (TransferFunction, error) {
+	if len(m.Poles) != 2 {
+		return TransferFunction{}, errors.New("el modelo analítico no tiene dos polos")
+	}
+	poles := []complex128{m.Poles[0].ToComplex(), m.Poles[1].ToComplex()}
+	return NewTransferFunctionZPK(nil, poles, m.Gain, 0)
+}
+
+// StepResponse evalúa la respuesta del modelo a un escalón de amplitud u en los instantes dados
+func (m *SecondOrderModel) StepResponse(t []float64, u float64) []float64 {
+	y := make([]float64, len(t))
+	for i, ti := range t {
+		y[i] = m.InitialValue + m.Gain*u*m.normalizedStep(ti-m.StepTime)
+	}
+	return y
+}
+
+// normalizedStep es la respuesta al escalón unitario con ganancia unitaria en el instante tau
+func (m *SecondOrderModel) normalizedStep(tau float64) float64 {
+	if tau <= 0 {
+		return 0
+	}
+	if m.Tau1 > 0 && m.Tau2 > 0 {
+		return overdampedStep(m.Tau1, m.Tau2, tau)
+	}
+	return underdampedStep(m.Wn, m.Zeta, tau)
+}
+
+// IdentifySecondOrder ajusta un modelo de segundo orden a la respuesta al escalón (t, y)
+// producida por una entrada de amplitud u. Usa sobrepico/tiempo de pico para datos
+// subamortiguados y un ajuste de dos constantes de tiempo para datos sobreamortiguados;
+// en ambos casos refina el resultado por mínimos cuadrados y conserva el de menor error.
+func IdentifySecondOrder(t, y []float64, u float64) (*SecondOrderModel, error) {
+	if len(t) != len(y) || len(t) < 10 {
+		return nil, errors.New("datos insuficientes para identificar un modelo de segundo orden")
+	}
+
+	initial, final := StepLevels(y)
+	delta := final - initial
+	if math.Abs(delta) < 1e-12 || math.Abs(delta) < 1e-6*math.Max(math.Abs(initial), math.Abs(final)) {
+		return nil, errors.New("la señal no presenta un cambio apreciable tras el escalón")
+	}
+	if u == 0 {
+		u = 1
+	}
+
+	// Respuesta normalizada entre 0 y 1
+	yn := make([]float64, len(y))
+	for i := range y {
+		yn[i] = (y[i] - initial) / delta
+	}
+
+	var best *SecondOrderModel
+	if under := fitUnderdamped(t, yn); under != nil {
+		best = under
+	}
+	if over := fitOverdamped(t, yn); over != nil && (best == nil || over.RMS < best.RMS) {
+		best = over
+	}
+	if best == nil {
+		return nil, errors.New("no se pudo ajustar un modelo de segundo orden")
+	}
+
+	// Pasar de unidades normalizadas a unidades de la señal
+	best.InitialValue = initial
+	best.FinalValue = initial + best.FinalValue*delta
+	best.Gain = (best.FinalValue - initial) / u
+	best.RMS *= math.Abs(delta)
+	best.Poles = secondOrderPoles(best)
+
+	return best, nil
+}
+
+// SecondOrderFromFit convierte un ajuste K·a2/(s²+a1·s+a2) (sin ceros) en un modelo de
+// segundo orden. El instante de inicio incluye el retardo estimado y el valor final
+// corresponde a un escalón unitario.
+func SecondOrderFromFit(fit *TransferFunctionFit) (*SecondOrderModel, error) {
+	if fit.Structure.DenOrder != 2 || fit.Structure.NumOrder != 0 {
+		return nil, errors.New("el ajuste no es de segundo orden sin ceros")
+	}
+	a1, a2 := fit.Model.Den[1], fit.Model.Den[2]
+	if a1 <= 0 || a2 <= 0 {
+		return nil, errors.New("el modelo ajustado es inestable")
+	}
+
+	wn := math.Sqrt(a2)
+	model := &SecondOrderModel{
+		Method:       MethodInputOutputFit,
+		Gain:         fit.DCGain,
+		Wn:           wn,
+		Zeta:         a1 / (2 * wn),
+		StepTime:     fit.StepTime + fit.Model.Delay,
+		InitialValue: fit.InitialValue,
+		FinalValue:   fit.InitialValue + fit.DCGain,
+		RMS:          fit.ResidualRMS,
+	}
+	if model.Zeta >= 1 {
+		// Polos reales: constantes de tiempo τ = -1/p
+		disc := math.Sqrt(a1*a1 - 4*a2)
+		model.Tau1 = 2 / (a1 - disc)
+		model.Tau2 = 2 / (a1 + disc)
+	}
+	model.Poles = secondOrderPoles(model)
+	return model, nil
+}
+
+// StepLevels estima el valor inicial (primeras muestras) y final (último 10%) de una respuesta al escalón
+func StepLevels(y []float64) (initial, final float64) {
+	n := len(y)
+	if n == 0 {
+		return 0, 0
+	}
+
+	head := n / 50
+	if head < 1 {
+		head = 1
+	}
+	if head > 10 {
+		head = 10
+	}
+	initial = mean(y[:head])
+
+	tail := n / 10
+	if tail < 1 {
+		tail = 1
+	}
+	final = mean(y[n-tail:])
+
+	return initial, final
+}
+
+// fitUnderdamped ajusta ωn, ζ, el instante de inicio y el valor final (normalizado)
+// partiendo de las fórmulas de sobrepico y tiempo de pico
+func fitUnderdamped(t, yn []float64) *SecondOrderModel {
+	peakIdx := 0
+	for i := range yn {
+		if yn[i] > yn[peakIdx] {
+			peakIdx = i
+		}
+	}
+
+	overshoot := yn[peakIdx] - 1
+	if overshoot <= 0.005 || peakIdx == 0 {
+		return nil
+	}
+
+	t0 := onsetTime(t, yn)
+	tp := t[peakIdx] - t0
+	if tp <= 0 {
+		return nil
+	}
+
+	// ζ = -ln(Mp)/√(π²+ln²Mp), ωn = π/(tp·√(1-ζ²))
+	logMp := math.Log(overshoot)
+	zeta := -logMp / math.Sqrt(math.Pi*math.Pi+logMp*logMp)
+	wn := math.Pi / (tp * math.Sqrt(1-zeta*zeta))
+
+	duration := t[len(t)-1] - t[0]
+	cost := func(p []float64) float64 {
+		w, z, start, fv := math.Exp(p[0]), p[1], p[2], p[3]
+		if z <= 0.001 || z >= 0.999 || start < t[0]-duration || start >= t[peakIdx] {
+			return math.Inf(1)
+		}
+		return meanSquaredError(t, yn, func(tau float64) float64 { return fv * underdampedStep(w, z, tau) }, start)
+	}
+
+	x, c := NelderMead(cost,
+		[]float64{math.Log(wn), zeta, t0, 1},
+		[]float64{0.2, 0.05, 0.02 * duration, 0.02},
+		2000, 1e-12)
+	if math.IsInf(c, 1) {
+		return nil
+	}
+
+	w, z := math.Exp(x[0]), x[1]
+	return &SecondOrderModel{
+		Method:     MethodOvershootPeakTime,
+		Wn:         w,
+		Zeta:       z,
+		StepTime:   x[2],
+		FinalValue: x[3],
+		RMS:        math.Sqrt(c),
+	}
+}
+
+// fitOverdamped ajusta dos constantes de tiempo reales, el instante de inicio y el valor
+// final (normalizado) por mínimos cuadrados, probando varias relaciones τ2/τ1 iniciales
+func fitOverdamped(t, yn []float64) *SecondOrderModel {
+	t0 := onsetTime(t, yn)
+	duration := t[len(t)-1] - t[0]
+
+	// Estimación inicial de la constante lenta: tiempo al 63.2% del valor final
+	t63 := duration / 3
+	for i := range yn {
+		if yn[i] >= 0.632 {
+			t63 = t[i] - t0
+			break
+		}
+	}
+	if t63 <= 0 {
+		t63 = duration / 3
+	}
+
+	cost := func(p []float64) float64 {
+		tau1, tau2, start, fv := math.Exp(p[0]), math.Exp(p[1]), p[2], p[3]
+		if tau2 > tau1 || start < t[0]-duration || start > t[len(t)-1] {
+			return math.Inf(1)
+		}
+		return meanSquaredError(t, yn, func(tau float64) float64 { return fv * overdampedStep(tau1, tau2, tau) }, start)
+	}
+
+	var bestX []float64
+	bestCost := math.Inf(1)
+	for _, ratio := range []float64{0.05, 0.25, 0.7} {
+		tau1 := t63 / (1 + ratio)
+		x, c := NelderMead(cost,
+			[]float64{math.Log(tau1), math.Log(tau1 * ratio), t0, 1},
+			[]float64{0.3, 0.3, 0.02 * duration, 0.02},
+			2000, 1e-12)
+		if c < bestCost {
+			bestX, bestCost = x, c
+		}
+	}
+	if bestX == nil {
+		return nil
+	}
+
+	tau1, tau2 := math.Exp(bestX[0]), math.Exp(bestX[1])
+	return &SecondOrderModel{
+		Method:     MethodTwoTimeConstants,
+		Wn:         1 / math.Sqrt(tau1*tau2),
+		Zeta:       (tau1 + tau2) / (2 * math.Sqrt(tau1*tau2)),
+		Tau1:       tau1,
+		Tau2:       tau2,
+		StepTime:   bestX[2],
+		FinalValue: bestX[3],
+		RMS:        math.Sqrt(bestCost),
+	}
+}
+
+// onsetTime estima el instante en que comienza la respuesta: la última muestra
+// por debajo del 2% antes de que la salida normalizada lo supere por primera vez
+func onsetTime(t, yn []float64) float64 {
+	for i := range yn {
+		if yn[i] > 0.02 {
+			if i == 0 {
+				return t[0]
+			}
+			return t[i-1]
+		}
+	}
+	return t[0]
+}
+
+// underdampedStep es la respuesta normalizada de un sistema subamortiguado
+func underdampedStep(wn, zeta, tau float64) float64 {
+	if tau <= 0 {
+		return 0
+	}
+	wd := wn * math.Sqrt(1-zeta*zeta)
+	return 1 - math.Exp(-zeta*wn*tau)*(math.Cos(wd*tau)+zeta/math.Sqrt(1-zeta*zeta)*math.Sin(wd*tau))
+}
+
+// overdampedStep es la respuesta normalizada de dos polos reales con constantes τ1 ≥ τ2
+func overdampedStep(tau1, tau2, tau float64) float64 {
+	if tau <= 0 {
+		return 0
+	}
+	if math.Abs(tau1-tau2) < 1e-9*tau1 {
+		// Caso críticamente amortiguado (polo doble)
+		return 1 - (1+tau/tau1)*math.Exp(-tau/tau1)
+	}
+	return 1 - (tau1*math.Exp(-tau/tau1)-tau2*math.Exp(-tau/tau2))/(tau1-tau2)
+}
+
+// meanSquaredError calcula el error cuadrático medio entre los datos y un modelo desplazado al instante start
+func meanSquaredError(t, y []float64, model func(float64) float64, start float64) float64 {
+	sum := 0.0
+	for i := range t {
+		e := y[i] - model(t[i]-start)
+		sum += e * e
+	}
+	return sum / float64(len(t))
+}
+
+// secondOrderPoles calcula los polos del modelo a partir de ωn y ζ (o de las constantes de tiempo)
+func secondOrderPoles(m *SecondOrderModel) []Complex {
+	if m.Tau1 > 0 && m.Tau2 > 0 {
+		return []Complex{{Real: -1 / m.Tau1}, {Real: -1 / m.Tau2}}
+	}
+	wd := m.Wn * math.Sqrt(1-m.Zeta*m.Zeta)
+	return []Complex{
+		{Real: -m.Zeta * m.Wn, Imag: wd},
+		{Real: -m.Zeta * m.Wn, Imag: -wd},
+	}
+}
+
+// mean calcula el promedio de un slice
+func mean(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range data {
+		sum += v
+	}
+	return sum / float64(len(data))
+}
@@ -0,0 +1,162 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// LMResult es el resultado de una minimización de Levenberg–Marquardt
+type LMResult struct {
+	Params     []float64
+	Covariance [][]float64 // Covarianza asintótica σ²·(JᵀJ)⁻¹ (nil si JᵀJ es singular)
+	SSE        float64     // Suma de cuadrados de los residuos
+	Residuals  []float64
+	Iterations int
+	Converged  bool
+}
+
+// LevenbergMarquardt minimiza la suma de cuadrados de residual(p) partiendo de p0.
+// El jacobiano se aproxima por diferencias finitas hacia adelante.
+func LevenbergMarquardt(residual func([]float64) []float64, p0 []float64, maxIter int) (*LMResult, error) {
+	params := append([]float64(nil), p0...)
+	r := residual(params)
+	sse := sumSquares(r)
+	if math.IsNaN(sse) || math.IsInf(sse, 0) {
+		return nil, errors.New("los parámetros iniciales producen residuos no finitos")
+	}
+	if len(r) <= len(params) {
+		return nil, errors.New("hay menos datos que parámetros a ajustar")
+	}
+
+	lambda := 1e-3
+	result := &LMResult{}
+	var jac matrix
+
+	for iter := 0; iter < maxIter; iter++ {
+		result.Iterations = iter + 1
+		jac = numericJacobian(residual, params, r)
+		jtj, jtr := normalEquations(jac, r)
+
+		improved := false
+		for attempt := 0; attempt < 20; attempt++ {
+			// (JᵀJ + λ·diag(JᵀJ))·δ = -Jᵀr
+			damped := newMatrix(len(params), len(params))
+			rhs := make([]float64, len(params))
+			for i := range jtj {
+				copy(damped[i], jtj[i])
+				damped[i][i] += lambda * math.Max(jtj[i][i], 1e-12)
+				rhs[i] = -jtr[i]
+			}
+			delta, err := solveLinear(damped, rhs)
+			if err != nil {
+				lambda *= 10
+				continue
+			}
+
+			candidate := make([]float64, len(params))
+			for i := range params {
+				candidate[i] = params[i] + delta[i]
+			}
+			cr := residual(candidate)
+			csse := sumSquares(cr)
+
+			if !math.IsNaN(csse) && csse < sse {
+				relChange := (sse - csse) / math.Max(sse, 1e-300)
+				stepNorm := 0.0
+				for i := range delta {
+					stepNorm = math.Max(stepNorm, math.Abs(delta[i])/(math.Abs(params[i])+1e-8))
+				}
+				params, r, sse = candidate, cr, csse
+				lambda = math.Max(lambda/10, 1e-12)
+				improved = true
+				if relChange < 1e-10 || stepNorm < 1e-10 {
+					result.Converged = true
+				}
+				break
+			}
+			lambda *= 10
+		}
+
+		if !improved {
+			// Ningún paso reduce el error: se está en un mínimo (local)
+			result.Converged = true
+		}
+		if result.Converged {
+			break
+		}
+	}
+
+	result.Params = params
+	result.Residuals = r
+	result.SSE = sse
+
+	// Covarianza de los parámetros en el óptimo
+	jac = numericJacobian(residual, params, r)
+	jtj, _ := normalEquations(jac, r)
+	if inv, err := invert(jtj); err == nil {
+		sigma2 := sse / float64(len(r)-len(params))
+		cov := make([][]float64, len(params))
+		for i := range inv {
+			cov[i] = make([]float64, len(params))
+			for j := range inv[i] {
+				cov[i][j] = inv[i][j] * sigma2
+			}
+		}
+		result.Covariance = cov
+	}
+
+	return result, nil
+}
+
+// numericJacobian aproxima ∂r/∂p por diferencias finitas hacia adelante
+func numericJacobian(residual func([]float64) []float64, p, r []float64) matrix {
+	jac := newMatrix(len(r), len(p))
+	probe := append([]float64(nil), p...)
+	for j := range p {
+		h := 1e-6 * math.Max(math.Abs(p[j]), 1e-3)
+		probe[j] = p[j] + h
+		rh := residual(probe)
+		probe[j] = p[j]
+		for i := range r {
+			d := (rh[i] - r[i]) / h
+			if math.IsNaN(d) || math.IsInf(d, 0) {
+				d = 0
+			}
+			jac[i][j] = d
+		}
+	}
+	return jac
+}
+
+// normalEquations calcula JᵀJ y Jᵀr
+func normalEquations(jac matrix, r []float64) (matrix, []float64) {
+	p := len(jac[0])
+	jtj := newMatrix(p, p)
+	jtr := make([]float64, p)
+	for i := range jac {
+		for a := 0; a < p; a++ {
+			jtr[a] += jac[i][a] * r[i]
+			for b := a; b < p; b++ {
+				jtj[a][b] += jac[i][a] * jac[i][b]
+			}
+		}
+	}
+	for a := 0; a < p; a++ {
+		for b := 0; b < a; b++ {
+			jtj[a][b] = jtj[b][a]
+		}
+	}
+	return jtj, jtr
+}
+
+// sumSquares calcula la suma de cuadrados (infinito si algún valor no es finito)
+func sumSquares(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return math.Inf(1)
+		}
+		sum += x * x
+	}
+	return sum
+}
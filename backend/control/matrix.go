@@ -0,0 +1,197 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// matrix es una matriz densa pequeña (filas × columnas)
+type matrix [][]float64
+
+// newMatrix crea una matriz de ceros
+func newMatrix(rows, cols int) matrix {
+	m := make(matrix, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+// identity crea la matriz identidad n×n
+func identity(n int) matrix {
+	m := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// mul calcula el producto a·b
+func (a matrix) mul(b matrix) matrix {
+	out := newMatrix(len(a), len(b[0]))
+	for i := range a {
+		for k := range b {
+			if a[i][k] == 0 {
+				continue
+			}
+			for j := range b[0] {
+				out[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return out
+}
+
+// mulVec calcula el producto a·v
+func (a matrix) mulVec(v []float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range a {
+		for j := range v {
+			out[i] += a[i][j] * v[j]
+		}
+	}
+	return out
+}
+
+// scale multiplica todos los elementos por k
+func (a matrix) scale(k float64) matrix {
+	out := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			out[i][j] = a[i][j] * k
+		}
+	}
+	return out
+}
+
+// add calcula a + b
+func (a matrix) add(b matrix) matrix {
+	out := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			out[i][j] = a[i][j] + b[i][j]
+		}
+	}
+	return out
+}
+
+// normInf calcula la norma infinito (máxima suma absoluta por fila)
+func (a matrix) normInf() float64 {
+	norm := 0.0
+	for i := range a {
+		sum := 0.0
+		for j := range a[i] {
+			sum += math.Abs(a[i][j])
+		}
+		norm = math.Max(norm, sum)
+	}
+	return norm
+}
+
+// expm calcula la exponencial de una matriz con aproximante de Padé (6,6) y escalado y cuadrado
+func expm(a matrix) matrix {
+	n := len(a)
+	norm := a.normInf()
+
+	squarings := 0
+	if norm > 0.5 {
+		squarings = int(math.Ceil(math.Log2(norm / 0.5)))
+	}
+	scaled := a.scale(math.Pow(2, -float64(squarings)))
+
+	// Coeficientes de Padé de orden q = 6
+	const q = 6
+	c := 1.0
+	num := identity(n)
+	den := identity(n)
+	power := identity(n)
+	for k := 1; k <= q; k++ {
+		c *= float64(q-k+1) / float64(k*(2*q-k+1))
+		power = power.mul(scaled)
+		num = num.add(power.scale(c))
+		if k%2 == 0 {
+			den = den.add(power.scale(c))
+		} else {
+			den = den.add(power.scale(-c))
+		}
+	}
+
+	result, err := solveMatrix(den, num)
+	if err != nil {
+		return identity(n)
+	}
+
+	for i := 0; i < squarings; i++ {
+		result = result.mul(result)
+	}
+	return result
+}
+
+// solveMatrix resuelve a·x = b para una matriz b (eliminación gaussiana con pivoteo parcial)
+func solveMatrix(a, b matrix) (matrix, error) {
+	n := len(a)
+	cols := len(b[0])
+
+	// Matriz aumentada [a | b]
+	aug := newMatrix(n, n+cols)
+	for i := 0; i < n; i++ {
+		copy(aug[i], a[i])
+		copy(aug[i][n:], b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(aug[row][col]) > math.Abs(aug[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(aug[pivot][col]) < 1e-300 {
+			return nil, errors.New("matriz singular")
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := aug[row][col] / aug[col][col]
+			if factor == 0 {
+				continue
+			}
+			for j := col; j < n+cols; j++ {
+				aug[row][j] -= factor * aug[col][j]
+			}
+		}
+	}
+
+	x := newMatrix(n, cols)
+	for i := 0; i < n; i++ {
+		for j := 0; j < cols; j++ {
+			x[i][j] = aug[i][n+j] / aug[i][i]
+		}
+	}
+	return x, nil
+}
+
+// solveLinear resuelve a·x = b para un vector b
+func solveLinear(a matrix, b []float64) ([]float64, error) {
+	bm := newMatrix(len(b), 1)
+	for i := range b {
+		bm[i][0] = b[i]
+	}
+	x, err := solveMatrix(a, bm)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(b))
+	for i := range out {
+		out[i] = x[i][0]
+	}
+	return out, nil
+}
+
+// invert calcula la inversa de una matriz cuadrada
+func invert(a matrix) (matrix, error) {
+	return solveMatrix(a, identity(len(a)))
+}
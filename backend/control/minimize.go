@@ -0,0 +1,103 @@
+package control
+
+import (
+	"math"
+	"sort"
+)
+
+// NelderMead minimiza una función sin derivadas con el método símplex de Nelder–Mead.
+// step es el tamaño inicial del símplex en cada dimensión. Devuelve el mejor punto y su costo.
+func NelderMead(f func([]float64) float64, x0, step []float64, maxIter int, tol float64) ([]float64, float64) {
+	n := len(x0)
+
+	// Construir el símplex inicial
+	simplex := make([][]float64, n+1)
+	costs := make([]float64, n+1)
+	for i := range simplex {
+		simplex[i] = append([]float64(nil), x0...)
+		if i > 0 {
+			simplex[i][i-1] += step[i-1]
+		}
+		costs[i] = f(simplex[i])
+	}
+
+	order := make([]int, n+1)
+	for iter := 0; iter < maxIter; iter++ {
+		// Ordenar vértices de mejor a peor
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return costs[order[a]] < costs[order[b]] })
+		sortedSimplex := make([][]float64, n+1)
+		sortedCosts := make([]float64, n+1)
+		for i, idx := range order {
+			sortedSimplex[i] = simplex[idx]
+			sortedCosts[i] = costs[idx]
+		}
+		simplex, costs = sortedSimplex, sortedCosts
+
+		// Criterio de parada: dispersión de costos pequeña
+		if math.Abs(costs[n]-costs[0]) <= tol*(math.Abs(costs[0])+tol) {
+			break
+		}
+
+		// Centroide de todos los vértices excepto el peor
+		centroid := make([]float64, n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				centroid[j] += simplex[i][j] / float64(n)
+			}
+		}
+
+		worst := simplex[n]
+		reflected := affine(centroid, worst, -1)
+		reflectedCost := f(reflected)
+
+		switch {
+		case reflectedCost < costs[0]:
+			// Expansión
+			expanded := affine(centroid, worst, -2)
+			if expandedCost := f(expanded); expandedCost < reflectedCost {
+				simplex[n], costs[n] = expanded, expandedCost
+			} else {
+				simplex[n], costs[n] = reflected, reflectedCost
+			}
+		case reflectedCost < costs[n-1]:
+			simplex[n], costs[n] = reflected, reflectedCost
+		default:
+			// Contracción (exterior o interior)
+			var contracted []float64
+			if reflectedCost < costs[n] {
+				contracted = affine(centroid, worst, -0.5)
+			} else {
+				contracted = affine(centroid, worst, 0.5)
+			}
+			if contractedCost := f(contracted); contractedCost < math.Min(reflectedCost, costs[n]) {
+				simplex[n], costs[n] = contracted, contractedCost
+			} else {
+				// Reducción hacia el mejor vértice
+				for i := 1; i <= n; i++ {
+					simplex[i] = affine(simplex[0], simplex[i], 0.5)
+					costs[i] = f(simplex[i])
+				}
+			}
+		}
+	}
+
+	best := 0
+	for i := range costs {
+		if costs[i] < costs[best] {
+			best = i
+		}
+	}
+	return simplex[best], costs[best]
+}
+
+// affine calcula c + alpha·(p - c)
+func affine(c, p []float64, alpha float64) []float64 {
+	out := make([]float64, len(c))
+	for i := range c {
+		out[i] = c[i] + alpha*(p[i]-c[i])
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Tipos de controlador PID
+const (
+	ControllerPI  = "PI"
+	ControllerPID = "PID"
+)
+
+// DefaultDerivativeFilter es el factor N del filtro de la acción derivativa: Td·s / (Td/N·s + 1)
+const DefaultDerivativeFilter = 10
+
+// PIDGains son las ganancias de un PID en forma estándar (ISA):
+// C(s) = Kp·(1 + 1/(Ti·s) + Td·s/(Td/N·s + 1)). Ki y Kd son las de la forma paralela.
+type PIDGains struct {
+	Controller string  `json:"controller"` // PI o PID
+	Kp         float64 `json:"kp"`
+	Ti         float64 `json:"ti"`           // Tiempo integral (s), 0 sin acción integral
+	Td         float64 `json:"td,omitempty"` // Tiempo derivativo (s)
+	Ki         float64 `json:"ki"`           // Kp/Ti
+	Kd         float64 `json:"kd,omitempty"` // Kp·Td
+	N          float64 `json:"n,omitempty"`  // Filtro de la derivada (por defecto 10)
+}
+
+// NewPIDGains completa Ki, Kd y el tipo de controlador a partir de Kp, Ti y Td
+func NewPIDGains(kp, ti, td float64) PIDGains {
+	g := PIDGains{Controller: ControllerPI, Kp: kp, Ti: ti, Td: td}
+	if ti > 0 {
+		g.Ki = kp / ti
+	}
+	if td > 0 {
+		g.Controller = ControllerPID
+		g.Kd = kp * td
+		g.N = DefaultDerivativeFilter
+	}
+	return g
+}
+
+// Validate comprueba que las ganancias sean finitas y los tiempos no negativos
+func (g PIDGains) Validate() error {
+	for _, v := range []float64{g.Kp, g.Ti, g.Td, g.Ki, g.Kd, g.N} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("las ganancias del PID deben ser números finitos")
+		}
+	}
+	if g.Ti < 0 || g.Td < 0 || g.N < 0 {
+		return errors.New("ti, td y n no pueden ser negativos")
+	}
+	if g.Kp == 0 && g.Ki == 0 {
+		return errors.New("el controlador no tiene acción proporcional ni integral (sin kp, la integral se indica con ki)")
+	}
+	if g.Kp == 0 && (g.Kd != 0 || g.Td != 0) {
+		return errors.New("la acción derivativa requiere una ganancia proporcional")
+	}
+	return nil
+}
+
+// TransferFunction devuelve C(s). Acepta la forma estándar (Kp, Ti, Td) o la paralela
+// (Kp, Ki, Kd); Ti y Td tienen prioridad cuando se indican ambas.
+func (g PIDGains) TransferFunction() (TransferFunction, error) {
+	if err := g.Validate(); err != nil {
+		return TransferFunction{}, err
+	}
+	ki, kd, td := g.Ki, g.Kd, g.Td
+	if g.Ti > 0 {
+		ki = g.Kp / g.Ti
+	}
+	if td > 0 {
+		kd = g.Kp * td
+	} else if kd != 0 {
+		td = kd / g.Kp
+	}
+
+	// Parte PI: (Kp·s + Ki) / s, o solo Kp
+	num, den := []float64{g.Kp, ki}, []float64{1, 0}
+	if ki == 0 {
+		num, den = []float64{g.Kp}, []float64{1}
+	}
+	if kd == 0 {
+		return NewTransferFunction(num, den, 0)
+	}
+
+	// Derivada filtrada Kd·s / (Tf·s + 1) con Tf = Td/N
+	n := g.N
+	if n <= 0 {
+		n = DefaultDerivativeFilter
+	}
+	filter := []float64{math.Abs(td) / n, 1}
+	num = polyAdd(polyMul(num, filter), polyMul([]float64{kd, 0}, den))
+	return NewTransferFunction(num, polyMul(den, filter), 0)
+}
+
+// LeadLag es un compensador de adelanto o atraso de fase C(s) = K·(s + z)/(s + p): de
+// adelanto si z < p y de atraso si z > p
+type LeadLag struct {
+	Gain float64 `json:"gain"` // K
+	Zero float64 `json:"zero"` // z (rad/s)
+	Pole float64 `json:"pole"` // p (rad/s)
+}
+
+// TransferFunction devuelve C(s)
+func (l LeadLag) TransferFunction() (TransferFunction, error) {
+	for _, v := range []float64{l.Gain, l.Zero, l.Pole} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return TransferFunction{}, errors.New("los parámetros del compensador deben ser números finitos")
+		}
+	}
+	if l.Gain == 0 {
+		return TransferFunction{}, errors.New("la ganancia del compensador no puede ser cero")
+	}
+	if l.Zero < 0 || l.Pole < 0 {
+		return TransferFunction{}, errors.New("el cero y el polo del compensador deben estar en el semiplano izquierdo (z, p ≥ 0)")
+	}
+	return NewTransferFunction([]float64{l.Gain, l.Gain * l.Zero}, []float64{1, l.Pole}, 0)
+}
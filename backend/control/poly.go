@@ -0,0 +1,188 @@
+package control
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Los polinomios se representan con sus coeficientes en potencias descendentes:
+// [a0, a1, ..., an] = a0·sⁿ + a1·sⁿ⁻¹ + ... + an
+
+// trimPoly elimina los coeficientes principales nulos
+func trimPoly(p []float64) []float64 {
+	for len(p) > 1 && p[0] == 0 {
+		p = p[1:]
+	}
+	return p
+}
+
+// polyEval evalúa un polinomio real en un punto complejo (método de Horner)
+func polyEval(p []float64, s complex128) complex128 {
+	var result complex128
+	for _, c := range p {
+		result = result*s + complex(c, 0)
+	}
+	return result
+}
+
+// polyMul multiplica dos polinomios
+func polyMul(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i := range a {
+		for j := range b {
+			out[i+j] += a[i] * b[j]
+		}
+	}
+	return out
+}
+
+// polyAdd suma dos polinomios alineando los términos de menor grado
+func polyAdd(a, b []float64) []float64 {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	out := append([]float64(nil), a...)
+	offset := len(a) - len(b)
+	for i := range b {
+		out[offset+i] += b[i]
+	}
+	return out
+}
+
+// polyScale multiplica un polinomio por una constante
+func polyScale(p []float64, k float64) []float64 {
+	out := make([]float64, len(p))
+	for i := range p {
+		out[i] = p[i] * k
+	}
+	return out
+}
+
+// polyDerivative deriva un polinomio
+func polyDerivative(p []float64) []float64 {
+	n := len(p) - 1
+	if n < 1 {
+		return []float64{0}
+	}
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		out[i] = p[i] * float64(n-i)
+	}
+	return out
+}
+
+// polyFromRoots construye el polinomio mónico con las raíces dadas. Las raíces complejas
+// deben aparecer en pares conjugados para que los coeficientes sean reales.
+func polyFromRoots(roots []complex128) []float64 {
+	coeffs := []complex128{1}
+	for _, r := range roots {
+		next := make([]complex128, len(coeffs)+1)
+		for i, c := range coeffs {
+			next[i] += c
+			next[i+1] -= c * r
+		}
+		coeffs = next
+	}
+	out := make([]float64, len(coeffs))
+	for i, c := range coeffs {
+		out[i] = real(c)
+	}
+	return out
+}
+
+// polyRoots calcula las raíces de un polinomio real con el método de Durand–Kerner
+func polyRoots(p []float64) []complex128 {
+	p = trimPoly(p)
+	n := len(p) - 1
+	if n < 1 {
+		return nil
+	}
+
+	// Raíces en cero (coeficientes finales nulos)
+	var zeroRoots []complex128
+	for n > 0 && p[n] == 0 {
+		zeroRoots = append(zeroRoots, 0)
+		p = p[:n]
+		n--
+	}
+	if n == 0 {
+		return zeroRoots
+	}
+
+	// Normalizar a mónico
+	monic := polyScale(p, 1/p[0])
+
+	var roots []complex128
+	switch n {
+	case 1:
+		roots = []complex128{complex(-monic[1], 0)}
+	case 2:
+		b, c := monic[1], monic[2]
+		disc := cmplx.Sqrt(complex(b*b-4*c, 0))
+		roots = []complex128{(complex(-b, 0) + disc) / 2, (complex(-b, 0) - disc) / 2}
+	default:
+		roots = durandKerner(monic)
+	}
+
+	roots = append(roots, zeroRoots...)
+	sortRoots(roots)
+	return roots
+}
+
+// durandKerner itera simultáneamente sobre todas las raíces de un polinomio mónico
+func durandKerner(monic []float64) []complex128 {
+	n := len(monic) - 1
+
+	// Radio inicial según la cota de Cauchy
+	radius := 0.0
+	for _, c := range monic[1:] {
+		radius = math.Max(radius, math.Abs(c))
+	}
+	radius = 1 + radius
+
+	roots := make([]complex128, n)
+	for i := range roots {
+		angle := 2*math.Pi*float64(i)/float64(n) + 0.4
+		roots[i] = cmplx.Rect(radius, angle)
+	}
+
+	for iter := 0; iter < 1000; iter++ {
+		maxDelta := 0.0
+		for i := range roots {
+			denom := complex(1, 0)
+			for j := range roots {
+				if i != j {
+					denom *= roots[i] - roots[j]
+				}
+			}
+			if denom == 0 {
+				denom = complex(1e-12, 0)
+			}
+			delta := polyEval(monic, roots[i]) / denom
+			roots[i] -= delta
+			maxDelta = math.Max(maxDelta, cmplx.Abs(delta)/math.Max(1, cmplx.Abs(roots[i])))
+		}
+		if maxDelta < 1e-14 {
+			break
+		}
+	}
+
+	// Limpiar partes imaginarias residuales de raíces reales
+	for i, r := range roots {
+		if math.Abs(imag(r)) < 1e-9*math.Max(1, cmplx.Abs(r)) {
+			roots[i] = complex(real(r), 0)
+		}
+	}
+	return roots
+}
+
+// sortRoots ordena las raíces por parte real y luego por parte imaginaria descendente
+func sortRoots(roots []complex128) {
+	sort.Slice(roots, func(i, j int) bool {
+		if real(roots[i]) != real(roots[j]) {
+			return real(roots[i]) > real(roots[j])
+		}
+		return imag(roots[i]) > imag(roots[j])
+	})
+}
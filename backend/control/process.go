@@ -0,0 +1,292 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Estructuras de modelo de proceso
+const (
+	ProcessFOPDT = "fopdt" // K·e^(-θs) / (τs + 1)
+	ProcessSOPDT = "sopdt" // K·e^(-θs) / ((τs + 1)(τ2·s + 1))
+)
+
+// Métodos de identificación de los modelos de proceso
+const (
+	MethodZieglerNichols         = "ziegler_nichols_tangent"
+	MethodSmithTwoPoint          = "smith_two_point"
+	MethodSundaresanKrishnaswamy = "sundaresan_krishnaswamy"
+	MethodArea                   = "area"
+	MethodSOPDTTwoPoint          = "sopdt_two_point"
+	MethodLeastSquaresFOPDT      = "least_squares_fopdt"
+	MethodLeastSquaresSOPDT      = "least_squares_sopdt"
+)
+
+// Niveles del método de dos puntos para SOPDT con constantes iguales
+const (
+	sopdtLowLevel  = 0.3
+	sopdtHighLevel = 0.7
+)
+
+// maxProcessIterations limita las iteraciones del refinamiento por mínimos cuadrados
+const maxProcessIterations = 200
+
+// ProcessModel es un modelo de primer o segundo orden con tiempo muerto
+type ProcessModel struct {
+	Type       string  `json:"type"` // fopdt o sopdt
+	Method     string  `json:"method"`
+	Gain       float64 `json:"gain"`           // K
+	Tau        float64 `json:"tau"`            // τ (la constante lenta en SOPDT)
+	Tau2       float64 `json:"tau2,omitempty"` // τ2 (solo SOPDT)
+	DeadTime   float64 `json:"dead_time"`      // θ
+	RMS        float64 `json:"rms"`            // Error cuadrático medio frente a la respuesta medida
+	FitPercent float64 `json:"fit_percent"`    // Ajuste NRMSE (%)
+}
+
+// TransferFunction devuelve la función de transferencia del modelo
+func (m ProcessModel) TransferFunction() (TransferFunction, error) {
+	den := []float64{m.Tau, 1}
+	if m.Type == ProcessSOPDT {
+		den = polyMul(den, []float64{m.Tau2, 1})
+	}
+	return NewTransferFunction([]float64{m.Gain}, den, m.DeadTime)
+}
+
+// normalizedStep es la respuesta del modelo con ganancia unitaria tau segundos después del escalón
+func (m ProcessModel) normalizedStep(tau float64) float64 {
+	x := tau - m.DeadTime
+	if x <= 0 || m.Tau <= 0 {
+		return 0
+	}
+	if m.Type == ProcessSOPDT && m.Tau2 > 0 {
+		return overdampedStep(m.Tau, m.Tau2, x)
+	}
+	return 1 - math.Exp(-x/m.Tau)
+}
+
+// ProcessIdentification reúne los modelos de proceso identificados con cada método
+type ProcessIdentification struct {
+	Best         ProcessModel   `json:"best"`       // El de menor error de ajuste
+	Candidates   []ProcessModel `json:"candidates"` // Todos, de menor a mayor error
+	StepTime     float64        `json:"step_time"`  // Instante del escalón en la base de tiempo de los datos
+	InitialValue float64        `json:"initial_value"`
+	FinalValue   float64        `json:"final_value"`
+	Amplitude    float64        `json:"amplitude"`
+}
+
+// IdentifyProcessModels identifica modelos FOPDT y SOPDT a partir de la respuesta (t, y) a un
+// escalón de amplitud u aplicado en stepTime, que lleva la salida del nivel initial al final.
+// Aplica los métodos gráficos clásicos (tangente de Ziegler–Nichols, dos puntos de Smith,
+// Sundaresan–Krishnaswamy, áreas y dos puntos para SOPDT), refina FOPDT y SOPDT por mínimos
+// cuadrados partiendo del mejor método gráfico y compara el error de ajuste de todos.
+func IdentifyProcessModels(t, y []float64, u, initial, final, stepTime float64) (*ProcessIdentification, error) {
+	if len(t) != len(y) || len(t) < 10 {
+		return nil, errors.New("datos insuficientes para identificar un modelo de proceso")
+	}
+	if u == 0 {
+		return nil, errors.New("la amplitud del escalón no puede ser cero")
+	}
+	step := final - initial
+	if step == 0 || math.IsNaN(step) || math.IsInf(step, 0) {
+		return nil, errors.New("la salida no cambia de nivel")
+	}
+
+	// Respuesta normalizada desde el escalón
+	var tau, yn, ys []float64
+	for i := range t {
+		if t[i] < stepTime {
+			continue
+		}
+		tau = append(tau, t[i]-stepTime)
+		yn = append(yn, (y[i]-initial)/step)
+		ys = append(ys, y[i])
+	}
+	if len(tau) < 10 {
+		return nil, errors.New("hay muy pocas muestras después del escalón")
+	}
+
+	gain := step / u
+	id := &ProcessIdentification{StepTime: stepTime, InitialValue: initial, FinalValue: final, Amplitude: u}
+	evaluate := func(m ProcessModel) {
+		if m.Tau <= 0 || m.DeadTime < 0 || math.IsNaN(m.Tau) || math.IsNaN(m.DeadTime) || (m.Type == ProcessSOPDT && m.Tau2 <= 0) {
+			return
+		}
+		simulated := make([]float64, len(tau))
+		for i, ti := range tau {
+			simulated[i] = initial + m.Gain*u*m.normalizedStep(ti)
+		}
+		quality, err := EvaluateFit(ys, simulated)
+		if err != nil {
+			return
+		}
+		m.RMS, m.FitPercent = quality.RMS, quality.FitPercent
+		id.Candidates = append(id.Candidates, m)
+	}
+
+	// Métodos gráficos
+	fopdt := func(method string, tau, theta float64) ProcessModel {
+		return ProcessModel{Type: ProcessFOPDT, Method: method, Gain: gain, Tau: tau, DeadTime: math.Max(0, theta)}
+	}
+	if m, ok := zieglerNicholsTangent(tau, yn); ok {
+		evaluate(fopdt(MethodZieglerNichols, m[0], m[1]))
+	}
+	if t28, ok := crossingTime(tau, yn, 0.283); ok {
+		if t63, ok := crossingTime(tau, yn, 0.632); ok {
+			tc := 1.5 * (t63 - t28)
+			evaluate(fopdt(MethodSmithTwoPoint, tc, t63-tc))
+		}
+	}
+	if t35, ok := crossingTime(tau, yn, 0.353); ok {
+		if t85, ok := crossingTime(tau, yn, 0.853); ok {
+			evaluate(fopdt(MethodSundaresanKrishnaswamy, 0.67*(t85-t35), 1.3*t35-0.29*t85))
+		}
+	}
+	if m, ok := areaMethod(tau, yn); ok {
+		evaluate(fopdt(MethodArea, m[0], m[1]))
+	}
+	if tLow, ok := crossingTime(tau, yn, sopdtLowLevel); ok {
+		if tHigh, ok := crossingTime(tau, yn, sopdtHighLevel); ok {
+			xLow, xHigh := equalTauStepInverse(sopdtLowLevel), equalTauStepInverse(sopdtHighLevel)
+			tc := (tHigh - tLow) / (xHigh - xLow)
+			evaluate(ProcessModel{Type: ProcessSOPDT, Method: MethodSOPDTTwoPoint, Gain: gain, Tau: tc, Tau2: tc, DeadTime: math.Max(0, tLow-xLow*tc)})
+		}
+	}
+	if len(id.Candidates) == 0 {
+		return nil, errors.New("la respuesta no alcanza los niveles que usan los métodos gráficos")
+	}
+
+	// Refinamiento por mínimos cuadrados desde el mejor FOPDT gráfico
+	sortByRMS(id.Candidates)
+	var start ProcessModel
+	for _, c := range id.Candidates {
+		if c.Type == ProcessFOPDT {
+			start = c
+			break
+		}
+	}
+	if start.Tau > 0 {
+		if m, ok := refineProcessModel(tau, ys, u, initial, ProcessModel{Type: ProcessFOPDT, Method: MethodLeastSquaresFOPDT, Gain: gain, Tau: start.Tau, DeadTime: start.DeadTime}); ok {
+			evaluate(m)
+			// SOPDT: el mismo retardo con la constante repartida, y el SOPDT gráfico si existe
+			best := ProcessModel{}
+			for _, s := range []ProcessModel{
+				{Type: ProcessSOPDT, Method: MethodLeastSquaresSOPDT, Gain: m.Gain, Tau: 0.7 * m.Tau, Tau2: 0.3 * m.Tau, DeadTime: m.DeadTime},
+				sopdtCandidate(id.Candidates),
+			} {
+				if s.Tau <= 0 {
+					continue
+				}
+				s.Method = MethodLeastSquaresSOPDT
+				if r, ok := refineProcessModel(tau, ys, u, initial, s); ok && (best.Tau == 0 || r.RMS < best.RMS) {
+					best = r
+				}
+			}
+			if best.Tau > 0 {
+				evaluate(best)
+			}
+		}
+	}
+
+	sortByRMS(id.Candidates)
+	id.Best = id.Candidates[0]
+	return id, nil
+}
+
+// zieglerNicholsTangent traza la tangente en el punto de máxima pendiente: corta el nivel
+// inicial en θ y el final en θ + τ. La pendiente se calcula sobre una ventana del 1% de la serie.
+func zieglerNicholsTangent(t, yn []float64) ([2]float64, bool) {
+	n := len(t)
+	w := max(n/100, 1)
+	best, slope := -1, 0.0
+	for i := w; i < n-w; i++ {
+		dt := t[i+w] - t[i-w]
+		if dt <= 0 {
+			continue
+		}
+		if s := (yn[i+w] - yn[i-w]) / dt; s > slope {
+			best, slope = i, s
+		}
+	}
+	if best < 0 {
+		return [2]float64{}, false
+	}
+	theta := t[best] - yn[best]/slope
+	return [2]float64{1 / slope, theta}, true
+}
+
+// areaMethod aplica el método de las áreas (Åström–Hägglund): A0 = ∫(1 - yn) dt = θ + τ y
+// A1 = ∫₀^A0 yn dt = τ/e
+func areaMethod(t, yn []float64) ([2]float64, bool) {
+	var a0 float64
+	for i := 1; i < len(t); i++ {
+		a0 += (t[i] - t[i-1]) * ((1 - yn[i-1]) + (1 - yn[i])) / 2
+	}
+	if a0 <= 0 || a0 > t[len(t)-1] {
+		return [2]float64{}, false
+	}
+	var a1 float64
+	for i := 1; i < len(t) && t[i-1] < a0; i++ {
+		hi := math.Min(t[i], a0)
+		yHi := yn[i-1] + (yn[i]-yn[i-1])*(hi-t[i-1])/(t[i]-t[i-1])
+		a1 += (hi - t[i-1]) * (yn[i-1] + yHi) / 2
+	}
+	tc := math.E * a1
+	return [2]float64{tc, a0 - tc}, tc > 0
+}
+
+// equalTauStepInverse resuelve 1 - (1 + x)·e^(-x) = level por bisección
+func equalTauStepInverse(level float64) float64 {
+	return bisect(1e-6, 50, func(x float64) float64 { return 1 - (1+x)*math.Exp(-x) - level })
+}
+
+// refineProcessModel ajusta K, τ, (τ2) y θ por Levenberg–Marquardt. Las constantes de tiempo
+// se parametrizan por su logaritmo para mantenerlas positivas y θ por su valor absoluto.
+func refineProcessModel(tau, y []float64, u, initial float64, m ProcessModel) (ProcessModel, bool) {
+	sopdt := m.Type == ProcessSOPDT
+	unpack := func(p []float64) ProcessModel {
+		r := m
+		r.Gain, r.Tau, r.DeadTime = p[0], math.Exp(p[1]), math.Abs(p[2])
+		if sopdt {
+			r.Tau2 = math.Exp(p[3])
+		}
+		return r
+	}
+	p0 := []float64{m.Gain, math.Log(m.Tau), m.DeadTime}
+	if sopdt {
+		p0 = append(p0, math.Log(m.Tau2))
+	}
+	residual := func(p []float64) []float64 {
+		model := unpack(p)
+		r := make([]float64, len(tau))
+		for i, ti := range tau {
+			r[i] = y[i] - (initial + model.Gain*u*model.normalizedStep(ti))
+		}
+		return r
+	}
+	res, err := LevenbergMarquardt(residual, p0, maxProcessIterations)
+	if err != nil {
+		return ProcessModel{}, false
+	}
+	r := unpack(res.Params)
+	if sopdt && r.Tau2 > r.Tau {
+		r.Tau, r.Tau2 = r.Tau2, r.Tau
+	}
+	r.RMS = math.Sqrt(res.SSE / float64(len(tau)))
+	return r, !math.IsNaN(r.RMS)
+}
+
+// sopdtCandidate devuelve el primer modelo SOPDT de la lista (vacío si no hay)
+func sopdtCandidate(models []ProcessModel) ProcessModel {
+	for _, m := range models {
+		if m.Type == ProcessSOPDT {
+			return m
+		}
+	}
+	return ProcessModel{}
+}
+
+// sortByRMS ordena los modelos de menor a mayor error
+func sortByRMS(models []ProcessModel) {
+	sort.SliceStable(models, func(i, j int) bool { return models[i].RMS < models[j].RMS })
+}
@@ -0,0 +1,309 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Parámetros del lugar de las raíces
+const (
+	DefaultRootLocusPoints = 200
+	MaxRootLocusPoints     = 2000
+	rootLocusDecades       = 6    // Décadas mínimas del barrido logarítmico de ganancia bajo la máxima
+	dampingScanPoints      = 2000 // Muestras del radio al buscar el cruce con la recta de ζ
+)
+
+// RootLocusPoint es un punto del lugar de las raíces con la ganancia que lo produce
+type RootLocusPoint struct {
+	Gain float64 `json:"gain"`
+	Real float64 `json:"real"`
+	Imag float64 `json:"imag"`
+}
+
+// newRootLocusPoint crea un punto a partir de una raíz compleja
+func newRootLocusPoint(gain float64, s complex128) RootLocusPoint {
+	return RootLocusPoint{Gain: gain, Real: real(s), Imag: imag(s)}
+}
+
+// RootLocusAsymptotes son las asíntotas de las ramas que tienden a infinito
+type RootLocusAsymptotes struct {
+	Centroid  float64   `json:"centroid"`   // Punto de corte con el eje real
+	AnglesDeg []float64 `json:"angles_deg"` // Ángulos respecto del eje real positivo
+}
+
+// RootLocusOptions son las opciones del barrido de ganancia
+type RootLocusOptions struct {
+	Points  int     // Ganancias del barrido (por defecto 200)
+	MaxGain float64 // Ganancia máxima (por defecto, la que aleja las ramas de los polos y ceros)
+}
+
+// RootLocus es el lugar de las raíces de 1 + K·G(s) = 0 para K ≥ 0. K multiplica a la función
+// de transferencia identificada, por lo que K = 1 es la planta con realimentación unitaria.
+type RootLocus struct {
+	OpenLoopPoles      []Complex            `json:"open_loop_poles"`
+	OpenLoopZeros      []Complex            `json:"open_loop_zeros"`
+	Branches           [][]RootLocusPoint   `json:"branches"` // Una rama por polo, ordenada por ganancia
+	Asymptotes         *RootLocusAsymptotes `json:"asymptotes,omitempty"`
+	Breakaway          []RootLocusPoint     `json:"breakaway"`           // Puntos de ruptura y de entrada
+	ImaginaryCrossings []RootLocusPoint     `json:"imaginary_crossings"` // Cruces con el eje imaginario (ω ≥ 0)
+	UnityGainPoles     []Complex            `json:"unity_gain_poles"`    // Polos del lazo cerrado con K = 1
+	MaxGain            float64              `json:"max_gain"`
+}
+
+// ComputeRootLocus calcula las ramas del lugar de las raíces de G(s) para un barrido de ganancia,
+// sus asíntotas, los puntos de ruptura y los cruces con el eje imaginario. El retardo de G(s),
+// si lo tiene, no se incluye.
+func ComputeRootLocus(g TransferFunction, options RootLocusOptions) (*RootLocus, error) {
+	num, den := trimPoly(g.Num), trimPoly(g.Den)
+	if isZero(num) {
+		return nil, errors.New("la función de transferencia es nula")
+	}
+	n, m := len(den)-1, len(num)-1
+	if n < 1 {
+		return nil, errors.New("la función de transferencia no tiene polos")
+	}
+	if options.Points == 0 {
+		options.Points = DefaultRootLocusPoints
+	}
+	if options.Points < 2 || options.Points > MaxRootLocusPoints {
+		return nil, errors.New("el número de puntos del lugar de las raíces debe estar entre 2 y 2000")
+	}
+	if options.MaxGain < 0 || math.IsNaN(options.MaxGain) || math.IsInf(options.MaxGain, 0) {
+		return nil, errors.New("la ganancia máxima debe ser un número positivo")
+	}
+
+	poles, zeros := polyRoots(den), polyRoots(num)
+	locus := &RootLocus{
+		OpenLoopPoles:      complexSlice(poles),
+		OpenLoopZeros:      complexSlice(zeros),
+		Breakaway:          []RootLocusPoint{},
+		ImaginaryCrossings: []RootLocusPoint{},
+		UnityGainPoles:     complexSlice(polyRoots(polyAdd(den, num))),
+	}
+
+	// Asíntotas: centroide (Σp - Σz)/(n - m) y ángulos (2q + 1)·180°/(n - m)
+	if n > m {
+		var sum float64
+		for _, p := range poles {
+			sum += real(p)
+		}
+		for _, z := range zeros {
+			sum -= real(z)
+		}
+		asymptotes := &RootLocusAsymptotes{Centroid: sum / float64(n-m)}
+		for q := 0; q < n-m; q++ {
+			asymptotes.AnglesDeg = append(asymptotes.AnglesDeg, float64(2*q+1)*180/float64(n-m))
+		}
+		locus.Asymptotes = asymptotes
+	}
+
+	// Puntos de ruptura: raíces de D'·N - D·N' = 0 con K = -D/N real y positivo
+	for _, s := range polyRoots(polyAdd(polyMul(polyDerivative(den), num), polyScale(polyMul(den, polyDerivative(num)), -1))) {
+		if k, ok := locusGain(num, den, s); ok {
+			locus.Breakaway = append(locus.Breakaway, newRootLocusPoint(k, s))
+		}
+	}
+
+	// Cruces con el eje imaginario: Im(D(jω)·N(-jω)) = 0 con K real y positivo
+	for _, w := range imaginaryAxisCandidates(num, den) {
+		if k, ok := locusGain(num, den, complex(0, w)); ok {
+			locus.ImaginaryCrossings = append(locus.ImaginaryCrossings, newRootLocusPoint(k, complex(0, w)))
+		}
+	}
+
+	// Ganancia máxima: la que lleva las ramas a cien veces el módulo del mayor polo o cero, y al
+	// menos diez veces la de los puntos notables
+	locus.MaxGain = options.MaxGain
+	if locus.MaxGain == 0 {
+		scale := 1e-6
+		for _, r := range append(append([]complex128(nil), poles...), zeros...) {
+			scale = math.Max(scale, cmplx.Abs(r))
+		}
+		far := complex(100*scale, 0)
+		locus.MaxGain = cmplx.Abs(polyEval(den, far) / polyEval(num, far))
+		for _, p := range append(append([]RootLocusPoint(nil), locus.Breakaway...), locus.ImaginaryCrossings...) {
+			locus.MaxGain = math.Max(locus.MaxGain, 10*p.Gain)
+		}
+	}
+
+	// Barrido de ganancia: K = 0, escala logarítmica desde dos décadas por debajo de K = 1 y de
+	// los puntos notables, y las ganancias de esos puntos
+	gains := []float64{0}
+	low := math.Min(locus.MaxGain*math.Pow(10, -rootLocusDecades), 0.01)
+	for _, p := range append(append([]RootLocusPoint(nil), locus.Breakaway...), locus.ImaginaryCrossings...) {
+		low = math.Min(low, 0.01*p.Gain)
+	}
+	// La última ganancia del barrido es siempre MaxGain, también con solo dos puntos
+	for i := options.Points - 2; i >= 0; i-- {
+		gains = append(gains, locus.MaxGain*math.Pow(low/locus.MaxGain, float64(i)/float64(max(options.Points-2, 1))))
+	}
+	for _, p := range append(append([]RootLocusPoint(nil), locus.Breakaway...), locus.ImaginaryCrossings...) {
+		if p.Gain <= locus.MaxGain {
+			gains = append(gains, p.Gain)
+		}
+	}
+	sort.Float64s(gains)
+
+	// Seguimiento de las ramas: cada raíz continúa la rama de la raíz anterior más cercana
+	locus.Branches = make([][]RootLocusPoint, n)
+	previous := poles
+	for _, k := range gains {
+		roots := polyRoots(polyAdd(den, polyScale(num, k)))
+		if len(roots) != n {
+			continue
+		}
+		ordered := matchRoots(previous, roots)
+		for b, r := range ordered {
+			locus.Branches[b] = append(locus.Branches[b], newRootLocusPoint(k, r))
+		}
+		previous = ordered
+	}
+	return locus, nil
+}
+
+// DampingGain es una ganancia con la que un polo del lazo cerrado tiene el amortiguamiento pedido
+type DampingGain struct {
+	Gain  float64   `json:"gain"`
+	Zeta  float64   `json:"zeta"`
+	Wn    float64   `json:"wn"`    // Frecuencia natural del polo sobre la recta de ζ
+	Point Complex   `json:"point"` // Polo sobre la recta de ζ (semiplano superior)
+	Poles []Complex `json:"poles"` // Todos los polos del lazo cerrado con esa ganancia
+}
+
+// GainsForDamping busca las ganancias K > 0 con las que el lugar de las raíces corta la recta de
+// amortiguamiento constante s = ωn·(-ζ + j·√(1 - ζ²)), ordenadas de menor a mayor ganancia
+func GainsForDamping(g TransferFunction, zeta float64) ([]DampingGain, error) {
+	if !(zeta > 0 && zeta < 1) {
+		return nil, errors.New("el amortiguamiento objetivo debe estar entre 0 y 1 (sin incluirlos)")
+	}
+	num, den := trimPoly(g.Num), trimPoly(g.Den)
+	if isZero(num) {
+		return nil, errors.New("la función de transferencia es nula")
+	}
+
+	// Radios a explorar según el módulo de los polos y ceros
+	scale := 1e-6
+	for _, r := range append(polyRoots(den), polyRoots(num)...) {
+		scale = math.Max(scale, cmplx.Abs(r))
+	}
+	direction := complex(-zeta, math.Sqrt(1-zeta*zeta))
+	angle := func(r float64) float64 {
+		s := complex(r, 0) * direction
+		return imag(polyEval(den, s) * cmplx.Conj(polyEval(num, s)))
+	}
+
+	var found []DampingGain
+	rmin, rmax := 1e-4*scale, 1e3*scale
+	prevR, prevF := rmin, angle(rmin)
+	for i := 1; i < dampingScanPoints; i++ {
+		r := rmin * math.Pow(rmax/rmin, float64(i)/float64(dampingScanPoints-1))
+		f := angle(r)
+		if prevF == 0 || prevF*f < 0 {
+			root := prevR
+			if prevF != 0 {
+				root = bisect(prevR, r, angle)
+			}
+			s := complex(root, 0) * direction
+			if k, ok := locusGain(num, den, s); ok {
+				found = append(found, DampingGain{
+					Gain:  k,
+					Zeta:  zeta,
+					Wn:    root,
+					Point: FromComplex(s),
+					Poles: complexSlice(polyRoots(polyAdd(den, polyScale(num, k)))),
+				})
+			}
+		}
+		prevR, prevF = r, f
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Gain < found[j].Gain })
+	return found, nil
+}
+
+// locusGain devuelve K = -D(s)/N(s) si es real y positivo, es decir, si s está en el lugar
+func locusGain(num, den []float64, s complex128) (float64, bool) {
+	n := polyEval(num, s)
+	if cmplx.Abs(n) < 1e-300 {
+		return 0, false
+	}
+	k := -polyEval(den, s) / n
+	if math.IsNaN(real(k)) || math.IsInf(real(k), 0) || real(k) <= 0 || math.Abs(imag(k)) > 1e-6*math.Abs(real(k)) {
+		return 0, false
+	}
+	return real(k), true
+}
+
+// imaginaryAxisCandidates devuelve las frecuencias ω ≥ 0 en que Im(D(jω)·N(-jω)) = 0, los
+// únicos puntos del eje imaginario en que K puede ser real
+func imaginaryAxisCandidates(num, den []float64) []float64 {
+	// N(-s): cambiar el signo de los coeficientes de potencia impar
+	mirrored := append([]float64(nil), num...)
+	for i := range mirrored {
+		if (len(mirrored)-1-i)%2 == 1 {
+			mirrored[i] = -mirrored[i]
+		}
+	}
+	q := polyMul(den, mirrored)
+
+	// Parte imaginaria de Q(jω): los términos impares, con j^k = j para k ≡ 1 y -j para k ≡ 3 (mod 4)
+	degree := len(q) - 1
+	im := make([]float64, len(q))
+	for i, c := range q {
+		switch (degree - i) % 4 {
+		case 1:
+			im[i] = c
+		case 3:
+			im[i] = -c
+		}
+	}
+
+	var out []float64
+	if isZero(im) {
+		return out
+	}
+	for _, w := range polyRoots(im) {
+		if math.Abs(imag(w)) <= 1e-7*math.Max(1, cmplx.Abs(w)) && real(w) >= 0 {
+			out = append(out, real(w))
+		}
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// matchRoots ordena roots para que cada una siga a la raíz de previous más cercana, emparejando
+// primero los pares más próximos
+func matchRoots(previous, roots []complex128) []complex128 {
+	type pair struct {
+		i, j int
+		d    float64
+	}
+	pairs := make([]pair, 0, len(previous)*len(roots))
+	for i, p := range previous {
+		for j, r := range roots {
+			pairs = append(pairs, pair{i, j, cmplx.Abs(p - r)})
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].d < pairs[b].d })
+
+	ordered := make([]complex128, len(previous))
+	usedPrev, usedRoot := make([]bool, len(previous)), make([]bool, len(roots))
+	for _, p := range pairs {
+		if usedPrev[p.i] || usedRoot[p.j] {
+			continue
+		}
+		ordered[p.i] = roots[p.j]
+		usedPrev[p.i], usedRoot[p.j] = true, true
+	}
+	return ordered
+}
+
+// complexSlice convierte raíces al formato Complex de la API
+func complexSlice(roots []complex128) []Complex {
+	out := make([]Complex, len(roots))
+	for i, r := range roots {
+		out[i] = FromComplex(r)
+	}
+	return out
+}
@@ -0,0 +1,189 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Bandas de establecimiento
+const (
+	DefaultSettlingBand = 0.02 // Banda del 2% del salto alrededor del valor final
+	MaxSettlingBand     = 0.2
+)
+
+// StepMetricsOptions son las opciones de las métricas de la respuesta transitoria
+type StepMetricsOptions struct {
+	SettlingBand float64 `json:"settling_band,omitempty"` // Fracción del salto (0.02 o 0.05 habitualmente, por defecto 0.02)
+}
+
+// Validate comprueba que las opciones sean coherentes
+func (o StepMetricsOptions) Validate() error {
+	if o.SettlingBand < 0 || o.SettlingBand > MaxSettlingBand {
+		return errors.New("metrics.settling_band debe estar entre 0 y 0.2 (por ejemplo 0.02 o 0.05)")
+	}
+	return nil
+}
+
+// Band devuelve la banda de establecimiento (la de por defecto si no se indicó)
+func (o *StepMetricsOptions) Band() float64 {
+	if o == nil || o.SettlingBand == 0 {
+		return DefaultSettlingBand
+	}
+	return o.SettlingBand
+}
+
+// StepMetrics son las métricas de la respuesta transitoria a un escalón, con las definiciones
+// habituales (IEEE Std 181 para los niveles y los tiempos de transición). Se calculan sobre la
+// respuesta normalizada (y - inicial) / (final - inicial), por lo que valen igual para
+// escalones negativos o con nivel inicial distinto de cero. Los tiempos se miden desde el
+// primer instante de la serie, que se toma como aplicación del escalón.
+type StepMetrics struct {
+	InitialValue      float64 `json:"initial_value"`
+	FinalValue        float64 `json:"final_value"`
+	RiseTime          float64 `json:"rise_time"`          // Del 10% al 90% del salto (0 si no se alcanza el 90%)
+	DelayTime         float64 `json:"delay_time"`         // Hasta el 50% del salto
+	PeakTime          float64 `json:"peak_time"`          // Hasta el máximo en la dirección del salto
+	PeakValue         float64 `json:"peak_value"`         // Valor de la salida en el pico
+	Overshoot         float64 `json:"overshoot"`          // Sobrepico sobre el valor final (% del salto)
+	Undershoot        float64 `json:"undershoot"`         // Excursión en sentido contrario al salto (% del salto)
+	SettlingTime      float64 `json:"settling_time"`      // Desde el que la salida queda dentro de la banda
+	SettlingBand      float64 `json:"settling_band"`      // Banda usada, como fracción del salto
+	Settled           bool    `json:"settled"`            // false si la salida termina fuera de la banda
+	DecayRatio        float64 `json:"decay_ratio"`        // Segundo sobrepico / primero (0 si hay menos de dos)
+	OscillationPeriod float64 `json:"oscillation_period"` // Entre sobrepicos consecutivos (0 si no oscila)
+	Oscillations      int     `json:"oscillations"`       // Sobrepicos que superan la banda
+	IAE               float64 `json:"iae"`                // ∫|e| dt con e = final - y
+	ISE               float64 `json:"ise"`                // ∫e² dt
+	ITAE              float64 `json:"itae"`               // ∫t·|e| dt
+}
+
+// extremum es el máximo apartamiento del valor final entre dos cruces
+type extremum struct {
+	t, e float64
+}
+
+// StepResponseMetrics calcula las métricas de la respuesta al escalón (t, y) que va del nivel
+// initial al nivel final, con la banda de establecimiento indicada (fracción del salto)
+func StepResponseMetrics(t, y []float64, initial, final, band float64) (*StepMetrics, error) {
+	if len(t) < 3 || len(t) != len(y) {
+		return nil, errors.New("se necesitan al menos 3 muestras de tiempo y salida")
+	}
+	step := final - initial
+	if step == 0 || math.IsNaN(step) || math.IsInf(step, 0) {
+		return nil, errors.New("la salida no cambia de nivel")
+	}
+	if band <= 0 {
+		band = DefaultSettlingBand
+	}
+
+	t0 := t[0]
+	yn := make([]float64, len(y))
+	for i, v := range y {
+		yn[i] = (v - initial) / step
+	}
+	m := &StepMetrics{InitialValue: initial, FinalValue: final, SettlingBand: band}
+
+	// Tiempos de subida y retardo por interpolación lineal de los cruces
+	if t10, ok := crossingTime(t, yn, 0.1); ok {
+		if t90, ok := crossingTime(t, yn, 0.9); ok {
+			m.RiseTime = t90 - t10
+		}
+	}
+	if t50, ok := crossingTime(t, yn, 0.5); ok {
+		m.DelayTime = t50 - t0
+	}
+
+	// Pico, sobrepico y subpico
+	peak := 0
+	for i := range yn {
+		if yn[i] > yn[peak] {
+			peak = i
+		}
+	}
+	m.PeakTime = t[peak] - t0
+	m.PeakValue = y[peak]
+	m.Overshoot = math.Max(0, yn[peak]-1) * 100
+	// El subpico solo cuenta antes del pico: después es parte de la oscilación
+	lowBeforePeak := 0
+	for i := 0; i <= peak; i++ {
+		if yn[i] < yn[lowBeforePeak] {
+			lowBeforePeak = i
+		}
+	}
+	m.Undershoot = math.Max(0, -yn[lowBeforePeak]) * 100
+
+	// Tiempo de establecimiento: la última salida de la banda
+	m.Settled = math.Abs(yn[len(yn)-1]-1) <= band
+	for i := len(yn) - 1; i >= 0; i-- {
+		if math.Abs(yn[i]-1) > band {
+			if i+1 < len(t) {
+				m.SettlingTime = t[i+1] - t0
+			} else {
+				m.SettlingTime = t[i] - t0
+			}
+			break
+		}
+	}
+
+	// Oscilaciones: extremos del error entre cruces del valor final, con histéresis de media
+	// banda para no contar el ruido
+	peaks := overshootPeaks(t, yn, band)
+	m.Oscillations = len(peaks)
+	if len(peaks) >= 2 {
+		m.DecayRatio = peaks[1].e / peaks[0].e
+		m.OscillationPeriod = (peaks[len(peaks)-1].t - peaks[0].t) / float64(len(peaks)-1)
+	}
+
+	// Criterios integrales (regla del trapecio), con el error en unidades de la salida
+	for i := 1; i < len(t); i++ {
+		dt := t[i] - t[i-1]
+		e0, e1 := final-y[i-1], final-y[i]
+		m.IAE += dt * (math.Abs(e0) + math.Abs(e1)) / 2
+		m.ISE += dt * (e0*e0 + e1*e1) / 2
+		m.ITAE += dt * ((t[i-1]-t0)*math.Abs(e0) + (t[i]-t0)*math.Abs(e1)) / 2
+	}
+
+	return m, nil
+}
+
+// crossingTime devuelve el primer instante en que yn alcanza level, interpolando entre muestras
+func crossingTime(t, yn []float64, level float64) (float64, bool) {
+	for i := range yn {
+		if yn[i] < level {
+			continue
+		}
+		if i == 0 || yn[i] == yn[i-1] {
+			return t[i], true
+		}
+		return t[i-1] + (t[i]-t[i-1])*(level-yn[i-1])/(yn[i]-yn[i-1]), true
+	}
+	return 0, false
+}
+
+// overshootPeaks devuelve los sobrepicos (máximos por encima del valor final normalizado 1)
+// que superan la banda, cada uno entre dos cruces del valor final con histéresis de band/2
+func overshootPeaks(t, yn []float64, band float64) []extremum {
+	hysteresis := band / 2
+	var peaks []extremum
+	above := false
+	var current extremum
+	for i := range yn {
+		e := yn[i] - 1
+		switch {
+		case !above && e > hysteresis:
+			above = true
+			current = extremum{t: t[i], e: e}
+		case above && e < -hysteresis:
+			above = false
+			if current.e > band {
+				peaks = append(peaks, current)
+			}
+		case above && e > current.e:
+			current = extremum{t: t[i], e: e}
+		}
+	}
+	if above && current.e > band {
+		peaks = append(peaks, current)
+	}
+	return peaks
+}
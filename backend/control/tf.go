@@ -0,0 +1,298 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// TransferFunction es una función de transferencia continua N(s)/D(s)·e^(-θs).
+// Los coeficientes están en potencias descendentes de s.
+type TransferFunction struct {
+	Num   []float64 `json:"num"`
+	Den   []float64 `json:"den"`
+	Delay float64   `json:"delay,omitempty"` // Retardo puro θ (s)
+}
+
+// NewTransferFunction crea una función de transferencia propia normalizando el denominador a mónico
+func NewTransferFunction(num, den []float64, delay float64) (TransferFunction, error) {
+	num = trimPoly(append([]float64(nil), num...))
+	den = trimPoly(append([]float64(nil), den...))
+	if len(den) == 0 || den[0] == 0 {
+		return TransferFunction{}, errors.New("el denominador no puede ser nulo")
+	}
+	if len(num) == 0 {
+		num = []float64{0}
+	}
+	if len(num) > len(den) {
+		return TransferFunction{}, errors.New("la función de transferencia debe ser propia (grado del numerador ≤ grado del denominador)")
+	}
+	if delay < 0 {
+		return TransferFunction{}, errors.New("el retardo no puede ser negativo")
+	}
+
+	lead := den[0]
+	return TransferFunction{
+		Num:   polyScale(num, 1/lead),
+		Den:   polyScale(den, 1/lead),
+		Delay: delay,
+	}, nil
+}
+
+// NewTransferFunctionZPK crea una función de transferencia a partir de ceros, polos y ganancia.
+// Si ningún polo está en el origen, gain es la ganancia estática G(0); en caso contrario es
+// el factor k de k·Π(s-z)/Π(s-p).
+func NewTransferFunctionZPK(zeros, poles []complex128, gain, delay float64) (TransferFunction, error) {
+	num := polyFromRoots(zeros)
+	den := polyFromRoots(poles)
+
+	k := gain
+	if dcDen := den[len(den)-1]; math.Abs(dcDen) > 1e-12 {
+		dcNum := num[len(num)-1]
+		if math.Abs(dcNum) < 1e-12 {
+			return TransferFunction{}, errors.New("un cero en el origen impide fijar la ganancia estática")
+		}
+		k = gain * dcDen / dcNum
+	}
+
+	return NewTransferFunction(polyScale(num, k), den, delay)
+}
+
+// Order devuelve el orden del sistema (grado del denominador)
+func (tf TransferFunction) Order() int {
+	return len(tf.Den) - 1
+}
+
+// Poles devuelve las raíces del denominador
+func (tf TransferFunction) Poles() []complex128 {
+	return polyRoots(tf.Den)
+}
+
+// Zeros devuelve las raíces del numerador
+func (tf TransferFunction) Zeros() []complex128 {
+	return polyRoots(tf.Num)
+}
+
+// DCGain devuelve la ganancia estática G(0) (infinita si hay un polo en el origen)
+func (tf TransferFunction) DCGain() float64 {
+	den := tf.Den[len(tf.Den)-1]
+	num := tf.Num[len(tf.Num)-1]
+	if den == 0 {
+		return math.Inf(1)
+	}
+	return num / den
+}
+
+// Eval evalúa G(s), incluido el retardo
+func (tf TransferFunction) Eval(s complex128) complex128 {
+	g := polyEval(tf.Num, s) / polyEval(tf.Den, s)
+	if tf.Delay > 0 {
+		g *= cmplx.Exp(-s * complex(tf.Delay, 0))
+	}
+	return g
+}
+
+// IsStable indica si todos los polos tienen parte real negativa
+func (tf TransferFunction) IsStable() bool {
+	for _, p := range tf.Poles() {
+		if real(p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// stateSpace devuelve la realización en forma canónica controlable (A, B, C, D)
+func (tf TransferFunction) stateSpace() (a matrix, b, c []float64, d float64) {
+	n := tf.Order()
+	den := tf.Den
+
+	// Numerador rellenado a n+1 coeficientes
+	num := make([]float64, n+1)
+	copy(num[n+1-len(tf.Num):], tf.Num)
+	d = num[0]
+
+	a = newMatrix(n, n)
+	for i := 0; i < n-1; i++ {
+		a[i][i+1] = 1
+	}
+	if n > 0 {
+		for j := 0; j < n; j++ {
+			a[n-1][j] = -den[n-j]
+		}
+	}
+
+	b = make([]float64, n)
+	if n > 0 {
+		b[n-1] = 1
+	}
+
+	c = make([]float64, n)
+	for j := 0; j < n; j++ {
+		c[j] = num[n-j] - den[n-j]*d
+	}
+	return a, b, c, d
+}
+
+// StepResponse simula la respuesta al escalón unitario aplicado en t = 0 (con estado
+// inicial nulo) en los instantes dados, que deben estar ordenados de forma ascendente.
+// La discretización es exacta para entrada constante, por lo que admite muestreo no uniforme.
+func (tf TransferFunction) StepResponse(t []float64) []float64 {
+	return tf.simulate(t, 0, func(tau float64) (float64, float64) {
+		if tau < 0 {
+			return 0, 0
+		}
+		return 1, math.Inf(1)
+	})
+}
+
+// Lsim simula la respuesta a una entrada muestreada u(t) retenida entre muestras (retenedor
+// de orden cero), con estado inicial nulo y entrada nula antes de t[0]
+func (tf TransferFunction) Lsim(t, u []float64) []float64 {
+	if len(t) == 0 {
+		return nil
+	}
+	cursor := 0
+	return tf.simulate(t, t[0], func(tau float64) (float64, float64) {
+		if tau < t[0] {
+			return 0, t[0]
+		}
+		// Las consultas llegan en orden creciente: basta con avanzar el cursor
+		for cursor+1 < len(t) && t[cursor+1] <= tau {
+			cursor++
+		}
+		if cursor+1 < len(t) {
+			return u[cursor], t[cursor+1]
+		}
+		return u[cursor], math.Inf(1)
+	})
+}
+
+// simulate integra el sistema de forma exacta para entradas constantes a tramos. input
+// devuelve el valor de la entrada en tau y el instante en que cambia; se consulta con
+// instantes no decrecientes. El estado es nulo hasta tau = start y los instantes de
+// evaluación se desplazan por el retardo.
+func (tf TransferFunction) simulate(t []float64, start float64, input func(tau float64) (float64, float64)) []float64 {
+	y := make([]float64, len(t))
+	if len(t) == 0 {
+		return y
+	}
+
+	a, b, c, d := tf.stateSpace()
+	n := len(b)
+	x := make([]float64, n)
+
+	// Matrices de transición de los últimos pasos usados: con muestreo uniforme (y retardo
+	// no múltiplo del período) solo aparecen uno o dos pasos distintos
+	type transition struct {
+		h     float64
+		phi   matrix
+		gamma []float64
+	}
+	var cache []*transition
+	discretize := func(h float64) *transition {
+		for _, tr := range cache {
+			if math.Abs(h-tr.h) <= 1e-9*tr.h {
+				return tr
+			}
+		}
+		phi, gamma := discretizeZOH(a, b, h)
+		tr := &transition{h: h, phi: phi, gamma: gamma}
+		if len(cache) < 4 {
+			cache = append(cache, tr)
+		} else {
+			copy(cache, cache[1:])
+			cache[3] = tr
+		}
+		return tr
+	}
+
+	prev := start
+	next := make([]float64, n)
+	for i, ti := range t {
+		tau := ti - tf.Delay
+		// Avanzar hasta tau cortando el intervalo en cada cambio de la entrada
+		for tau > prev && n > 0 {
+			uk, change := input(prev)
+			end := tau
+			if change > prev && change < tau {
+				end = change
+			}
+			if uk != 0 || !isZero(x) {
+				tr := discretize(end - prev)
+				for r := 0; r < n; r++ {
+					v := tr.gamma[r] * uk
+					for k := 0; k < n; k++ {
+						v += tr.phi[r][k] * x[k]
+					}
+					next[r] = v
+				}
+				x, next = next, x
+			}
+			prev = end
+		}
+
+		if tau >= start {
+			uk, _ := input(tau)
+			out := d * uk
+			for j := range x {
+				out += c[j] * x[j]
+			}
+			y[i] = out
+		}
+	}
+	return y
+}
+
+// discretizeZOH discretiza ẋ = Ax + Bu con retenedor de orden cero y período h:
+// exp([[A B],[0 0]]·h) = [[Φ Γ],[0 1]]
+func discretizeZOH(a matrix, b []float64, h float64) (matrix, []float64) {
+	n := len(b)
+	aug := newMatrix(n+1, n+1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			aug[i][j] = a[i][j] * h
+		}
+		aug[i][n] = b[i] * h
+	}
+	e := expm(aug)
+	phi, gamma := newMatrix(n, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		copy(phi[i], e[i][:n])
+		gamma[i] = e[i][n]
+	}
+	return phi, gamma
+}
+
+// discretizeFOH discretiza ẋ = Ax + Bu con retenedor de primer orden (entrada lineal en cada
+// paso): x[k+1] = Φ·x[k] + Γ0·u[k] + Γ1·u[k+1], a partir de
+// exp([[A B 0],[0 0 1],[0 0 0]]·h) = [[Φ Γa Γb],[0 1 h],[0 0 1]], con Γ0 = Γa - Γb/h y Γ1 = Γb/h
+func discretizeFOH(a matrix, b []float64, h float64) (matrix, []float64, []float64) {
+	n := len(b)
+	aug := newMatrix(n+2, n+2)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			aug[i][j] = a[i][j] * h
+		}
+		aug[i][n] = b[i] * h
+	}
+	aug[n][n+1] = h
+	e := expm(aug)
+	phi, gamma0, gamma1 := newMatrix(n, n), make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		copy(phi[i], e[i][:n])
+		gamma1[i] = e[i][n+1] / h
+		gamma0[i] = e[i][n] - gamma1[i]
+	}
+	return phi, gamma0, gamma1
+}
+
+// isZero indica si todos los elementos de un vector son cero
+func isZero(v []float64) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// Reglas de sintonía
+const (
+	TuningZieglerNichols = "ziegler_nichols" // Curva de reacción (lazo abierto)
+	TuningCohenCoon      = "cohen_coon"
+	TuningIMC            = "imc"  // Rivera–Morari–Skogestad, con filtro λ
+	TuningSIMC           = "simc" // Skogestad, con τc = λ
+	TuningAMIGO          = "amigo"
+	TuningPolePlacement  = "pole_placement"
+)
+
+// TuningRules son todas las reglas de sintonía, en el orden en que se devuelven
+var TuningRules = []string{TuningZieglerNichols, TuningCohenCoon, TuningIMC, TuningSIMC, TuningAMIGO, TuningPolePlacement}
+
+// Valores por defecto de la sintonía
+const (
+	DefaultTuningZeta = 0.7 // Amortiguamiento deseado de la asignación de polos
+	minLambdaFraction = 0.1 // λ mínimo por defecto, como fracción de τ
+)
+
+// MethodHalfRule indica un FOPDT obtenido de un SOPDT por la regla de la mitad de Skogestad
+const MethodHalfRule = "half_rule"
+
+// TuningOptions son las opciones de la sintonía de controladores
+type TuningOptions struct {
+	Lambda       float64  `json:"lambda,omitempty"`        // λ de IMC/SIMC (s); por defecto θ, con un mínimo del 10% de τ
+	Zeta         float64  `json:"zeta,omitempty"`          // Amortiguamiento de la asignación de polos (por defecto 0.7)
+	Rules        []string `json:"rules,omitempty"`         // Reglas a aplicar (por defecto todas)
+	SettlingBand float64  `json:"settling_band,omitempty"` // Banda de establecimiento de la predicción (por defecto 0.02)
+}
+
+// Validate comprueba que las opciones sean coherentes
+func (o TuningOptions) Validate() error {
+	if o.Lambda < 0 || math.IsNaN(o.Lambda) || math.IsInf(o.Lambda, 0) {
+		return errors.New("lambda debe ser un número positivo")
+	}
+	if o.Zeta < 0 || o.Zeta > 2 || math.IsNaN(o.Zeta) {
+		return errors.New("zeta debe estar entre 0 y 2")
+	}
+	if err := (StepMetricsOptions{SettlingBand: o.SettlingBand}).Validate(); err != nil {
+		return errors.New("settling_band debe estar entre 0 y 0.2")
+	}
+	for _, rule := range o.Rules {
+		if !isTuningRule(rule) {
+			return errors.New("regla de sintonía desconocida: " + rule)
+		}
+	}
+	return nil
+}
+
+// isTuningRule indica si rule es una de las reglas de sintonía
+func isTuningRule(rule string) bool {
+	for _, r := range TuningRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// TuningPlant es la planta a controlar: la función de transferencia identificada (con su
+// retardo), que se usa para predecir el lazo cerrado, y el modelo de proceso FOPDT/SOPDT si
+// existe, del que parten las reglas
+type TuningPlant struct {
+	Model   TransferFunction
+	Process *ProcessModel
+}
+
+// PIDTuning es un juego de ganancias con el comportamiento previsto del lazo cerrado
+type PIDTuning struct {
+	Rule       string                `json:"rule"`
+	Gains      PIDGains              `json:"gains"`
+	Prediction *ClosedLoopPrediction `json:"prediction,omitempty"`
+}
+
+// SkippedTuning es una regla que no se pudo aplicar a la planta
+type SkippedTuning struct {
+	Rule       string `json:"rule"`
+	Controller string `json:"controller"`
+	Reason     string `json:"reason"`
+}
+
+// TuningResult son las sintonías recomendadas para la planta
+type TuningResult struct {
+	Plant   TransferFunction `json:"plant"`
+	FOPDT   ProcessModel     `json:"fopdt"` // Aproximación de primer orden con retardo usada por las reglas
+	Lambda  float64          `json:"lambda"`
+	Zeta    float64          `json:"zeta"`
+	Horizon float64          `json:"horizon"` // Horizonte de la simulación de la predicción (s)
+	Tunings []PIDTuning      `json:"tunings"`
+	Skipped []SkippedTuning  `json:"skipped,omitempty"`
+}
+
+// TunePID calcula ganancias PI y PID con las reglas pedidas y predice, simulando el lazo
+// cerrado con la planta completa, el sobrepico y el tiempo de establecimiento de cada juego
+func TunePID(plant TuningPlant, options TuningOptions) (*TuningResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if !plant.Model.IsStable() {
+		return nil, errors.New("las reglas de sintonía requieren una planta estable")
+	}
+	if k := plant.Model.DCGain(); k == 0 || math.IsInf(k, 0) || math.IsNaN(k) {
+		return nil, errors.New("la planta no tiene ganancia estática finita")
+	}
+
+	fopdt, err := fopdtApproximation(plant)
+	if err != nil {
+		return nil, err
+	}
+	k, tau, theta := fopdt.Gain, fopdt.Tau, fopdt.DeadTime
+
+	result := &TuningResult{
+		Plant:   plant.Model,
+		FOPDT:   fopdt,
+		Lambda:  options.Lambda,
+		Zeta:    options.Zeta,
+		Horizon: ClosedLoopHorizon(plant.Model),
+	}
+	if result.Lambda == 0 {
+		result.Lambda = math.Max(theta, minLambdaFraction*tau)
+	}
+	if result.Zeta == 0 {
+		result.Zeta = DefaultTuningZeta
+	}
+	lambda := result.Lambda
+
+	rules := options.Rules
+	if len(rules) == 0 {
+		rules = TuningRules
+	}
+	skip := func(rule, controller, reason string) {
+		result.Skipped = append(result.Skipped, SkippedTuning{Rule: rule, Controller: controller, Reason: reason})
+	}
+	add := func(rule string, kp, ti, td float64) {
+		gains := NewPIDGains(kp, ti, td)
+		if kp*k <= 0 || ti <= 0 || td < 0 || math.IsNaN(kp+ti+td) || math.IsInf(kp+ti+td, 0) {
+			skip(rule, gains.Controller, "la regla da ganancias no válidas para esta planta")
+			return
+		}
+		tuning := PIDTuning{Rule: rule, Gains: gains}
+		if controller, err := gains.TransferFunction(); err == nil {
+			prediction, _, err := PredictClosedLoop(plant.Model, controller, result.Horizon, options.SettlingBand)
+			if err != nil {
+				prediction = &ClosedLoopPrediction{SimulationError: err.Error()}
+			}
+			tuning.Prediction = prediction
+		}
+		result.Tunings = append(result.Tunings, tuning)
+	}
+
+	for _, rule := range rules {
+		// Las reglas empíricas se definen para un retardo positivo
+		if theta <= 0 && (rule == TuningZieglerNichols || rule == TuningCohenCoon || rule == TuningAMIGO) {
+			skip(rule, ControllerPI, "requiere un tiempo muerto positivo")
+			skip(rule, ControllerPID, "requiere un tiempo muerto positivo")
+			continue
+		}
+
+		switch rule {
+		case TuningZieglerNichols:
+			a := k * theta / tau
+			add(rule, 0.9/a, 3*theta, 0)
+			add(rule, 1.2/a, 2*theta, 0.5*theta)
+
+		case TuningCohenCoon:
+			r := theta / tau
+			add(rule, (0.9+r/12)/(k*r), theta*(30+3*r)/(9+20*r), 0)
+			add(rule, (4.0/3+r/4)/(k*r), theta*(32+6*r)/(13+8*r), 4*theta/(11+2*r))
+
+		case TuningIMC:
+			add(rule, tau/(k*(lambda+theta)), tau, 0)
+			add(rule, (2*tau+theta)/(k*(2*lambda+theta)), tau+theta/2, tau*theta/(2*tau+theta))
+
+		case TuningSIMC:
+			add(rule, tau/(k*(lambda+theta)), math.Min(tau, 4*(lambda+theta)), 0)
+			// El PID de SIMC cancela la segunda constante de tiempo del SOPDT (forma serie)
+			if p := plant.Process; p != nil && p.Type == ProcessSOPDT {
+				kc := p.Tau / (p.Gain * (lambda + p.DeadTime))
+				ti, td := math.Min(p.Tau, 4*(lambda+p.DeadTime)), p.Tau2
+				add(rule, kc*(1+td/ti), ti+td, ti*td/(ti+td))
+			} else {
+				skip(rule, ControllerPID, "requiere un modelo SOPDT")
+			}
+
+		case TuningAMIGO:
+			add(rule, 0.15/k+(0.35-theta*tau/((theta+tau)*(theta+tau)))*tau/(k*theta),
+				0.35*theta+13*theta*tau*tau/(tau*tau+12*theta*tau+7*theta*theta), 0)
+			add(rule, (0.2+0.45*tau/theta)/k, (0.4*theta+0.8*tau)/(theta+0.1*tau)*theta, 0.5*theta*tau/(0.3*theta+tau))
+
+		case TuningPolePlacement:
+			// PI: polos s² + 2ζω0·s + ω0² sobre el FOPDT sin retardo, con ω0 = 1/(λ + θ) para
+			// que el lazo no sea más rápido de lo que el retardo permite
+			w0, zeta := 1/(lambda+theta), result.Zeta
+			add(rule, (2*zeta*w0*tau-1)/k, (2*zeta*w0*tau-1)/(w0*w0*tau), 0)
+			// PID: (s² + 2ζω0·s + ω0²)(s + ω0) con la planta de segundo orden K·b/(s² + a1·s + a2)
+			if a1, a2, b, ok := secondOrderCoefficients(plant); ok {
+				kp := ((1+2*zeta)*w0*w0 - a2) / b
+				kd := ((1+2*zeta)*w0 - a1) / b
+				ki := w0 * w0 * w0 / b
+				if kd <= 0 {
+					skip(rule, ControllerPID, "los polos pedidos son más lentos que la planta: la acción derivativa sería negativa")
+				} else {
+					add(rule, kp, kp/ki, kd/kp)
+				}
+			} else {
+				skip(rule, ControllerPID, "requiere una planta de segundo orden sin ceros")
+			}
+		}
+	}
+
+	if len(result.Tunings) == 0 {
+		return nil, errors.New("ninguna regla de sintonía es aplicable a esta planta")
+	}
+	return result, nil
+}
+
+// fopdtApproximation devuelve el modelo de primer orden con retardo que usan las reglas:
+// el FOPDT identificado, el SOPDT reducido por la regla de la mitad de Skogestad
+// (τ = τ1 + τ2/2, θ = θ + τ2/2) o, sin modelo de proceso, el ajuste de la respuesta al
+// escalón simulada de la planta
+func fopdtApproximation(plant TuningPlant) (ProcessModel, error) {
+	if p := plant.Process; p != nil && p.Tau > 0 {
+		fopdt := *p
+		if p.Type == ProcessSOPDT {
+			fopdt.Type, fopdt.Method = ProcessFOPDT, MethodHalfRule
+			fopdt.Tau, fopdt.Tau2, fopdt.DeadTime = p.Tau+p.Tau2/2, 0, p.DeadTime+p.Tau2/2
+		}
+		return fopdt, nil
+	}
+
+	horizon := ClosedLoopHorizon(plant.Model)
+	t := make([]float64, closedLoopSamples)
+	for i := range t {
+		t[i] = horizon * float64(i) / float64(len(t)-1)
+	}
+	y := plant.Model.StepResponse(t)
+	id, err := IdentifyProcessModels(t, y, 1, 0, plant.Model.DCGain(), 0)
+	if err != nil {
+		return ProcessModel{}, errors.New("no se pudo aproximar la planta por un modelo FOPDT: " + err.Error())
+	}
+	for _, m := range id.Candidates {
+		if m.Type == ProcessFOPDT {
+			return m, nil
+		}
+	}
+	return ProcessModel{}, errors.New("no se pudo aproximar la planta por un modelo FOPDT")
+}
+
+// secondOrderCoefficients devuelve a1, a2 y b de la planta b/(s² + a1·s + a2): la función
+// de transferencia si es de segundo orden sin ceros, o el SOPDT sin su retardo
+func secondOrderCoefficients(plant TuningPlant) (a1, a2, b float64, ok bool) {
+	if m := plant.Model; m.Order() == 2 && len(m.Num) == 1 {
+		return m.Den[1] / m.Den[0], m.Den[2] / m.Den[0], m.Num[0] / m.Den[0], true
+	}
+	if p := plant.Process; p != nil && p.Type == ProcessSOPDT && p.Tau > 0 && p.Tau2 > 0 {
+		return (p.Tau + p.Tau2) / (p.Tau * p.Tau2), 1 / (p.Tau * p.Tau2), p.Gain / (p.Tau * p.Tau2), true
+	}
+	return 0, 0, 0, false
+}
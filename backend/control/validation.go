@@ -0,0 +1,150 @@
+package control
+
+import (
+	"errors"
+	"math"
+)
+
+// StepExperiment describe el escalón aplicado durante el ensayo
+type StepExperiment struct {
+	StepTime     float64 // Instante en que se aplica el escalón
+	InitialValue float64 // Nivel de la salida antes del escalón
+	Amplitude    float64 // Amplitud del escalón de entrada
+}
+
+// SimulateStep simula la respuesta de G(s) con los ceros, polos y ganancia estática dados
+// ante el escalón del experimento, evaluada en el vector de tiempo medido
+func SimulateStep(zeros, poles []complex128, gain float64, t []float64, exp StepExperiment) ([]float64, error) {
+	if len(poles) == 0 {
+		return nil, errors.New("se necesita al menos un polo para simular")
+	}
+	tf, err := NewTransferFunctionZPK(zeros, poles, gain, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	shifted := make([]float64, len(t))
+	for i := range t {
+		shifted[i] = t[i] - exp.StepTime
+	}
+
+	y := tf.StepResponse(shifted)
+	for i := range y {
+		y[i] = exp.InitialValue + exp.Amplitude*y[i]
+	}
+	return y, nil
+}
+
+// FitQuality resume la bondad de ajuste entre la salida medida y la simulada
+type FitQuality struct {
+	FitPercent  float64        `json:"fit_percent"` // 100·(1 - ‖y-ŷ‖/‖y-ȳ‖), criterio NRMSE
+	RSquared    float64        `json:"r_squared"`
+	RMS         float64        `json:"rms"`
+	MaxResidual float64        `json:"max_residual"` // Máximo error absoluto
+	Whiteness   WhitenessCheck `json:"whiteness"`
+}
+
+// WhitenessCheck es la prueba de blancura de los residuos (Ljung–Box sobre la autocorrelación)
+type WhitenessCheck struct {
+	Lags               int     `json:"lags"`
+	Statistic          float64 `json:"statistic"`           // Estadístico Q de Ljung–Box
+	Threshold          float64 `json:"threshold"`           // Cuantil χ² al 95% con Lags grados de libertad
+	ConfidenceBound    float64 `json:"confidence_bound"`    // ±1.96/√N para cada autocorrelación
+	MaxAutocorrelation float64 `json:"max_autocorrelation"` // Máxima |r_k| para k ≥ 1
+	OutsideBound       int     `json:"outside_bound"`       // Retardos con |r_k| fuera de la banda
+	White              bool    `json:"white"`
+}
+
+// EvaluateFit compara la salida medida y con la simulada yHat
+func EvaluateFit(y, yHat []float64) (*FitQuality, error) {
+	n := len(y)
+	if n != len(yHat) || n < 3 {
+		return nil, errors.New("las series medida y simulada deben tener la misma longitud")
+	}
+
+	yMean := mean(y)
+	residuals := make([]float64, n)
+	sse, sst, maxAbs := 0.0, 0.0, 0.0
+	for i := range y {
+		residuals[i] = y[i] - yHat[i]
+		sse += residuals[i] * residuals[i]
+		sst += (y[i] - yMean) * (y[i] - yMean)
+		maxAbs = math.Max(maxAbs, math.Abs(residuals[i]))
+	}
+	if math.IsNaN(sse) || math.IsInf(sse, 0) {
+		return nil, errors.New("la simulación produjo valores no finitos")
+	}
+
+	quality := &FitQuality{
+		RMS:         math.Sqrt(sse / float64(n)),
+		MaxResidual: maxAbs,
+		Whiteness:   residualWhiteness(residuals),
+	}
+	if sst > 0 {
+		quality.FitPercent = 100 * (1 - math.Sqrt(sse/sst))
+		quality.RSquared = 1 - sse/sst
+	}
+	return quality, nil
+}
+
+// residualWhiteness aplica la prueba de Ljung–Box a los residuos
+func residualWhiteness(residuals []float64) WhitenessCheck {
+	n := len(residuals)
+	lags := n / 4
+	if lags > 20 {
+		lags = 20
+	}
+	if lags < 1 {
+		lags = 1
+	}
+
+	r := autocorrelation(residuals, lags)
+	check := WhitenessCheck{
+		Lags:            lags,
+		ConfidenceBound: 1.96 / math.Sqrt(float64(n)),
+	}
+
+	q := 0.0
+	for k := 1; k <= lags; k++ {
+		q += r[k] * r[k] / float64(n-k)
+		if math.Abs(r[k]) > check.MaxAutocorrelation {
+			check.MaxAutocorrelation = math.Abs(r[k])
+		}
+		if math.Abs(r[k]) > check.ConfidenceBound {
+			check.OutsideBound++
+		}
+	}
+	check.Statistic = float64(n) * float64(n+2) * q
+	check.Threshold = chiSquareQuantile95(lags)
+	check.White = check.Statistic <= check.Threshold
+	return check
+}
+
+// autocorrelation calcula la autocorrelación normalizada r_0..r_maxLag (r_0 = 1)
+func autocorrelation(x []float64, maxLag int) []float64 {
+	m := mean(x)
+	r := make([]float64, maxLag+1)
+	var c0 float64
+	for _, v := range x {
+		c0 += (v - m) * (v - m)
+	}
+	if c0 == 0 {
+		r[0] = 1
+		return r
+	}
+	for k := 0; k <= maxLag; k++ {
+		var ck float64
+		for i := k; i < len(x); i++ {
+			ck += (x[i] - m) * (x[i-k] - m)
+		}
+		r[k] = ck / c0
+	}
+	return r
+}
+
+// chiSquareQuantile95 aproxima el cuantil 95% de χ² con k grados de libertad (Wilson–Hilferty)
+func chiSquareQuantile95(k int) float64 {
+	const z = 1.644854
+	v := 2 / (9 * float64(k))
+	return float64(k) * math.Pow(1-v+z*math.Sqrt(v), 3)
+}
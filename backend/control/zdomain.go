@@ -0,0 +1,287 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// Métodos de conversión entre los dominios s y z
+const (
+	ConversionZOH    = "zoh"    // Retenedor de orden cero (exacta para entradas escalonadas)
+	ConversionTustin = "tustin" // Transformación bilineal s = (2/T)·(z-1)/(z+1)
+)
+
+// DiscreteTransferFunction es una función de transferencia discreta N(z)/D(z) con período de
+// muestreo T. Los coeficientes están en potencias descendentes de z.
+type DiscreteTransferFunction struct {
+	Num            []float64 `json:"num"`
+	Den            []float64 `json:"den"`
+	SamplingPeriod float64   `json:"sampling_period"`
+}
+
+// NewDiscreteTransferFunction crea una función de transferencia discreta propia normalizando el
+// denominador a mónico
+func NewDiscreteTransferFunction(num, den []float64, ts float64) (DiscreteTransferFunction, error) {
+	if ts <= 0 || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return DiscreteTransferFunction{}, errors.New("el período de muestreo debe ser positivo")
+	}
+	tf, err := NewTransferFunction(num, den, 0)
+	if err != nil {
+		return DiscreteTransferFunction{}, err
+	}
+	return DiscreteTransferFunction{Num: tf.Num, Den: tf.Den, SamplingPeriod: ts}, nil
+}
+
+// Poles devuelve las raíces del denominador
+func (d DiscreteTransferFunction) Poles() []complex128 {
+	return polyRoots(d.Den)
+}
+
+// DCGain devuelve la ganancia estática G(z = 1) (infinita si hay un polo en z = 1)
+func (d DiscreteTransferFunction) DCGain() float64 {
+	den := real(polyEval(d.Den, 1))
+	if math.Abs(den) < 1e-12 {
+		return math.Inf(1)
+	}
+	return real(polyEval(d.Num, 1)) / den
+}
+
+// IsStable indica si todos los polos están dentro del círculo unidad
+func (d DiscreteTransferFunction) IsStable() bool {
+	for _, p := range d.Poles() {
+		if cmplx.Abs(p) >= 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Discretize convierte G(s) al dominio z con período ts. El retardo se redondea al número
+// entero de períodos más cercano y se representa con polos en el origen.
+func Discretize(tf TransferFunction, ts float64, method string) (DiscreteTransferFunction, error) {
+	if ts <= 0 || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return DiscreteTransferFunction{}, errors.New("el período de muestreo debe ser positivo")
+	}
+	var num, den []float64
+	switch method {
+	case ConversionZOH:
+		a, b, c, d := tf.stateSpace()
+		phi, gamma := discretizeZOH(a, b, ts)
+		num, den = stateSpaceNumDen(phi, gamma, c, d)
+	case ConversionTustin:
+		n := tf.Order()
+		num = substitutePoly(padPoly(tf.Num, n+1), []float64{1, -1}, []float64{1, 1}, 2/ts)
+		den = substitutePoly(tf.Den, []float64{1, -1}, []float64{1, 1}, 2/ts)
+	default:
+		return DiscreteTransferFunction{}, fmt.Errorf("método de conversión desconocido: %s (zoh o tustin)", method)
+	}
+	if samples := int(math.Round(tf.Delay / ts)); samples > 0 {
+		den = append(den, make([]float64, samples)...)
+	}
+	return NewDiscreteTransferFunction(num, den, ts)
+}
+
+// Continuous convierte la función de transferencia discreta al dominio s. Los polos en el
+// origen se interpretan como retardo puro de un período cada uno. Con ZOH se usa el logaritmo
+// de la matriz de transición, que no existe si hay polos en el semieje real negativo.
+func (d DiscreteTransferFunction) Continuous(method string) (TransferFunction, error) {
+	ts := d.SamplingPeriod
+	if ts <= 0 || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return TransferFunction{}, errors.New("el período de muestreo debe ser positivo")
+	}
+	num, den, samples := d.withoutDelay()
+	delay := float64(samples) * ts
+
+	switch method {
+	case ConversionZOH:
+		n := len(den) - 1
+		for _, p := range polyRoots(den) {
+			if cmplx.Abs(p) < 1e-9 || (real(p) < 0 && math.Abs(imag(p)) < 1e-9) {
+				return TransferFunction{}, fmt.Errorf("el polo z = %.4g no tiene equivalente continuo con ZOH", real(p))
+			}
+		}
+		a, b, c, dd := TransferFunction{Num: num, Den: den}.stateSpace()
+
+		// log([[Φ Γ],[0 1]])/T = [[A B],[0 0]]
+		aug := newMatrix(n+1, n+1)
+		for i := 0; i < n; i++ {
+			copy(aug[i], a[i])
+			aug[i][n] = b[i]
+		}
+		aug[n][n] = 1
+		l, err := logm(aug)
+		if err != nil {
+			return TransferFunction{}, err
+		}
+		ac, bc := newMatrix(n, n), make([]float64, n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				ac[i][j] = l[i][j] / ts
+			}
+			bc[i] = l[i][n] / ts
+		}
+		cnum, cden := stateSpaceNumDen(ac, bc, c, dd)
+		return NewTransferFunction(cnum, cden, delay)
+	case ConversionTustin:
+		// z = (1 + s·T/2)/(1 - s·T/2)
+		n := len(den) - 1
+		cnum := substitutePoly(padPoly(num, n+1), []float64{ts / 2, 1}, []float64{-ts / 2, 1}, 1)
+		cden := substitutePoly(den, []float64{ts / 2, 1}, []float64{-ts / 2, 1}, 1)
+		if math.Abs(cden[0]) < 1e-12*math.Max(1, maxAbs(cden)) {
+			return TransferFunction{}, errors.New("un polo en z = -1 no tiene equivalente continuo con Tustin")
+		}
+		return NewTransferFunction(cnum, cden, delay)
+	default:
+		return TransferFunction{}, fmt.Errorf("método de conversión desconocido: %s (zoh o tustin)", method)
+	}
+}
+
+// withoutDelay separa los polos en el origen que puede absorber un retardo z^-k sin que la
+// función deje de ser propia. Los ceros y polos en el origen comunes se cancelan.
+func (d DiscreteTransferFunction) withoutDelay() (num, den []float64, samples int) {
+	num, den = append([]float64(nil), d.Num...), append([]float64(nil), d.Den...)
+	tol := 1e-12 * math.Max(1, maxAbs(den))
+	for len(den) > 1 && math.Abs(den[len(den)-1]) < tol {
+		switch {
+		case len(num) > 1 && math.Abs(num[len(num)-1]) < tol:
+			num = num[:len(num)-1]
+		case len(trimPoly(num)) < len(den):
+			samples++
+		default:
+			return num, den, samples
+		}
+		den = den[:len(den)-1]
+	}
+	return num, den, samples
+}
+
+// stateSpaceNumDen devuelve numerador y denominador de c·(λI - A)⁻¹·b + d con
+// D(λ) = det(λI - A) y N(λ) = det(λI - A + b·c) - D(λ) + d·D(λ)
+func stateSpaceNumDen(a matrix, b, c []float64, d float64) ([]float64, []float64) {
+	n := len(b)
+	den := charPoly(a)
+	closed := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			closed[i][j] = a[i][j] - b[i]*c[j]
+		}
+	}
+	num := polyAdd(polyAdd(charPoly(closed), polyScale(den, -1)), polyScale(den, d))
+	return num, den
+}
+
+// charPoly calcula el polinomio característico det(λI - A) (mónico, potencias descendentes)
+// con el algoritmo de Faddeev–LeVerrier
+func charPoly(a matrix) []float64 {
+	n := len(a)
+	coeffs := make([]float64, n+1)
+	coeffs[0] = 1
+	if n == 0 {
+		return coeffs
+	}
+	m := newMatrix(n, n)
+	for k := 1; k <= n; k++ {
+		// M_k = A·M_(k-1) + c_(k-1)·I,  c_k = -tr(A·M_k)/k
+		m = a.mul(m).add(identity(n).scale(coeffs[k-1]))
+		am := a.mul(m)
+		trace := 0.0
+		for i := 0; i < n; i++ {
+			trace += am[i][i]
+		}
+		coeffs[k] = -trace / float64(k)
+	}
+	return coeffs
+}
+
+// substitutePoly sustituye la variable x = scale·p(v)/q(v) en el polinomio P(x) de grado
+// len(poly)-1 y multiplica por q(v)^grado: Σ c_k·scale^k·p^k·q^(n-k)
+func substitutePoly(poly, p, q []float64, scale float64) []float64 {
+	n := len(poly) - 1
+	out := []float64{0}
+	for k := 0; k <= n; k++ {
+		term := []float64{poly[n-k] * math.Pow(scale, float64(k))}
+		for i := 0; i < k; i++ {
+			term = polyMul(term, p)
+		}
+		for i := k; i < n; i++ {
+			term = polyMul(term, q)
+		}
+		out = polyAdd(out, term)
+	}
+	return out
+}
+
+// padPoly rellena p con ceros a la izquierda hasta length coeficientes
+func padPoly(p []float64, length int) []float64 {
+	if len(p) >= length {
+		return p
+	}
+	out := make([]float64, length)
+	copy(out[length-len(p):], p)
+	return out
+}
+
+// maxAbs devuelve el mayor valor absoluto de los coeficientes
+func maxAbs(p []float64) float64 {
+	m := 0.0
+	for _, v := range p {
+		m = math.Max(m, math.Abs(v))
+	}
+	return m
+}
+
+// logm calcula el logaritmo principal de una matriz por escalado inverso y cuadrado: se
+// toman raíces cuadradas hasta que ‖X - I‖ ≤ 1/4, se suma la serie de log(I + E) y se
+// multiplica por 2^k
+func logm(a matrix) (matrix, error) {
+	n := len(a)
+	x := a
+	roots := 0
+	for x.add(identity(n).scale(-1)).normInf() > 0.25 {
+		if roots >= 50 {
+			return nil, errors.New("el logaritmo de la matriz no converge")
+		}
+		var err error
+		if x, err = sqrtm(x); err != nil {
+			return nil, err
+		}
+		roots++
+	}
+
+	e := x.add(identity(n).scale(-1))
+	result, power := newMatrix(n, n), identity(n)
+	for k := 1; k <= 40; k++ {
+		power = power.mul(e)
+		sign := 1.0
+		if k%2 == 0 {
+			sign = -1
+		}
+		result = result.add(power.scale(sign / float64(k)))
+	}
+	return result.scale(math.Pow(2, float64(roots))), nil
+}
+
+// sqrtm calcula la raíz cuadrada principal de una matriz con la iteración de Denman–Beavers
+func sqrtm(a matrix) (matrix, error) {
+	y, z := a, identity(len(a))
+	for iter := 0; iter < 100; iter++ {
+		yInv, err := invert(y)
+		if err != nil {
+			return nil, errors.New("la matriz no tiene raíz cuadrada real")
+		}
+		zInv, err := invert(z)
+		if err != nil {
+			return nil, errors.New("la matriz no tiene raíz cuadrada real")
+		}
+		next := y.add(zInv).scale(0.5)
+		z = z.add(yInv).scale(0.5)
+		change := next.add(y.scale(-1)).normInf()
+		y = next
+		if change <= 1e-14*math.Max(1, y.normInf()) {
+			return y, nil
+		}
+	}
+	return nil, errors.New("la raíz cuadrada de la matriz no converge")
+}
@@ -30,6 +30,9 @@ func MigrateDatabase(db *gorm.DB) error {
 			&models.Result{},
 			&models.ContactForm{},
 			&models.FeedbackForm{},
+			&models.AnalysisJob{},
+			&models.DocumentSeries{},
+			&models.DiscreteModel{},
 		); err != nil {
 			log.Printf("Error al crear las tablas: %v", err)
 			return err
@@ -112,6 +115,87 @@ func applyMigrations(db *gorm.DB) error {
 		return err
 	}
 
+	// Agregar columnas del modelo analítico de segundo orden en la tabla results
+	if err := addNewColumnIfNotExists(db, "results", "analytic_model", "JSONB"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "fit_polo1_real", "REAL"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "fit_polo1_imag", "REAL"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "fit_polo2_real", "REAL"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "fit_polo2_imag", "REAL"); err != nil {
+		return err
+	}
+
+	// Agregar columnas del ciclo de vida en la tabla analysis_requests
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "status", "VARCHAR(20) NOT NULL DEFAULT 'queued'"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "stage", "VARCHAR(30)"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "error_code", "VARCHAR(50)"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "error_message", "VARCHAR(1000)"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "started_at", "TIMESTAMP WITH TIME ZONE"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "finished_at", "TIMESTAMP WITH TIME ZONE"); err != nil {
+		return err
+	}
+
+	// Agregar modo y opciones del análisis, y el espectro de los resultados
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "mode", "VARCHAR(20) NOT NULL DEFAULT 'step_response'"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "analysis_requests", "options", "JSONB"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "spectrum", "JSONB"); err != nil {
+		return err
+	}
+
+	// Agregar el tiempo muerto y el instante del escalón en la tabla results
+	if err := addNewColumnIfNotExists(db, "results", "dead_time", "DOUBLE PRECISION"); err != nil {
+		return err
+	}
+	if err := addNewColumnIfNotExists(db, "results", "step_time", "DOUBLE PRECISION"); err != nil {
+		return err
+	}
+
+	// Agregar el modelo de proceso FOPDT/SOPDT en la tabla results
+	if err := addNewColumnIfNotExists(db, "results", "process_model", "JSONB"); err != nil {
+		return err
+	}
+
+	// Las solicitudes procesadas antes de existir el estado se marcan como exitosas
+	if err := db.Exec("UPDATE analysis_requests SET status = 'succeeded' WHERE is_processed = TRUE AND status = 'queued'").Error; err != nil {
+		return err
+	}
+
+	// Crear la tabla de la cola de trabajos de análisis
+	if err := createTableIfNotExists(db, &models.AnalysisJob{}, "analysis_jobs"); err != nil {
+		return err
+	}
+
+	// Crear la tabla de las series guardadas de los documentos
+	if err := createTableIfNotExists(db, &models.DocumentSeries{}, "document_series"); err != nil {
+		return err
+	}
+
+	// Crear la tabla de los modelos discretos de los resultados
+	if err := createTableIfNotExists(db, &models.DiscreteModel{}, "discrete_models"); err != nil {
+		return err
+	}
+
 	log.Println("Todas las migraciones aplicadas correctamente")
 	return nil
 }
@@ -149,3 +233,20 @@ func addNewColumnIfNotExists(db *gorm.DB, tableName, columnName, columnType stri
 
 	return nil
 }
+
+// Función auxiliar para crear tablas nuevas a partir de su modelo
+func createTableIfNotExists(db *gorm.DB, model interface{}, tableName string) error {
+	if db.Migrator().HasTable(tableName) {
+		log.Printf("Tabla %s ya existe", tableName)
+		return nil
+	}
+
+	log.Printf("Creando tabla %s...", tableName)
+
+	if err := db.Migrator().CreateTable(model); err != nil {
+		return err
+	}
+
+	log.Printf("Tabla %s creada exitosamente", tableName)
+	return nil
+}
@@ -0,0 +1,129 @@
+package dsp
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// LTTB elige hasta points índices de la serie (x, y) con el algoritmo Largest-Triangle-
+// Three-Buckets: conserva el primer y el último punto y, de cada uno de los points-2 tramos
+// intermedios, el punto que forma el triángulo de mayor área con el punto elegido en el tramo
+// anterior y el promedio del tramo siguiente. Devuelve los índices en orden creciente.
+func LTTB(x, y []float64, points int) []int {
+	n := len(x)
+	if points >= n || points < 3 {
+		return allIndices(n, points)
+	}
+
+	indices := make([]int, 0, points)
+	indices = append(indices, 0)
+	bucket := float64(n-2) / float64(points-2)
+	selected := 0
+	for b := 0; b < points-2; b++ {
+		// Tramo actual [start, end) y promedio del siguiente (el último punto al final)
+		start := int(math.Floor(float64(b)*bucket)) + 1
+		end := int(math.Floor(float64(b+1)*bucket)) + 1
+		nextStart, nextEnd := end, min(int(math.Floor(float64(b+2)*bucket))+1, n)
+		if b == points-3 {
+			nextStart, nextEnd = n-1, n
+		}
+		avgX, avgY := mean(x[nextStart:nextEnd]), mean(y[nextStart:nextEnd])
+
+		best, bestArea := start, -1.0
+		for i := start; i < end; i++ {
+			area := math.Abs((x[selected]-avgX)*(y[i]-y[selected]) - (x[selected]-x[i])*(avgY-y[selected]))
+			if area > bestArea {
+				best, bestArea = i, area
+			}
+		}
+		indices = append(indices, best)
+		selected = best
+	}
+	return append(indices, n-1)
+}
+
+// RDP elige hasta points índices de la serie (x, y) con el algoritmo de Ramer–Douglas–Peucker:
+// parte de los extremos y divide repetidamente el segmento cuyo punto intermedio está más
+// lejos de la recta que une sus extremos, hasta alcanzar points puntos o hasta que ninguna
+// desviación supere epsilon (0 para no limitar por tolerancia). La distancia es vertical
+// (en unidades de y), porque el tiempo y la amplitud no tienen una escala común. Devuelve los
+// índices en orden creciente.
+func RDP(x, y []float64, points int, epsilon float64) []int {
+	n := len(x)
+	if points >= n || points < 3 {
+		return allIndices(n, points)
+	}
+
+	selected := []int{0, n - 1}
+	queue := &segmentQueue{}
+	if s, ok := farthestPoint(x, y, 0, n-1); ok {
+		heap.Push(queue, s)
+	}
+	for len(selected) < points && queue.Len() > 0 {
+		s := heap.Pop(queue).(segment)
+		if s.distance <= epsilon {
+			break
+		}
+		selected = append(selected, s.split)
+		for _, part := range [][2]int{{s.start, s.split}, {s.split, s.end}} {
+			if next, ok := farthestPoint(x, y, part[0], part[1]); ok {
+				heap.Push(queue, next)
+			}
+		}
+	}
+	sort.Ints(selected)
+	return selected
+}
+
+// segment es un tramo (start, end) de RDP con su punto más alejado de la cuerda
+type segment struct {
+	start, end, split int
+	distance          float64
+}
+
+// farthestPoint busca el punto interior del tramo más alejado de la recta entre sus extremos
+func farthestPoint(x, y []float64, start, end int) (segment, bool) {
+	if end-start < 2 {
+		return segment{}, false
+	}
+	s := segment{start: start, end: end, split: start + 1, distance: -1}
+	dx := x[end] - x[start]
+	for i := start + 1; i < end; i++ {
+		chord := y[start]
+		if dx != 0 {
+			chord += (y[end] - y[start]) * (x[i] - x[start]) / dx
+		}
+		if d := math.Abs(y[i] - chord); d > s.distance {
+			s.split, s.distance = i, d
+		}
+	}
+	return s, true
+}
+
+// segmentQueue ordena los tramos por distancia descendente
+type segmentQueue []segment
+
+func (q segmentQueue) Len() int            { return len(q) }
+func (q segmentQueue) Less(i, j int) bool  { return q[i].distance > q[j].distance }
+func (q segmentQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *segmentQueue) Push(v interface{}) { *q = append(*q, v.(segment)) }
+func (q *segmentQueue) Pop() interface{} {
+	old := *q
+	s := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return s
+}
+
+// allIndices devuelve todos los índices de una serie de n puntos, o solo los extremos si se
+// piden menos de tres puntos
+func allIndices(n, points int) []int {
+	if points < 3 && n > 2 {
+		return []int{0, n - 1}
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
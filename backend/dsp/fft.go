@@ -0,0 +1,62 @@
+// Package dsp contiene rutinas de procesamiento de señales muestreadas uniformemente:
+// transformada rápida de Fourier, ventanas y estimación espectral.
+package dsp
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// NextPowerOfTwo devuelve la menor potencia de dos mayor o igual que n
+func NextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// FFT calcula la transformada discreta de Fourier de x con el algoritmo radix-2 de
+// Cooley–Tukey. Si la longitud no es potencia de dos se rellena con ceros.
+func FFT(x []complex128) []complex128 {
+	n := NextPowerOfTwo(len(x))
+	out := make([]complex128, n)
+	copy(out, x)
+	if n == 1 {
+		return out
+	}
+
+	// Permutación por inversión de bits
+	shift := 64 - uint(bits.Len(uint(n-1)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if j > i {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a := out[start+k]
+				b := w * out[start+k+size/2]
+				out[start+k] = a + b
+				out[start+k+size/2] = a - b
+				w *= step
+			}
+		}
+	}
+	return out
+}
+
+// RealFFT calcula la FFT de una señal real rellenada con ceros hasta n (potencia de dos)
+// y devuelve los n/2+1 coeficientes de frecuencias no negativas
+func RealFFT(x []float64, n int) []complex128 {
+	buf := make([]complex128, n)
+	for i := 0; i < len(x) && i < n; i++ {
+		buf[i] = complex(x[i], 0)
+	}
+	return FFT(buf)[:n/2+1]
+}
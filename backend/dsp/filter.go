@@ -0,0 +1,350 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Límites de los filtros
+const (
+	MaxButterworthOrder = 8
+	madToSigma          = 1.4826 // Factor de la MAD a la desviación estándar de una distribución normal
+	meanAbsToSigma      = 1.2533 // Factor de la desviación absoluta media a la desviación estándar
+)
+
+// MovingAverage suaviza x con una media móvil centrada de window muestras; en los extremos
+// la ventana se acorta para no desplazar la señal
+func MovingAverage(x []float64, window int) []float64 {
+	out := make([]float64, len(x))
+	if len(x) == 0 || window < 2 {
+		copy(out, x)
+		return out
+	}
+
+	// Suma acumulada: cada promedio es una diferencia de dos sumas
+	prefix := make([]float64, len(x)+1)
+	for i, v := range x {
+		prefix[i+1] = prefix[i] + v
+	}
+	before, after := (window-1)/2, window/2
+	for i := range x {
+		lo, hi := max(i-before, 0), min(i+after, len(x)-1)
+		out[i] = (prefix[hi+1] - prefix[lo]) / float64(hi-lo+1)
+	}
+	return out
+}
+
+// biquad es una sección de segundo orden (a0 = 1) en forma directa II transpuesta
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+}
+
+// ButterworthLowPass filtra x con un pasa bajos de Butterworth del orden dado y frecuencia de
+// corte cutoff (Hz), aplicado hacia adelante y hacia atrás para no introducir desfase. El
+// filtrado doble eleva al cuadrado la respuesta en magnitud (atenuación de 6 dB en el corte).
+func ButterworthLowPass(x []float64, fs, cutoff float64, order int) ([]float64, error) {
+	if order < 1 || order > MaxButterworthOrder {
+		return nil, fmt.Errorf("el orden del filtro de Butterworth debe estar entre 1 y %d", MaxButterworthOrder)
+	}
+	if fs <= 0 || cutoff <= 0 || cutoff >= fs/2 {
+		return nil, fmt.Errorf("la frecuencia de corte debe estar entre 0 y la de Nyquist (%g Hz)", fs/2)
+	}
+
+	// Extensión impar en ambos extremos para reducir los transitorios de borde
+	pad := min(3*(order+1), len(x)-1)
+	if len(x) == 0 {
+		pad = 0
+	}
+	padded := make([]float64, 0, len(x)+2*pad)
+	for i := pad; i >= 1; i-- {
+		padded = append(padded, 2*x[0]-x[i])
+	}
+	padded = append(padded, x...)
+	for i := 1; i <= pad; i++ {
+		padded = append(padded, 2*x[len(x)-1]-x[len(x)-1-i])
+	}
+
+	sections := butterworthSections(fs, cutoff, order)
+	for pass := 0; pass < 2; pass++ {
+		for _, s := range sections {
+			s.filter(padded)
+		}
+		reverse(padded)
+	}
+	return padded[pad : pad+len(x)], nil
+}
+
+// butterworthSections diseña el filtro como cascada de secciones de segundo orden (y una de
+// primer orden si el orden es impar) mediante la transformación bilineal con predistorsión
+func butterworthSections(fs, cutoff float64, order int) []biquad {
+	k := math.Tan(math.Pi * cutoff / fs)
+	var sections []biquad
+	for i := 0; i < order/2; i++ {
+		// Polos analógicos normalizados en pares conjugados: Q = 1 / (2·sen θ)
+		theta := math.Pi * float64(2*i+1) / float64(2*order)
+		q := 1 / (2 * math.Sin(theta))
+		norm := 1 / (1 + k/q + k*k)
+		b0 := k * k * norm
+		sections = append(sections, biquad{
+			b0: b0, b1: 2 * b0, b2: b0,
+			a1: 2 * (k*k - 1) * norm,
+			a2: (1 - k/q + k*k) * norm,
+		})
+	}
+	if order%2 == 1 {
+		norm := 1 / (1 + k)
+		sections = append(sections, biquad{b0: k * norm, b1: k * norm, a1: (k - 1) * norm})
+	}
+	return sections
+}
+
+// filter aplica la sección sobre x en el lugar. El estado inicial es el de régimen para la
+// primera muestra, de modo que una señal que empieza en un valor distinto de cero no produce
+// un transitorio espurio.
+func (s biquad) filter(x []float64) {
+	if len(x) == 0 {
+		return
+	}
+	z2 := (s.b2 - s.a2) * x[0]
+	z1 := (s.b1-s.a1)*x[0] + z2
+	for i, v := range x {
+		y := s.b0*v + z1
+		z1 = s.b1*v - s.a1*y + z2
+		z2 = s.b2*v - s.a2*y
+		x[i] = y
+	}
+}
+
+// SavitzkyGolay suaviza x ajustando por mínimos cuadrados un polinomio del orden dado en
+// cada ventana de window muestras (impar). En los extremos se evalúa el polinomio ajustado a
+// la primera y a la última ventana.
+func SavitzkyGolay(x []float64, window, order int) ([]float64, error) {
+	if window < 3 || window%2 == 0 {
+		return nil, errors.New("la ventana de Savitzky-Golay debe ser impar y de al menos 3 muestras")
+	}
+	if order < 0 || order >= window {
+		return nil, errors.New("el orden del polinomio de Savitzky-Golay debe ser menor que la ventana")
+	}
+	if window > len(x) {
+		return nil, fmt.Errorf("la ventana de Savitzky-Golay (%d) es mayor que la señal (%d muestras)", window, len(x))
+	}
+
+	half := window / 2
+	out := make([]float64, len(x))
+	center := savitzkyGolayCoefficients(window, order, 0)
+	for i := half; i < len(x)-half; i++ {
+		out[i] = dot(center, x[i-half:i+half+1])
+	}
+	for offset := 1; offset <= half; offset++ {
+		head := savitzkyGolayCoefficients(window, order, -offset)
+		tail := savitzkyGolayCoefficients(window, order, offset)
+		out[half-offset] = dot(head, x[:window])
+		out[len(x)-1-half+offset] = dot(tail, x[len(x)-window:])
+	}
+	return out, nil
+}
+
+// savitzkyGolayCoefficients calcula los pesos que, aplicados a una ventana centrada en cero,
+// evalúan el polinomio ajustado en la posición at: c = A·(AᵀA)⁻¹·[1, at, at², ...]
+func savitzkyGolayCoefficients(window, order, at int) []float64 {
+	half := window / 2
+	n := order + 1
+
+	// Ecuaciones normales AᵀA con A[i][j] = (i-half)^j
+	ata := make([][]float64, n)
+	for r := range ata {
+		ata[r] = make([]float64, n)
+		for i := -half; i <= half; i++ {
+			for c := 0; c < n; c++ {
+				ata[r][c] += math.Pow(float64(i), float64(r+c))
+			}
+		}
+	}
+	rhs := make([]float64, n)
+	for j := range rhs {
+		rhs[j] = math.Pow(float64(at), float64(j))
+	}
+	w := solveLinear(ata, rhs)
+
+	coefficients := make([]float64, window)
+	for i := -half; i <= half; i++ {
+		for j := 0; j < n; j++ {
+			coefficients[i+half] += w[j] * math.Pow(float64(i), float64(j))
+		}
+	}
+	return coefficients
+}
+
+// solveLinear resuelve a·x = b por eliminación gaussiana con pivoteo parcial (a es pequeña y
+// no singular)
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		sum := b[r]
+		for c := r + 1; c < n; c++ {
+			sum -= a[r][c] * x[c]
+		}
+		x[r] = sum / a[r][r]
+	}
+	return x
+}
+
+// RemoveOutliers reemplaza por la mediana local las muestras que se alejan de ella más de
+// threshold desviaciones estándar robustas (MAD escalada) en una ventana centrada de window
+// muestras (filtro de Hampel). Devuelve la señal corregida y la cantidad de reemplazos.
+func RemoveOutliers(x []float64, window int, threshold float64) ([]float64, int) {
+	out := make([]float64, len(x))
+	copy(out, x)
+	half := window / 2
+	buffer := make([]float64, 0, window)
+	replaced := 0
+	for i := range x {
+		lo, hi := max(i-half, 0), min(i+half, len(x)-1)
+		buffer = append(buffer[:0], x[lo:hi+1]...)
+		median := medianInPlace(buffer)
+		for k := range buffer {
+			buffer[k] = math.Abs(buffer[k] - median)
+		}
+		// Si más de la mitad de la ventana es constante la MAD es cero y se usa la desviación media
+		sigma := madToSigma * medianInPlace(buffer)
+		if sigma == 0 {
+			sigma = meanAbsToSigma * mean(buffer)
+		}
+		if sigma > 0 && math.Abs(x[i]-median) > threshold*sigma {
+			out[i] = median
+			replaced++
+		}
+	}
+	return out, replaced
+}
+
+// medianInPlace calcula la mediana ordenando los datos recibidos
+func medianInPlace(data []float64) float64 {
+	sort.Float64s(data)
+	n := len(data)
+	if n%2 == 1 {
+		return data[n/2]
+	}
+	return (data[n/2-1] + data[n/2]) / 2
+}
+
+// Métodos de eliminación de tendencia
+const (
+	DetrendBaseline = "baseline" // Resta el nivel inicial (media de las primeras muestras)
+	DetrendMean     = "mean"     // Resta la media de toda la señal
+	DetrendLinear   = "linear"   // Resta la recta de mínimos cuadrados
+)
+
+// Detrend elimina de x el nivel o la tendencia indicados por el método. La línea de base es la
+// media de las muestras con tiempo menor o igual que t[0]+baseline (las primeras 5% si
+// baseline es cero).
+func Detrend(t, x []float64, method string, baseline float64) ([]float64, error) {
+	out := make([]float64, len(x))
+	if len(x) == 0 {
+		return out, nil
+	}
+	switch method {
+	case "", DetrendBaseline:
+		n := max(len(x)/20, 1)
+		if baseline > 0 {
+			n = 1
+			for n < len(t) && t[n] <= t[0]+baseline {
+				n++
+			}
+		}
+		level := mean(x[:n])
+		for i, v := range x {
+			out[i] = v - level
+		}
+	case DetrendMean:
+		level := mean(x)
+		for i, v := range x {
+			out[i] = v - level
+		}
+	case DetrendLinear:
+		tm, xm := mean(t), mean(x)
+		var sxy, sxx float64
+		for i := range x {
+			sxy += (t[i] - tm) * (x[i] - xm)
+			sxx += (t[i] - tm) * (t[i] - tm)
+		}
+		slope := 0.0
+		if sxx > 0 {
+			slope = sxy / sxx
+		}
+		for i, v := range x {
+			out[i] = v - xm - slope*(t[i]-tm)
+		}
+	default:
+		return nil, fmt.Errorf("método de eliminación de tendencia desconocido: %s", method)
+	}
+	return out, nil
+}
+
+// Resample interpola linealmente las series de valores sobre una malla uniforme de período
+// period que cubre el mismo intervalo de tiempo
+func Resample(t []float64, period float64, series ...[]float64) ([]float64, [][]float64) {
+	if len(t) == 0 || period <= 0 {
+		return t, series
+	}
+	n := int(math.Floor((t[len(t)-1]-t[0])/period+1e-9)) + 1
+	grid := make([]float64, n)
+	out := make([][]float64, len(series))
+	for s := range out {
+		out[s] = make([]float64, n)
+	}
+
+	j := 0
+	for i := range grid {
+		ti := t[0] + float64(i)*period
+		grid[i] = ti
+		for j < len(t)-2 && t[j+1] < ti {
+			j++
+		}
+		for s, x := range series {
+			if len(t) == 1 || t[j+1] == t[j] {
+				out[s][i] = x[j]
+				continue
+			}
+			f := math.Max(0, math.Min(1, (ti-t[j])/(t[j+1]-t[j])))
+			out[s][i] = x[j] + f*(x[j+1]-x[j])
+		}
+	}
+	return grid, out
+}
+
+// dot calcula el producto escalar de dos vectores de igual longitud
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// reverse invierte x en el lugar
+func reverse(x []float64) {
+	for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
+		x[i], x[j] = x[j], x[i]
+	}
+}
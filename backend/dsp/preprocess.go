@@ -0,0 +1,300 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Pasos de preprocesamiento disponibles
+const (
+	StepMovingAverage  = "moving_average"
+	StepButterworth    = "butterworth"
+	StepSavitzkyGolay  = "savitzky_golay"
+	StepMedianOutliers = "median_outliers"
+	StepDetrend        = "detrend"
+	StepResample       = "resample"
+	StepCrop           = "crop"
+)
+
+// Valores por defecto y límites del preprocesamiento
+const (
+	MaxPreprocessSteps      = 20
+	DefaultFilterWindow     = 5
+	DefaultButterworthOrder = 2
+	DefaultSavitzkyOrder    = 2
+	DefaultOutlierWindow    = 7
+	DefaultOutlierThreshold = 3.0
+	MaxFilterWindow         = 1001 // Ventana máxima de moving_average, savitzky_golay y median_outliers
+	MaxSavitzkyOrder        = 10
+	maxResampledPoints      = 2000000
+	minPreprocessedPoints   = 2
+)
+
+// PreprocessStep es un paso del preprocesamiento. Cada tipo usa solo algunos campos:
+//   - moving_average: window (muestras, hasta 1001, por defecto 5)
+//   - butterworth: cutoff (Hz, obligatorio) y order (1 a 8, por defecto 2)
+//   - savitzky_golay: window (impar, hasta 1001, por defecto 5) y order (grado del polinomio,
+//     hasta 10, por defecto 2)
+//   - median_outliers: window (hasta 1001, por defecto 7) y threshold (desviaciones robustas,
+//     por defecto 3)
+//   - detrend: method (baseline por defecto, mean o linear) y baseline (s de línea de base)
+//   - resample: period (s, obligatorio)
+//   - crop: start y end (s, al menos uno)
+type PreprocessStep struct {
+	Type      string   `json:"type"`
+	Window    int      `json:"window,omitempty"`
+	Cutoff    float64  `json:"cutoff,omitempty"`
+	Order     int      `json:"order,omitempty"`
+	Threshold float64  `json:"threshold,omitempty"`
+	Method    string   `json:"method,omitempty"`
+	Baseline  float64  `json:"baseline,omitempty"`
+	Period    float64  `json:"period,omitempty"`
+	Start     *float64 `json:"start,omitempty"`
+	End       *float64 `json:"end,omitempty"`
+}
+
+// PreprocessOptions es la especificación declarativa del preprocesamiento de un análisis.
+// Los pasos se aplican en orden sobre la señal leída; los filtros y la corrección de valores
+// atípicos afectan a la salida y a la entrada medida, la eliminación de tendencia solo a la
+// salida, y el recorte y el remuestreo a todas las series.
+type PreprocessOptions struct {
+	Steps    []PreprocessStep `json:"steps,omitempty"`
+	AutoTrim *bool            `json:"auto_trim,omitempty"` // Recorte automático del transitorio en step_response (por defecto true)
+}
+
+// AutoTrimEnabled indica si se mantiene el recorte automático del transitorio
+func (o *PreprocessOptions) AutoTrimEnabled() bool {
+	return o == nil || o.AutoTrim == nil || *o.AutoTrim
+}
+
+// Validate comprueba que los pasos estén completos y sean coherentes
+func (o PreprocessOptions) Validate() error {
+	if len(o.Steps) > MaxPreprocessSteps {
+		return fmt.Errorf("preprocessing admite como máximo %d pasos", MaxPreprocessSteps)
+	}
+	for i, s := range o.Steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("preprocessing.steps[%d] (%s): %w", i, s.Type, err)
+		}
+	}
+	return nil
+}
+
+// validate comprueba los parámetros de un paso
+func (s PreprocessStep) validate() error {
+	switch s.Type {
+	case StepMovingAverage, StepSavitzkyGolay, StepMedianOutliers:
+		if s.Window > MaxFilterWindow {
+			return fmt.Errorf("window no puede superar las %d muestras", MaxFilterWindow)
+		}
+	}
+	switch s.Type {
+	case StepMovingAverage:
+		if s.Window < 0 || s.Window == 1 {
+			return errors.New("window debe ser de al menos 2 muestras")
+		}
+	case StepButterworth:
+		if s.Cutoff <= 0 {
+			return errors.New("cutoff debe ser una frecuencia positiva en Hz")
+		}
+		if s.Order < 0 || s.Order > MaxButterworthOrder {
+			return fmt.Errorf("order debe estar entre 1 y %d", MaxButterworthOrder)
+		}
+	case StepSavitzkyGolay:
+		d := s.withDefaults()
+		if d.Window < 3 || d.Window%2 == 0 {
+			return errors.New("window debe ser impar y de al menos 3 muestras")
+		}
+		if d.Order < 0 || d.Order >= d.Window {
+			return errors.New("order debe ser menor que window")
+		}
+		if d.Order > MaxSavitzkyOrder {
+			return fmt.Errorf("order no puede superar %d", MaxSavitzkyOrder)
+		}
+	case StepMedianOutliers:
+		if s.Window < 0 || s.Window == 1 || s.Window == 2 {
+			return errors.New("window debe ser de al menos 3 muestras")
+		}
+		if s.Threshold < 0 {
+			return errors.New("threshold no puede ser negativo")
+		}
+	case StepDetrend:
+		switch s.Method {
+		case "", DetrendBaseline, DetrendMean, DetrendLinear:
+		default:
+			return errors.New("method debe ser baseline, mean o linear")
+		}
+		if s.Baseline < 0 {
+			return errors.New("baseline no puede ser negativo")
+		}
+	case StepResample:
+		if s.Period <= 0 {
+			return errors.New("period debe ser un período positivo en segundos")
+		}
+	case StepCrop:
+		if s.Start == nil && s.End == nil {
+			return errors.New("debe indicarse start, end o ambos")
+		}
+		if s.Start != nil && s.End != nil && *s.End <= *s.Start {
+			return errors.New("end debe ser mayor que start")
+		}
+	default:
+		return errors.New("type debe ser moving_average, butterworth, savitzky_golay, median_outliers, detrend, resample o crop")
+	}
+	return nil
+}
+
+// withDefaults completa los parámetros omitidos con sus valores por defecto
+func (s PreprocessStep) withDefaults() PreprocessStep {
+	switch s.Type {
+	case StepMovingAverage:
+		if s.Window == 0 {
+			s.Window = DefaultFilterWindow
+		}
+	case StepButterworth:
+		if s.Order == 0 {
+			s.Order = DefaultButterworthOrder
+		}
+	case StepSavitzkyGolay:
+		if s.Window == 0 {
+			s.Window = DefaultFilterWindow
+		}
+		if s.Order == 0 {
+			s.Order = DefaultSavitzkyOrder
+		}
+	case StepMedianOutliers:
+		if s.Window == 0 {
+			s.Window = DefaultOutlierWindow
+		}
+		if s.Threshold == 0 {
+			s.Threshold = DefaultOutlierThreshold
+		}
+	case StepDetrend:
+		if s.Method == "" {
+			s.Method = DetrendBaseline
+		}
+	}
+	return s
+}
+
+// AppliedStep registra un paso aplicado con sus parámetros efectivos y su efecto
+type AppliedStep struct {
+	PreprocessStep
+	Samples  int `json:"samples"`            // Muestras después del paso
+	Replaced int `json:"replaced,omitempty"` // Valores atípicos reemplazados (median_outliers)
+}
+
+// PreprocessedSignal es la señal resultante del preprocesamiento
+type PreprocessedSignal struct {
+	Time           []float64
+	Output         []float64
+	Input          []float64 // Vacía si la señal no tiene entrada medida
+	SamplingPeriod float64
+	Applied        []AppliedStep
+}
+
+// Preprocess aplica los pasos de opts en orden a la señal (tiempo, salida y entrada medida
+// opcional) muestreada con el período dado. Los filtros suponen un muestreo uniforme.
+func Preprocess(t, output, input []float64, samplingPeriod float64, opts PreprocessOptions) (*PreprocessedSignal, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	signal := &PreprocessedSignal{Time: t, Output: output, Input: input, SamplingPeriod: samplingPeriod}
+
+	for i, step := range opts.Steps {
+		step = step.withDefaults()
+		applied := AppliedStep{PreprocessStep: step}
+		if err := signal.apply(&applied); err != nil {
+			return nil, fmt.Errorf("preprocessing.steps[%d] (%s): %w", i, step.Type, err)
+		}
+		if len(signal.Time) < minPreprocessedPoints {
+			return nil, fmt.Errorf("preprocessing.steps[%d] (%s): la señal quedó con %d muestras", i, step.Type, len(signal.Time))
+		}
+		applied.Samples = len(signal.Time)
+		signal.Applied = append(signal.Applied, applied)
+	}
+	return signal, nil
+}
+
+// apply aplica un paso (con sus valores por defecto ya completados) a la señal
+func (p *PreprocessedSignal) apply(applied *AppliedStep) error {
+	step := applied.PreprocessStep
+	switch step.Type {
+	case StepMovingAverage, StepSavitzkyGolay, StepMedianOutliers:
+		if step.Window > len(p.Time) {
+			return fmt.Errorf("window (%d) es mayor que la señal (%d muestras)", step.Window, len(p.Time))
+		}
+	}
+	switch step.Type {
+	case StepMovingAverage:
+		return p.filter(func(x []float64) ([]float64, error) {
+			return MovingAverage(x, step.Window), nil
+		})
+	case StepButterworth:
+		if p.SamplingPeriod <= 0 {
+			return errors.New("se necesita el período de muestreo")
+		}
+		return p.filter(func(x []float64) ([]float64, error) {
+			return ButterworthLowPass(x, 1/p.SamplingPeriod, step.Cutoff, step.Order)
+		})
+	case StepSavitzkyGolay:
+		return p.filter(func(x []float64) ([]float64, error) {
+			return SavitzkyGolay(x, step.Window, step.Order)
+		})
+	case StepMedianOutliers:
+		return p.filter(func(x []float64) ([]float64, error) {
+			out, replaced := RemoveOutliers(x, step.Window, step.Threshold)
+			applied.Replaced += replaced
+			return out, nil
+		})
+	case StepDetrend:
+		out, err := Detrend(p.Time, p.Output, step.Method, step.Baseline)
+		if err != nil {
+			return err
+		}
+		p.Output = out
+	case StepResample:
+		if n := (p.Time[len(p.Time)-1]-p.Time[0])/step.Period + 1; n > maxResampledPoints {
+			return fmt.Errorf("el remuestreo generaría %.0f muestras (máximo %d)", n, maxResampledPoints)
+		}
+		series := [][]float64{p.Output}
+		if len(p.Input) > 0 {
+			series = append(series, p.Input)
+		}
+		var resampled [][]float64
+		p.Time, resampled = Resample(p.Time, step.Period, series...)
+		p.Output = resampled[0]
+		if len(p.Input) > 0 {
+			p.Input = resampled[1]
+		}
+		p.SamplingPeriod = step.Period
+	case StepCrop:
+		lo, hi := 0, len(p.Time)
+		for lo < hi && step.Start != nil && p.Time[lo] < *step.Start {
+			lo++
+		}
+		for hi > lo && step.End != nil && p.Time[hi-1] > *step.End {
+			hi--
+		}
+		p.Time, p.Output = p.Time[lo:hi], p.Output[lo:hi]
+		if len(p.Input) > 0 {
+			p.Input = p.Input[lo:hi]
+		}
+	}
+	return nil
+}
+
+// filter aplica un filtro a la salida y, si existe, a la entrada medida
+func (p *PreprocessedSignal) filter(fn func([]float64) ([]float64, error)) error {
+	out, err := fn(p.Output)
+	if err != nil {
+		return err
+	}
+	p.Output = out
+	if len(p.Input) > 0 {
+		if p.Input, err = fn(p.Input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
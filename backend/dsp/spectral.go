@@ -0,0 +1,318 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// Valores por defecto del estimador de Welch
+const (
+	DefaultOverlap       = 0.5
+	maxSegmentLength     = 4096
+	minSegmentLength     = 16
+	snrHarmonics         = 5
+	defaultSpectrumLimit = 1024
+)
+
+// SpectralOptions configura el análisis espectral
+type SpectralOptions struct {
+	Window        string  `json:"window,omitempty"`         // hann (por defecto), hamming o rectangular
+	SegmentLength int     `json:"segment_length,omitempty"` // Muestras por segmento de Welch (potencia de dos)
+	Overlap       float64 `json:"overlap,omitempty"`        // Solapamiento entre segmentos, en [0, 1)
+}
+
+// Validate comprueba que las opciones sean coherentes
+func (o SpectralOptions) Validate() error {
+	if !ValidWindow(o.Window) {
+		return errors.New("window debe ser hann, hamming o rectangular")
+	}
+	if o.SegmentLength != 0 && (o.SegmentLength < minSegmentLength || o.SegmentLength&(o.SegmentLength-1) != 0) {
+		return errors.New("segment_length debe ser una potencia de dos mayor o igual que 16")
+	}
+	if o.Overlap < 0 || o.Overlap >= 1 {
+		return errors.New("overlap debe estar en [0, 1)")
+	}
+	return nil
+}
+
+// Spectrum es una serie de valores espectrales por frecuencia (Hz)
+type Spectrum struct {
+	Frequencies []float64 `json:"frequencies"`
+	Values      []float64 `json:"values"`
+}
+
+// SpectralAnalysis es el resultado del análisis espectral de una señal
+type SpectralAnalysis struct {
+	SampleRate          float64  `json:"sample_rate"` // Hz
+	Samples             int      `json:"samples"`
+	Window              string   `json:"window"`
+	FFTSize             int      `json:"fft_size"`
+	FrequencyResolution float64  `json:"frequency_resolution"` // Resolución de la PSD de Welch (Hz)
+	SegmentLength       int      `json:"segment_length"`
+	Segments            int      `json:"segments"`
+	Amplitude           Spectrum `json:"amplitude"` // Espectro de amplitud de un lado (reducido para graficar)
+	PSD                 Spectrum `json:"psd"`       // Densidad espectral de potencia de Welch (unidades²/Hz)
+	DominantFrequency   float64  `json:"dominant_frequency"`
+	DominantAmplitude   float64  `json:"dominant_amplitude"`
+	TotalPower          float64  `json:"total_power"` // Potencia de la señal sin componente continua
+	SignalPower         float64  `json:"signal_power"`
+	NoisePower          float64  `json:"noise_power"`
+	SNRDB               *float64 `json:"snr_db,omitempty"`
+}
+
+// Analyze calcula el espectro de amplitud con ventana, la PSD de Welch, la frecuencia
+// dominante y la relación señal/ruido de una señal muestreada con el período dado.
+// La SNR considera señal la potencia en el lóbulo principal del pico dominante y sus
+// armónicos, y ruido el resto (excluida la componente continua).
+func Analyze(x []float64, samplingPeriod float64, opts SpectralOptions) (*SpectralAnalysis, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if samplingPeriod <= 0 {
+		return nil, errors.New("el período de muestreo debe ser positivo")
+	}
+	if len(x) < minSegmentLength {
+		return nil, errors.New("la señal es demasiado corta para el análisis espectral")
+	}
+	if opts.Window == "" {
+		opts.Window = WindowHann
+	}
+	if opts.Overlap == 0 {
+		opts.Overlap = DefaultOverlap
+	}
+
+	fs := 1 / samplingPeriod
+	result := &SpectralAnalysis{
+		SampleRate: fs,
+		Samples:    len(x),
+		Window:     opts.Window,
+	}
+
+	amplitude, fftSize, err := amplitudeSpectrum(x, fs, opts.Window)
+	if err != nil {
+		return nil, err
+	}
+	result.FFTSize = fftSize
+	result.Amplitude = reduceSpectrum(amplitude, defaultSpectrumLimit)
+
+	segment := opts.SegmentLength
+	if segment == 0 || segment > len(x) {
+		segment = defaultSegmentLength(len(x))
+	}
+	psd, segments, err := Welch(x, fs, opts.Window, segment, opts.Overlap)
+	if err != nil {
+		return nil, err
+	}
+	result.PSD = psd
+	result.SegmentLength = segment
+	result.Segments = segments
+	result.FrequencyResolution = fs / float64(segment)
+
+	// Frecuencia dominante: máximo de la PSD fuera de la componente continua
+	peak := 1
+	for k := 2; k < len(psd.Values); k++ {
+		if psd.Values[k] > psd.Values[peak] {
+			peak = k
+		}
+	}
+	result.DominantFrequency = interpolatePeak(psd, peak)
+	result.DominantAmplitude = peakAmplitude(amplitude, result.DominantFrequency)
+
+	// Potencias por integración de la PSD
+	df := result.FrequencyResolution
+	lobe := mainLobeBins(opts.Window)
+	signalBins := make(map[int]bool)
+	for h := 1; h <= snrHarmonics; h++ {
+		center := int(math.Round(float64(peak) * float64(h)))
+		if center >= len(psd.Values) {
+			break
+		}
+		for k := center - lobe; k <= center+lobe; k++ {
+			if k > lobe && k < len(psd.Values) {
+				signalBins[k] = true
+			}
+		}
+	}
+	for k := lobe + 1; k < len(psd.Values); k++ {
+		p := psd.Values[k] * df
+		result.TotalPower += p
+		if signalBins[k] {
+			result.SignalPower += p
+		} else {
+			result.NoisePower += p
+		}
+	}
+	if result.NoisePower > 0 && result.SignalPower > 0 {
+		snr := 10 * math.Log10(result.SignalPower/result.NoisePower)
+		result.SNRDB = &snr
+	}
+
+	return result, nil
+}
+
+// Welch estima la densidad espectral de potencia de un lado promediando periodogramas de
+// segmentos solapados, con la componente continua de cada segmento eliminada
+func Welch(x []float64, fs float64, window string, segment int, overlap float64) (Spectrum, int, error) {
+	if segment > len(x) || segment < 2 {
+		return Spectrum{}, 0, errors.New("longitud de segmento inválida")
+	}
+	w, err := Window(window, segment)
+	if err != nil {
+		return Spectrum{}, 0, err
+	}
+	hop := int(float64(segment) * (1 - overlap))
+	if hop < 1 {
+		hop = 1
+	}
+
+	nfft := NextPowerOfTwo(segment)
+	bins := nfft/2 + 1
+	psd := make([]float64, bins)
+	scale := 1 / (fs * powerGain(w) * float64(segment))
+
+	segments := 0
+	buf := make([]float64, segment)
+	for start := 0; start+segment <= len(x); start += hop {
+		m := mean(x[start : start+segment])
+		for i := range buf {
+			buf[i] = (x[start+i] - m) * w[i]
+		}
+		spectrum := RealFFT(buf, nfft)
+		for k, c := range spectrum {
+			p := real(c)*real(c) + imag(c)*imag(c)
+			if k != 0 && !(nfft%2 == 0 && k == nfft/2) {
+				p *= 2 // Espectro de un lado
+			}
+			psd[k] += p * scale
+		}
+		segments++
+	}
+	for k := range psd {
+		psd[k] /= float64(segments)
+	}
+
+	freqs := make([]float64, bins)
+	for k := range freqs {
+		freqs[k] = float64(k) * fs / float64(nfft)
+	}
+	return Spectrum{Frequencies: freqs, Values: psd}, segments, nil
+}
+
+// amplitudeSpectrum calcula el espectro de amplitud de un lado de toda la señal con ventana,
+// corregido por la ganancia coherente para que una senoidal muestre su amplitud
+func amplitudeSpectrum(x []float64, fs float64, window string) (Spectrum, int, error) {
+	w, err := Window(window, len(x))
+	if err != nil {
+		return Spectrum{}, 0, err
+	}
+	m := mean(x)
+	buf := make([]float64, len(x))
+	for i := range x {
+		buf[i] = (x[i] - m) * w[i]
+	}
+
+	nfft := NextPowerOfTwo(len(x))
+	coeffs := RealFFT(buf, nfft)
+	norm := 2 / (float64(len(x)) * coherentGain(w))
+
+	spectrum := Spectrum{
+		Frequencies: make([]float64, len(coeffs)),
+		Values:      make([]float64, len(coeffs)),
+	}
+	for k, c := range coeffs {
+		spectrum.Frequencies[k] = float64(k) * fs / float64(nfft)
+		spectrum.Values[k] = cmplx.Abs(c) * norm
+	}
+	spectrum.Values[0] /= 2
+	return spectrum, nfft, nil
+}
+
+// reduceSpectrum limita el número de puntos conservando el máximo de cada grupo de bins
+func reduceSpectrum(s Spectrum, maxPoints int) Spectrum {
+	n := len(s.Values)
+	if n <= maxPoints {
+		return s
+	}
+	out := Spectrum{
+		Frequencies: make([]float64, 0, maxPoints),
+		Values:      make([]float64, 0, maxPoints),
+	}
+	for b := 0; b < maxPoints; b++ {
+		start := b * n / maxPoints
+		end := (b + 1) * n / maxPoints
+		best := start
+		for k := start; k < end; k++ {
+			if s.Values[k] > s.Values[best] {
+				best = k
+			}
+		}
+		out.Frequencies = append(out.Frequencies, s.Frequencies[best])
+		out.Values = append(out.Values, s.Values[best])
+	}
+	return out
+}
+
+// defaultSegmentLength elige la mayor potencia de dos que permite al menos 8 segmentos
+// con solapamiento del 50%, acotada entre 16 y 4096 muestras
+func defaultSegmentLength(n int) int {
+	segment := maxSegmentLength
+	for segment > minSegmentLength && segment*9/2 > n {
+		segment /= 2
+	}
+	if segment > n {
+		segment = NextPowerOfTwo(n) / 2
+	}
+	return segment
+}
+
+// interpolatePeak refina la frecuencia de un pico con una parábola sobre tres bins
+func interpolatePeak(s Spectrum, k int) float64 {
+	if k <= 0 || k >= len(s.Values)-1 {
+		return s.Frequencies[k]
+	}
+	a, b, c := s.Values[k-1], s.Values[k], s.Values[k+1]
+	denom := a - 2*b + c
+	if denom == 0 {
+		return s.Frequencies[k]
+	}
+	offset := 0.5 * (a - c) / denom
+	df := s.Frequencies[1] - s.Frequencies[0]
+	return s.Frequencies[k] + offset*df
+}
+
+// peakAmplitude devuelve la amplitud máxima del espectro en torno a la frecuencia dada
+func peakAmplitude(s Spectrum, f float64) float64 {
+	if len(s.Frequencies) < 2 {
+		return 0
+	}
+	df := s.Frequencies[1] - s.Frequencies[0]
+	center := int(math.Round(f / df))
+	best := 0.0
+	for k := center - 2; k <= center+2; k++ {
+		if k >= 0 && k < len(s.Values) {
+			best = math.Max(best, s.Values[k])
+		}
+	}
+	return best
+}
+
+// mainLobeBins es la semianchura del lóbulo principal de la ventana en bins
+func mainLobeBins(window string) int {
+	if window == WindowRectangular {
+		return 1
+	}
+	return 2
+}
+
+// mean calcula el promedio de un slice
+func mean(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range data {
+		sum += v
+	}
+	return sum / float64(len(data))
+}
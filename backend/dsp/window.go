@@ -0,0 +1,63 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Ventanas disponibles para el análisis espectral
+const (
+	WindowHann        = "hann"
+	WindowHamming     = "hamming"
+	WindowRectangular = "rectangular"
+)
+
+// ValidWindow indica si el nombre de ventana es válido (vacío equivale a Hann)
+func ValidWindow(name string) bool {
+	switch name {
+	case "", WindowHann, WindowHamming, WindowRectangular:
+		return true
+	}
+	return false
+}
+
+// Window genera los n coeficientes de la ventana indicada (forma periódica, adecuada para
+// análisis espectral)
+func Window(name string, n int) ([]float64, error) {
+	w := make([]float64, n)
+	switch name {
+	case "", WindowHann:
+		for i := range w {
+			w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		}
+	case WindowHamming:
+		for i := range w {
+			w[i] = 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(n))
+		}
+	case WindowRectangular:
+		for i := range w {
+			w[i] = 1
+		}
+	default:
+		return nil, fmt.Errorf("ventana desconocida: %s", name)
+	}
+	return w, nil
+}
+
+// coherentGain es la media de los coeficientes de la ventana (corrige amplitudes)
+func coherentGain(w []float64) float64 {
+	sum := 0.0
+	for _, v := range w {
+		sum += v
+	}
+	return sum / float64(len(w))
+}
+
+// powerGain es la media de los cuadrados de los coeficientes (corrige potencias)
+func powerGain(w []float64) float64 {
+	sum := 0.0
+	for _, v := range w {
+		sum += v * v
+	}
+	return sum / float64(len(w))
+}
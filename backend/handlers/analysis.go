@@ -1,10 +1,9 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math"
 	"net/http"
@@ -13,12 +12,15 @@ import (
 	"strings"
 	"time"
 
+	"backend/control"
 	"backend/database"
+	"backend/jobs"
 	"backend/middleware"
 	"backend/models"
 	"backend/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 func CreateAnalysisRequestHandler() gin.HandlerFunc {
@@ -30,6 +32,63 @@ func CreateAnalysisRequestHandler() gin.HandlerFunc {
 			return
 		}
 
+		// Validar el modo de análisis y sus opciones
+		if !models.IsValidAnalysisMode(req.Mode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode debe ser step_response o spectral"})
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = models.AnalysisModeStepResponse
+		}
+		var options models.AnalysisOptions
+		if req.Mode == models.AnalysisModeSpectral && req.Spectral != nil {
+			if err := req.Spectral.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			options.Spectral = req.Spectral
+		}
+		if req.Mode == models.AnalysisModeStepResponse && req.Excitation != nil {
+			if err := req.Excitation.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if (req.Excitation.Type == models.ExcitationImpulse || req.Excitation.Type == models.ExcitationRamp) && req.InputVoltage == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "input_voltage debe ser el área del impulso o la pendiente de la rampa y no puede ser cero"})
+				return
+			}
+			options.Excitation = req.Excitation
+		}
+		if req.Parsing != nil {
+			if err := req.Parsing.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			options.Parsing = req.Parsing
+		}
+		if req.Preprocessing != nil {
+			if err := req.Preprocessing.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			options.Preprocessing = req.Preprocessing
+		}
+		if req.Metrics != nil {
+			if err := req.Metrics.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			options.Metrics = req.Metrics
+		}
+		if req.Discrete != nil {
+			if err := req.Discrete.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			options.Discrete = req.Discrete
+		}
+		optionsJSON, _ := json.Marshal(options)
+
 		// Verificar que el documento existe y no está eliminado
 		var document models.Document
 		result := database.DB.Where("id = ? AND is_deleted = ?", req.DocumentID, false).First(&document)
@@ -82,17 +141,28 @@ func CreateAnalysisRequestHandler() gin.HandlerFunc {
 			DocumentID:   req.DocumentID,
 			InputVoltage: req.InputVoltage,
 			Comment:      analysisComment, // Solo se guarda si está autenticado
+			Mode:         req.Mode,
+			Options:      datatypes.JSON(optionsJSON),
 			IsProcessed:  false,
+			Status:       models.AnalysisStatusQueued,
 			CreatedAt:    time.Now(),
 		}
 
-		if err := database.DB.Create(&analysis).Error; err != nil {
+		// Crear la solicitud y su trabajo en la cola dentro de la misma transacción,
+		// así ninguna solicitud queda sin procesar si el servidor se reinicia
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&analysis).Error; err != nil {
+				return err
+			}
+			return jobs.Enqueue(tx, analysis.ID)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear solicitud de análisis: " + err.Error()})
 			return
 		}
 
-		// Procesar análisis en background
-		go processAnalysisRequest(analysis.ID, req.DocumentID, req.InputVoltage)
+		// Avisar a los workers para que procesen el análisis en background
+		jobs.Notify()
 
 		// Preparar respuesta
 		response := struct {
@@ -100,12 +170,16 @@ func CreateAnalysisRequestHandler() gin.HandlerFunc {
 			DocumentID   uint    `json:"document_id"`
 			InputVoltage float64 `json:"input_voltage"`
 			Comment      string  `json:"comment,omitempty"`
+			Mode         string  `json:"mode"`
+			Status       string  `json:"status"`
 			Message      string  `json:"message"`
 		}{
 			ID:           analysis.ID,
 			DocumentID:   analysis.DocumentID,
 			InputVoltage: analysis.InputVoltage,
 			Comment:      analysis.Comment,
+			Mode:         analysis.Mode,
+			Status:       analysis.Status,
 			Message:      "Solicitud de análisis creada. El procesamiento comenzará en breve.",
 		}
 
@@ -116,49 +190,12 @@ func CreateAnalysisRequestHandler() gin.HandlerFunc {
 
 func GetAnalysisResultHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Obtener ID del análisis de la URL
-		analysisIDStr := c.Param("id")
-		analysisID, err := strconv.ParseUint(analysisIDStr, 10, 32)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de análisis inválido"})
-			return
-		}
-
-		// Buscar el análisis
-		var analysis models.AnalysisRequest
-		if err := database.DB.First(&analysis, analysisID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Análisis no encontrado"})
-			return
-		}
-
-		// Buscar el documento asociado
-		var document models.Document
-		if err := database.DB.First(&document, analysis.DocumentID).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener información del documento"})
-			return
-		}
-
-		// Verificar si el documento está eliminado
-		if document.IsDeleted {
-			c.JSON(http.StatusNotFound, gin.H{"error": "El documento asociado ha sido eliminado"})
+		// Obtener el análisis verificando los permisos del usuario
+		analysis, document, ok := loadAuthorizedAnalysis(c)
+		if !ok {
 			return
 		}
 
-		// Verificar si el usuario tiene permisos para ver este análisis
-		if document.UserID != nil {
-			// Si hay un usuario autenticado, verificar que sea el propietario
-			if currentUserID, ok := middleware.GetUserIDFromGin(c); ok {
-				if *document.UserID != currentUserID {
-					c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para ver este análisis"})
-					return
-				}
-			} else {
-				// Si el documento pertenece a un usuario pero no hay usuario autenticado
-				c.JSON(http.StatusForbidden, gin.H{"error": "Este análisis solo puede ser visto por su propietario"})
-				return
-			}
-		}
-
 		// Contar el número de análisis para este documento
 		var analysisCount int64
 		database.DB.Model(&models.AnalysisRequest{}).Where("document_id = ?", document.ID).Count(&analysisCount)
@@ -168,19 +205,23 @@ func GetAnalysisResultHandler() gin.HandlerFunc {
 
 		// Buscar el resultado más reciente para este análisis
 		var result models.Result
-		err = database.DB.Where("analysis_request_id = ?", analysisID).Where("is_latest = ?", true).First(&result).Error
+		err := database.DB.Preload("DiscreteModel").Where("analysis_request_id = ?", analysis.ID).Where("is_latest = ?", true).First(&result).Error
 
 		// Verificar si el resultado está disponible
 		if err != nil {
-			// El análisis está en proceso, enviar estado pendiente
+			// Sin resultado: el análisis está en cola, en ejecución, fallido o cancelado
 			response := struct {
 				AnalysisRequest models.AnalysisRequest  `json:"analysis_request"`
 				Document        models.DocumentResponse `json:"document"`
 				Status          string                  `json:"status"`
+				ErrorCode       string                  `json:"error_code,omitempty"`
+				ErrorMessage    string                  `json:"error_message,omitempty"`
 			}{
-				AnalysisRequest: analysis,
+				AnalysisRequest: *analysis,
 				Document:        documentResponse,
-				Status:          "pending",
+				Status:          analysis.Status,
+				ErrorCode:       analysis.ErrorCode,
+				ErrorMessage:    analysis.ErrorMessage,
 			}
 
 			c.JSON(http.StatusOK, response)
@@ -190,7 +231,7 @@ func GetAnalysisResultHandler() gin.HandlerFunc {
 		// Resultado disponible, enviar respuesta completa
 		resultResponse := models.ResultResponse{
 			Result:          result,
-			AnalysisRequest: analysis,
+			AnalysisRequest: *analysis,
 			Document:        documentResponse,
 		}
 
@@ -198,6 +239,112 @@ func GetAnalysisResultHandler() gin.HandlerFunc {
 	}
 }
 
+// CancelAnalysisRequestHandler cancela un análisis que todavía está en cola
+func CancelAnalysisRequestHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtener el análisis verificando los permisos del usuario
+		analysis, _, ok := loadAuthorizedAnalysis(c)
+		if !ok {
+			return
+		}
+
+		cancelled, err := jobs.Cancel(database.DB, analysis.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar el análisis: " + err.Error()})
+			return
+		}
+
+		if !cancelled {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  "El análisis ya comenzó o terminó y no puede cancelarse",
+				"status": analysis.Status,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"id":      analysis.ID,
+			"status":  models.AnalysisStatusCancelled,
+			"message": "Análisis cancelado",
+		})
+	}
+}
+
+// loadAuthorizedAnalysis obtiene el análisis indicado en la URL y su documento, verificando
+// que el usuario actual pueda verlo. Si no es posible, escribe la respuesta de error y devuelve false.
+func loadAuthorizedAnalysis(c *gin.Context) (*models.AnalysisRequest, *models.Document, bool) {
+	// Obtener ID del análisis de la URL
+	analysisIDStr := c.Param("id")
+	analysisID, err := strconv.ParseUint(analysisIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de análisis inválido"})
+		return nil, nil, false
+	}
+
+	// Buscar el análisis
+	var analysis models.AnalysisRequest
+	if err := database.DB.First(&analysis, analysisID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Análisis no encontrado"})
+		return nil, nil, false
+	}
+
+	// Buscar el documento asociado
+	var document models.Document
+	if err := database.DB.First(&document, analysis.DocumentID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener información del documento"})
+		return nil, nil, false
+	}
+
+	// Verificar si el documento está eliminado
+	if document.IsDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El documento asociado ha sido eliminado"})
+		return nil, nil, false
+	}
+
+	// Verificar si el usuario tiene permisos para ver este análisis
+	if document.UserID != nil {
+		// Si hay un usuario autenticado, verificar que sea el propietario
+		if currentUserID, ok := middleware.GetUserIDFromGin(c); ok {
+			if *document.UserID != currentUserID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para ver este análisis"})
+				return nil, nil, false
+			}
+		} else {
+			// Si el documento pertenece a un usuario pero no hay usuario autenticado
+			c.JSON(http.StatusForbidden, gin.H{"error": "Este análisis solo puede ser visto por su propietario"})
+			return nil, nil, false
+		}
+	}
+
+	return &analysis, &document, true
+}
+
+// loadAnalysisResult obtiene un análisis autorizado junto con su resultado vigente (is_latest)
+// y los datos de la gráfica; escribe la respuesta de error si algo falla
+func loadAnalysisResult(c *gin.Context) (*models.AnalysisRequest, *models.Result, *models.GraphData, bool) {
+	analysis, _, ok := loadAuthorizedAnalysis(c)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	var result models.Result
+	if err := database.DB.Where("analysis_request_id = ?", analysis.ID).Where("is_latest = ?", true).First(&result).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "El análisis todavía no tiene resultados",
+			"status": analysis.Status,
+		})
+		return nil, nil, nil, false
+	}
+
+	var graphData models.GraphData
+	if err := json.Unmarshal(result.GraphData, &graphData); err != nil || len(graphData.Time) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "El resultado no contiene datos de respuesta"})
+		return nil, nil, nil, false
+	}
+
+	return analysis, &result, &graphData, true
+}
+
 func GetUserAnalysisRequestsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Obtener ID del usuario del contexto de Gin
@@ -231,7 +378,11 @@ func GetUserAnalysisRequestsHandler() gin.HandlerFunc {
 			ID           uint      `json:"id"`
 			DocumentID   uint      `json:"document_id"`
 			InputVoltage float64   `json:"input_voltage"`
+			Mode         string    `json:"mode"`
 			IsProcessed  bool      `json:"is_processed"`
+			Status       string    `json:"status"`
+			ErrorCode    string    `json:"error_code,omitempty"`
+			ErrorMessage string    `json:"error_message,omitempty"`
 			CreatedAt    time.Time `json:"created_at"`
 			Filename     string    `json:"filename"`
 		}
@@ -240,7 +391,8 @@ func GetUserAnalysisRequestsHandler() gin.HandlerFunc {
 		var analyses []AnalysisWithFilename
 
 		rows, err := database.DB.Raw(`
-			SELECT ar.id, ar.document_id, ar.input_voltage, ar.is_processed, ar.created_at,
+			SELECT ar.id, ar.document_id, ar.input_voltage, ar.mode, ar.is_processed, ar.status,
+				   COALESCE(ar.error_code, ''), COALESCE(ar.error_message, ''), ar.created_at,
 				   d.original_filename as filename
 			FROM analysis_requests ar
 			JOIN documents d ON ar.document_id = d.id
@@ -258,8 +410,9 @@ func GetUserAnalysisRequestsHandler() gin.HandlerFunc {
 		for rows.Next() {
 			var analysis AnalysisWithFilename
 			err := rows.Scan(
-				&analysis.ID, &analysis.DocumentID, &analysis.InputVoltage,
-				&analysis.IsProcessed, &analysis.CreatedAt, &analysis.Filename,
+				&analysis.ID, &analysis.DocumentID, &analysis.InputVoltage, &analysis.Mode,
+				&analysis.IsProcessed, &analysis.Status, &analysis.ErrorCode,
+				&analysis.ErrorMessage, &analysis.CreatedAt, &analysis.Filename,
 			)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar análisis: " + err.Error()})
@@ -279,118 +432,128 @@ func GetUserAnalysisRequestsHandler() gin.HandlerFunc {
 	}
 }
 
-// processAnalysisRequest procesa una solicitud de análisis de forma optimizada
-func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
-	// Esperar un poco para simular procesamiento
-	time.Sleep(2 * time.Second)
+// ProcessAnalysisJob es el handler de la cola de trabajos: carga la solicitud y la procesa
+func ProcessAnalysisJob(analysisID uint) error {
+	var analysis models.AnalysisRequest
+	if err := database.DB.First(&analysis, analysisID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(jobs.ErrCodeRequestNotFound, "La solicitud de análisis ya no existe", err)
+		}
+		return jobs.Transient(jobs.ErrCodePersistFailed, "No se pudo leer la solicitud de análisis", err)
+	}
 
-	// Obtener la URL del archivo
-	var document models.Document
-	if err := database.DB.First(&document, documentID).Error; err != nil {
-		log.Printf("Error al obtener documento: %v", err)
-		return
+	// La solicitud pudo completarse en un intento anterior cuyo cierre no llegó a registrarse
+	if analysis.IsProcessed || analysis.Status == models.AnalysisStatusSucceeded {
+		log.Printf("Análisis %d ya estaba procesado, se omite", analysisID)
+		return nil
+	}
+	if analysis.Status == models.AnalysisStatusCancelled {
+		log.Printf("Análisis %d fue cancelado, se omite", analysisID)
+		return nil
 	}
 
-	// Crear un manejador de almacenamiento en Cloudinary
-	cloudStorage := utils.NewCloudinaryStorage()
+	return processAnalysisRequest(&analysis)
+}
+
+// processAnalysisRequest procesa una solicitud de análisis de forma optimizada
+func processAnalysisRequest(analysis *models.AnalysisRequest) error {
+	analysisID, inputVoltage := analysis.ID, analysis.InputVoltage
 
-	// Obtener el archivo de Cloudinary
-	fileReader, err := cloudStorage.GetFile(document.FilePath)
+	// Descargar, leer y preprocesar el archivo del documento
+	signal, err := loadAnalysisSignal(analysis, func(stage string) {
+		jobs.ReportStage(database.DB, analysisID, stage)
+	})
 	if err != nil {
-		log.Printf("Error al obtener archivo de Cloudinary: %v", err)
-		return
+		return err
 	}
-	defer fileReader.Close()
-
-	// Leer y procesar el CSV
-	reader := csv.NewReader(fileReader)
+	series, report, options, preprocessing := signal.series, signal.report, signal.options, signal.preprocessing
+	excitation := options.ExcitationType()
 
-	// Variables para almacenar información del CSV
-	var samplingPeriod float64 = 0.001 // Valor por defecto: 1ms
-	var rawTimeData []float64
-	var rawOutputData []float64
+	// El modo espectral analiza la señal completa sin identificar un modelo
+	if analysis.Mode == models.AnalysisModeSpectral {
+		return processSpectralAnalysis(analysis, series, report, preprocessing)
+	}
 
-	// Leer línea por línea buscando información relevante
-	lineNumber := 0
-	dataStarted := false
+	// Las excitaciones distintas del escalón se identifican simulando la entrada real
+	if excitation != models.ExcitationStep {
+		return processExcitationAnalysis(analysis, signal)
+	}
 
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Printf("Error al leer línea %d del CSV: %v", lineNumber, err)
-			lineNumber++
-			continue
-		}
+	rawTimeData, rawOutputData, samplingPeriod := series.Time, series.Output, series.SamplingPeriod
+	autoTrim := options.Preprocessing.AutoTrimEnabled()
 
-		lineNumber++
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStageOptimizing)
 
-		// Buscar el sampling period en las primeras líneas
-		if lineNumber <= 10 && len(record) >= 2 {
-			if strings.Contains(strings.ToLower(record[0]), "sampling period") {
-				if val, err := strconv.ParseFloat(record[1], 64); err == nil {
-					samplingPeriod = val
-					log.Printf("Sampling period encontrado: %e", samplingPeriod)
-					continue
-				}
-			}
-		}
+	// Recortar la respuesta y corregir el tiempo, a resolución completa
+	responseTime, responseOutput, trimStart := optimizeDataPoints(rawTimeData, rawOutputData, inputVoltage, samplingPeriod, autoTrim)
 
-		// Identificar si esta línea contiene encabezados
-		if len(record) >= 2 && !dataStarted {
-			// Buscar patrones de encabezados
-			col1Lower := strings.ToLower(record[0])
-			col2Lower := strings.ToLower(record[1])
+	// La identificación usa la respuesta promediada por bloques (filtro antialiasing) y la
+	// gráfica guardada una reducción LTTB que conserva picos y flancos
+	fitTime, fitOutput, _ := averageBlocks(responseTime, responseOutput, nil, maxFitPoints)
+	optimizedTime, optimizedOutput := decimateSeries(responseTime, responseOutput, defaultGraphPoints)
 
-			if strings.Contains(col1Lower, "tiempo") || strings.Contains(col1Lower, "time") ||
-				strings.Contains(col2Lower, "salida") || strings.Contains(col2Lower, "output") {
-				log.Printf("Encabezados encontrados en línea %d: %v", lineNumber, record)
-				continue
-			}
-		}
+	log.Printf("Respuesta de %d puntos: %d para la identificación y %d para la gráfica", len(responseTime), len(fitTime), len(optimizedTime))
 
-		// Intentar procesar como datos numéricos
-		if len(record) >= 2 {
-			// Convertir tiempo
-			if timeVal, err := strconv.ParseFloat(record[0], 64); err == nil {
-				// Convertir salida
-				if outputVal, err := strconv.ParseFloat(record[1], 64); err == nil {
-					rawTimeData = append(rawTimeData, timeVal)
-					rawOutputData = append(rawOutputData, outputVal)
-					dataStarted = true
-				}
-			}
-		}
+	// Crear estructura de datos de gráfica
+	graphData := models.GraphData{
+		Time:   optimizedTime,
+		Output: optimizedOutput,
 	}
 
-	// Si no se encontró sampling period explícito, calcularlo desde los datos de tiempo
-	if samplingPeriod == 0.001 && len(rawTimeData) >= 2 {
-		calculatedPeriod := math.Abs(rawTimeData[1] - rawTimeData[0])
-		if calculatedPeriod > 0 && calculatedPeriod < 1.0 { // Debe ser un período razonable
-			samplingPeriod = calculatedPeriod
-			log.Printf("Sampling period calculado desde datos: %e", samplingPeriod)
-		}
-	}
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStageIdentifying)
 
-	log.Printf("Leídos %d puntos de datos del archivo", len(rawTimeData))
+	// Identificación analítica de segundo orden (independiente del servicio ML)
+	analyticModel, err := control.IdentifySecondOrder(fitTime, fitOutput, inputVoltage)
+	if err != nil {
+		log.Printf("No se pudo identificar el modelo analítico: %v", err)
+	} else {
+		log.Printf("Modelo analítico (%s): K=%f, wn=%f, zeta=%f, rms=%f",
+			analyticModel.Method, analyticModel.Gain, analyticModel.Wn, analyticModel.Zeta, analyticModel.RMS)
+	}
 
-	// Verificar que tenemos datos suficientes
-	if len(rawTimeData) == 0 || len(rawOutputData) == 0 {
-		log.Printf("No se encontraron datos válidos de tiempo/salida")
-		return
+	// Valor final y ganancia estática: promedio del tramo final si la respuesta se estableció,
+	// o extrapolación del modelo analítico si la grabación terminó antes
+	finalValue, err := control.EstimateFinalValue(responseTime, responseOutput, inputVoltage, analyticModel)
+	if err != nil {
+		log.Printf("No se pudo estimar el valor final: %v", err)
+	} else {
+		log.Printf("Valor final estimado (%s): %f, K=%f, deriva final=%.2f%%",
+			finalValue.Method, finalValue.FinalValue, finalValue.DCGain, finalValue.TailDrift*100)
 	}
 
-	// Procesar y optimizar los datos con tiempo corregido
-	optimizedTime, optimizedOutput := optimizeDataPoints(rawTimeData, rawOutputData, inputVoltage, samplingPeriod)
+	// Tiempo muerto sobre la serie sin recortar, que conserva el tramo anterior al escalón
+	deadTime, err := estimateStepDeadTime(series, options.Excitation, finalValue)
+	if err != nil {
+		log.Printf("No se pudo estimar el tiempo muerto: %v", err)
+	} else {
+		log.Printf("Tiempo muerto (%s): θ=%f, escalón en t=%f (%s)", deadTime.Method, deadTime.DeadTime, deadTime.StepTime, deadTime.StepTimeSource)
+	}
 
-	log.Printf("Optimizados a %d puntos de datos", len(optimizedTime))
+	// Modelos FOPDT/SOPDT por los métodos gráficos y por mínimos cuadrados, desde el escalón
+	var processModel *control.ProcessIdentification
+	if deadTime != nil {
+		processModel, err = identifyStepProcessModel(series, deadTime, finalValue, inputVoltage)
+		if err != nil {
+			log.Printf("No se pudo identificar el modelo de proceso: %v", err)
+		} else {
+			log.Printf("Modelo de proceso (%s, %s): K=%f, τ=%f, τ2=%f, θ=%f, ajuste=%.2f%%", processModel.Best.Type, processModel.Best.Method,
+				processModel.Best.Gain, processModel.Best.Tau, processModel.Best.Tau2, processModel.Best.DeadTime, processModel.Best.FitPercent)
+		}
+	}
 
-	// Crear estructura de datos de gráfica
-	graphData := models.GraphData{
-		Time:   optimizedTime,
-		Output: optimizedOutput,
+	// Modelo discreto ARX/ARMAX/OE, si se pidió, al período de muestreo de la serie
+	var discreteModel *control.DiscreteIdentification
+	var discreteRecord *models.DiscreteModel
+	if options.Discrete != nil && deadTime != nil {
+		discreteModel, discreteRecord, err = identifyStepDiscreteModel(series, options.Discrete, deadTime, inputVoltage)
+		if err != nil {
+			log.Printf("No se pudo identificar el modelo discreto: %v", err)
+		} else {
+			log.Printf("Modelo discreto %s(na=%d, nb=%d, nk=%d), T=%f, ajuste=%.2f%%", discreteModel.Model.Structure,
+				discreteModel.Model.Na, discreteModel.Model.Nb, discreteModel.Model.Nk, discreteModel.Model.SamplingPeriod, discreteModel.Model.FitPercent)
+		}
+	} else if options.Discrete != nil {
+		log.Printf("No se identifica el modelo discreto: no se conoce el instante del escalón")
 	}
 
 	// INTEGRACIÓN CON MACHINE LEARNING - EJECUTAR PRIMERO
@@ -406,7 +569,9 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 		},
 	}
 
-	// Extraer características para ML
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStageExtractingFeatures)
+
+	// Extraer características para ML (sobre la gráfica reducida, como en el entrenamiento)
 	features := extractFeatures(optimizedTime, optimizedOutput, inputVoltage)
 	if len(features) > 0 {
 		// Obtener URL del servicio ML
@@ -418,6 +583,8 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 		// Crear cliente ML
 		mlClient := utils.NewMLClient(mlServiceURL)
 
+		jobs.ReportStage(database.DB, analysisID, models.AnalysisStagePredictingType)
+
 		// Predecir tipo de sistema
 		typeResp, err := mlClient.PredictType(features)
 		if err != nil {
@@ -437,6 +604,8 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 			}
 		}
 
+		jobs.ReportStage(database.DB, analysisID, models.AnalysisStagePredictingPoles)
+
 		polosResp, err := mlClient.PredictPolos(features)
 		if err != nil {
 			log.Printf("Error prediciendo polos: %v", err)
@@ -505,17 +674,43 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 		}
 	}
 
+	// Si el servicio ML no respondió, usar el modelo analítico en lugar de los valores por defecto
+	if analyticModel != nil {
+		if mlPredictedType == nil {
+			systemType = analyticSystemType(analyticModel)
+		}
+		if mlPolo1Real == nil {
+			polesData = map[string]interface{}{"polos": polesToMaps(analyticModel.Poles)}
+		}
+	}
+
 	// Datos adicionales (DESPUÉS de las predicciones ML)
 	rawData := map[string]interface{}{
 		"voltaje_entrada":    inputVoltage,
 		"puntos_originales":  len(rawTimeData),
+		"puntos_analizados":  len(responseTime),
 		"puntos_optimizados": len(optimizedTime),
 		"sampling_period":    samplingPeriod,
-		"tiempo_inicial":     optimizedTime[0],
-		"tiempo_final":       optimizedTime[len(optimizedTime)-1],
-		"valor_inicial":      optimizedOutput[0],
-		"valor_final":        optimizedOutput[len(optimizedOutput)-1],
-	}
+		"tiempo_inicial":     responseTime[0],
+		"tiempo_final":       responseTime[len(responseTime)-1],
+		"valor_inicial":      responseOutput[0],
+		"valor_final":        responseOutput[len(responseOutput)-1],
+		"lectura":            report,
+		"recorte_automatico": autoTrim,
+		"inicio_recorte":     trimStart,
+	}
+	if deadTime != nil {
+		rawData["tiempo_muerto"] = deadTime.DeadTime
+		rawData["instante_escalon"] = deadTime.StepTime
+		rawData["fuente_instante_escalon"] = deadTime.StepTimeSource
+	}
+	if processModel != nil {
+		rawData["modelo_proceso"] = processModel.Best.Type
+		rawData["metodo_modelo_proceso"] = processModel.Best.Method
+		rawData["constante_tiempo"] = processModel.Best.Tau
+	}
+	addInstrumentData(rawData, report)
+	addPreprocessingData(rawData, preprocessing)
 
 	// Agregar datos ML al rawData si están disponibles
 	if mlPredictedType != nil {
@@ -536,31 +731,35 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 
 	log.Printf("RawData incluye ML: tipo=%v, polo1=%v, polo2=%v", mlPredictedType, mlPolo1Real, mlPolo2Real)
 
-	// Convertir a JSON
-	polesJSON, _ := json.Marshal(polesData)
-	rawDataJSON, _ := json.Marshal(rawData)
-	graphDataJSON, _ := json.Marshal(graphData)
-
-	// Comenzar transacción
-	tx := database.DB.Begin()
-
-	// Marcar todos los resultados previos como no-latest
-	if err := tx.Model(&models.Result{}).Where(
-		"analysis_request_id IN (SELECT id FROM analysis_requests WHERE document_id = ?)", documentID,
-	).Update("is_latest", false).Error; err != nil {
-		tx.Rollback()
-		log.Printf("Error al actualizar resultados previos: %v", err)
-		return
+	// Validar el modelo: simular el modelo analítico (o, en su defecto, los polos almacenados)
+	// y compararlo con la curva medida
+	var modelValidation map[string]interface{}
+	if polosArray, ok := polesData["polos"].([]map[string]float64); ok {
+		simulated, validation, err := validateModel(fitTime, fitOutput, polosArray, analyticModel, inputVoltage)
+		if err != nil {
+			log.Printf("No se pudo validar el modelo: %v", err)
+		} else {
+			graphData.Simulated = interpolateAt(fitTime, simulated, optimizedTime)
+			modelValidation = validation
+			log.Printf("Validación del modelo: ajuste=%.2f%%, R2=%.4f", validation["porcentaje_ajuste"], validation["r2"])
+		}
 	}
 
-	// Después de calcular las métricas de rendimiento, antes de crear el Result
-	performanceMetrics := extractPerformanceMetrics(optimizedTime, optimizedOutput, inputVoltage)
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStagePersisting)
+
+	// Métricas de rendimiento respecto del valor final estimado, antes de crear el Result
+	performanceMetrics := extractPerformanceMetrics(responseTime, responseOutput, inputVoltage, options.Metrics.Band(), finalValue)
 
 	// Agregar métricas al rawData
 	for key, value := range performanceMetrics {
 		rawData[key] = value
 	}
 
+	// Convertir a JSON
+	polesJSON, _ := json.Marshal(polesData)
+	rawDataJSON, _ := json.Marshal(rawData)
+	graphDataJSON, _ := json.Marshal(graphData)
+
 	// Convertir polesData a slice para la descripción
 	var polesSlice []map[string]float64
 	if polosArray, ok := polesData["polos"].([]map[string]float64); ok {
@@ -572,6 +771,33 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 
 	// Generar resumen técnico
 	technicalSummary := generateTechnicalSummary(rawData, polesSlice)
+	if analyticModel != nil {
+		technicalSummary["modelo_analitico"] = analyticModelSummary(analyticModel, mlPolo1Real, mlPolo1Imag, mlPolo2Real, mlPolo2Imag)
+	}
+	if modelValidation != nil {
+		technicalSummary["validacion_modelo"] = modelValidation
+	}
+	if deadTime != nil {
+		technicalSummary["tiempo_muerto"] = deadTimeSummary(deadTime)
+	}
+	if processModel != nil {
+		technicalSummary["modelo_proceso"] = processModelSummary(processModel)
+	}
+	if discreteModel != nil {
+		technicalSummary["modelo_discreto"] = discreteModelSummary(discreteModel)
+	}
+
+	// Márgenes de estabilidad del modelo identificado: el ajuste analítico y, en su defecto,
+	// los polos del modelo ML
+	identified, err := identifiedTransferFunction(nil, polesSlice, modelGain(fitOutput, nil, inputVoltage))
+	if analyticModel != nil {
+		identified, err = analyticModel.TransferFunction()
+	}
+	if err == nil {
+		if margins, err := control.Margins(identified); err == nil {
+			technicalSummary["margenes_estabilidad"] = frequencySummary(margins)
+		}
+	}
 	technicalSummaryJSON, _ := json.Marshal(technicalSummary)
 
 	// Crear resultado con descripción
@@ -594,39 +820,87 @@ func processAnalysisRequest(analysisID, documentID uint, inputVoltage float64) {
 		MLPolo2Imag:     mlPolo2Imag,
 	}
 
-	if err := tx.Create(&result).Error; err != nil {
+	if deadTime != nil {
+		result.DeadTime = &deadTime.DeadTime
+		result.StepTime = &deadTime.StepTime
+	}
+	if processModel != nil {
+		processModelJSON, _ := json.Marshal(processModel)
+		result.ProcessModel = datatypes.JSON(processModelJSON)
+	}
+	result.DiscreteModel = discreteRecord
+
+	// Polos del modelo analítico, junto a los del modelo ML para compararlos
+	if analyticModel != nil {
+		analyticModelJSON, _ := json.Marshal(analyticModel)
+		result.AnalyticModel = datatypes.JSON(analyticModelJSON)
+		result.FitPolo1Real = &analyticModel.Poles[0].Real
+		result.FitPolo1Imag = &analyticModel.Poles[0].Imag
+		result.FitPolo2Real = &analyticModel.Poles[1].Real
+		result.FitPolo2Imag = &analyticModel.Poles[1].Imag
+	}
+
+	if err := saveAnalysisResult(analysisID, analysis.DocumentID, &result); err != nil {
+		return err
+	}
+
+	log.Printf("Análisis %d completado exitosamente con %d puntos analizados", analysisID, len(responseTime))
+	return nil
+}
+
+// saveAnalysisResult guarda el resultado como el más reciente del documento y marca la
+// solicitud como completada, todo en una transacción
+func saveAnalysisResult(analysisID, documentID uint, result *models.Result) error {
+	// Comenzar transacción
+	tx := database.DB.Begin()
+
+	// Marcar todos los resultados previos como no-latest
+	if err := tx.Model(&models.Result{}).Where(
+		"analysis_request_id IN (SELECT id FROM analysis_requests WHERE document_id = ?)", documentID,
+	).Update("is_latest", false).Error; err != nil {
+		tx.Rollback()
+		return jobs.Transient(jobs.ErrCodePersistFailed, "Error al actualizar resultados previos", err)
+	}
+
+	if err := tx.Create(result).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error al guardar resultado: %v", err)
-		return
+		return jobs.Transient(jobs.ErrCodePersistFailed, "Error al guardar el resultado", err)
 	}
 
 	// Marcar la solicitud como procesada
-	if err := tx.Model(&models.AnalysisRequest{}).Where("id = ?", analysisID).Update("is_processed", true).Error; err != nil {
+	if err := tx.Model(&models.AnalysisRequest{}).Where("id = ?", analysisID).Updates(map[string]interface{}{
+		"is_processed":  true,
+		"status":        models.AnalysisStatusSucceeded,
+		"error_code":    "",
+		"error_message": "",
+		"finished_at":   time.Now(),
+	}).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error al actualizar solicitud: %v", err)
-		return
+		return jobs.Transient(jobs.ErrCodePersistFailed, "Error al actualizar la solicitud", err)
 	}
 
 	// Confirmar transacción
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("Error al confirmar transacción: %v", err)
-		return
+		return jobs.Transient(jobs.ErrCodePersistFailed, "Error al confirmar la transacción", err)
 	}
-
-	log.Printf("Análisis %d completado exitosamente con %d puntos optimizados", analysisID, len(optimizedTime))
+	return nil
 }
 
-// optimizeDataPoints optimiza los datos eliminando tiempo muerto y corrigiendo el tiempo
-func optimizeDataPoints(timeData, outputData []float64, inputVoltage, samplingPeriod float64) ([]float64, []float64) {
+// optimizeDataPoints recorta la respuesta eliminando tiempo muerto y corrige el tiempo para
+// que empiece en 0; conserva la resolución completa de la porción relevante y devuelve el
+// instante original de su primera muestra
+func optimizeDataPoints(timeData, outputData []float64, inputVoltage, samplingPeriod float64, autoTrim bool) ([]float64, []float64, float64) {
 	if len(timeData) == 0 || len(outputData) == 0 {
-		return timeData, outputData
+		return timeData, outputData, 0
 	}
 
-	// Paso 1: Detectar el punto donde empieza el cambio significativo
-	startIndex := findSignificantChangeStart(outputData, inputVoltage)
-
-	// Paso 2: Encontrar el punto donde se estabiliza (cerca del voltaje de entrada)
-	endIndex := findStabilizationPoint(outputData, inputVoltage, startIndex)
+	// Pasos 1 y 2: recortar desde el inicio del cambio significativo hasta la estabilización
+	// (cerca del voltaje de entrada), salvo que el preprocesamiento lo desactive
+	startIndex, endIndex := 0, len(outputData)-1
+	if autoTrim {
+		startIndex = findSignificantChangeStart(outputData, inputVoltage)
+		endIndex = findStabilizationPoint(outputData, inputVoltage, startIndex)
+	}
 
 	log.Printf("Datos relevantes desde índice %d hasta %d (de %d total)", startIndex, endIndex, len(timeData))
 
@@ -642,10 +916,7 @@ func optimizeDataPoints(timeData, outputData []float64, inputVoltage, samplingPe
 
 	log.Printf("Tiempo corregido: de %f-%f a 0-%f", relevantTime[0], relevantTime[len(relevantTime)-1], correctedTime[len(correctedTime)-1])
 
-	// Paso 4: Reducir la densidad de puntos manteniendo los más importantes
-	optimizedTime, optimizedOutput := reduceDataDensity(correctedTime, relevantOutput, 300) // Reducido a 300 puntos máximo
-
-	return optimizedTime, optimizedOutput
+	return correctedTime, relevantOutput, relevantTime[0]
 }
 
 // findSignificantChangeStart encuentra el índice donde empieza el cambio significativo
@@ -725,59 +996,6 @@ func findStabilizationPoint(outputData []float64, inputVoltage float64, startInd
 	return fallbackIdx
 }
 
-// reduceDataDensity reduce la cantidad de puntos manteniendo los más importantes
-func reduceDataDensity(timeData, outputData []float64, maxPoints int) ([]float64, []float64) {
-	if len(timeData) <= maxPoints {
-		return timeData, outputData
-	}
-
-	// Algoritmo de Douglas-Peucker simplificado para mantener puntos importantes
-	reducedTime := []float64{timeData[0]} // Siempre incluir el primer punto
-	reducedOutput := []float64{outputData[0]}
-
-	// Calcular el intervalo de muestreo adaptativo
-	step := float64(len(timeData)) / float64(maxPoints-2) // -2 para incluir primer y último punto
-
-	for i := 1; i < len(timeData)-1; i++ {
-		// Incluir punto si:
-		// 1. Es parte del intervalo regular
-		// 2. Hay un cambio significativo en la derivada (pico, valle, inflexión)
-		includePoint := false
-
-		// Condición 1: Intervalo regular
-		if float64(i) >= float64(len(reducedTime))*step {
-			includePoint = true
-		}
-
-		// Condición 2: Cambio significativo en la derivada
-		if i > 0 && i < len(outputData)-1 {
-			prevSlope := outputData[i] - outputData[i-1]
-			nextSlope := outputData[i+1] - outputData[i]
-
-			// Detectar cambios significativos de pendiente
-			if math.Abs(nextSlope-prevSlope) > 0.1 { // Umbral ajustable
-				includePoint = true
-			}
-		}
-
-		if includePoint {
-			reducedTime = append(reducedTime, timeData[i])
-			reducedOutput = append(reducedOutput, outputData[i])
-		}
-
-		// Parar si ya tenemos suficientes puntos
-		if len(reducedTime) >= maxPoints-1 {
-			break
-		}
-	}
-
-	// Siempre incluir el último punto
-	reducedTime = append(reducedTime, timeData[len(timeData)-1])
-	reducedOutput = append(reducedOutput, outputData[len(outputData)-1])
-
-	return reducedTime, reducedOutput
-}
-
 // extractFeatures extrae 30 características mejoradas compatibles con el modelo Python
 func extractFeatures(timeData, outputData []float64, inputVoltage float64) []float64 {
 	if len(timeData) < 10 || len(outputData) < 10 {
@@ -1060,53 +1278,6 @@ func calculateEnergy(data []float64) float64 {
 	return energy / float64(len(data))
 }
 
-// calculateSettlingTime calcula el tiempo de establecimiento (95% del valor final)
-func calculateSettlingTime(timeData, outputData []float64, inputVoltage float64) float64 {
-	target := inputVoltage * 0.95
-	tolerance := math.Abs(inputVoltage) * 0.05
-
-	for i := len(outputData) - 1; i >= 0; i-- {
-		if math.Abs(outputData[i]-target) > tolerance {
-			if i+1 < len(timeData) {
-				return timeData[i+1]
-			}
-		}
-	}
-	return timeData[len(timeData)-1]
-}
-
-// calculateMaxOvershoot calcula el sobrepico máximo como porcentaje
-func calculateMaxOvershoot(outputData []float64, inputVoltage float64) float64 {
-	maxVal := findMaxValue(outputData)
-	if inputVoltage != 0 {
-		return math.Max(0, (maxVal-inputVoltage)/math.Abs(inputVoltage)*100)
-	}
-	return 0.0
-}
-
-// calculateRiseTime calcula el tiempo de subida (10% a 90% del valor final)
-func calculateRiseTime(timeData, outputData []float64, inputVoltage float64) float64 {
-	target10 := inputVoltage * 0.1
-	target90 := inputVoltage * 0.9
-
-	var time10, time90 float64 = -1, -1
-
-	for i, val := range outputData {
-		if time10 == -1 && val >= target10 {
-			time10 = timeData[i]
-		}
-		if time90 == -1 && val >= target90 {
-			time90 = timeData[i]
-			break
-		}
-	}
-
-	if time10 != -1 && time90 != -1 {
-		return time90 - time10
-	}
-	return 0
-}
-
 // countZeroCrossings cuenta los cruces por cero en la señal
 func countZeroCrossings(data []float64) int {
 	count := 0
@@ -1263,8 +1434,37 @@ func generateSystemDescription(systemType string, rawData map[string]interface{}
 		description.WriteString("Consulte los datos técnicos para más detalles sobre el comportamiento del sistema.")
 	}
 
-	// Agregar información sobre el voltaje de entrada
-	description.WriteString(fmt.Sprintf(" Análisis realizado con voltaje de entrada de %.1fV.", inputVoltage))
+	// Ganancia estática y origen del valor final
+	if gain, ok := rawData["ganancia_estatica"].(float64); ok {
+		finalValue, _ := rawData["valor_final_estimado"].(float64)
+		source := "promedio del tramo final"
+		if rawData["metodo_valor_final"] == control.FinalValueModel {
+			source = "del modelo identificado"
+			if stationary, _ := rawData["respuesta_estacionaria"].(bool); !stationary {
+				source = "extrapolado del modelo porque la grabación termina antes del establecimiento"
+			}
+		}
+		description.WriteString(fmt.Sprintf(" Ganancia estática K=%.3f (valor final %.3f, %s).", gain, finalValue, source))
+	}
+
+	// Tiempo muerto
+	if deadTime, ok := rawData["tiempo_muerto"].(float64); ok && deadTime > 0 {
+		if rawData["fuente_instante_escalon"] == control.StepTimeAssumed {
+			description.WriteString(fmt.Sprintf(" Tiempo muerto θ=%.3f segundos, medido desde el inicio del registro porque no se conoce el instante del escalón.", deadTime))
+		} else {
+			description.WriteString(fmt.Sprintf(" Tiempo muerto θ=%.3f segundos.", deadTime))
+		}
+	}
+
+	// Modelo de proceso de mejor ajuste
+	if processType, ok := rawData["modelo_proceso"].(string); ok {
+		tau, _ := rawData["constante_tiempo"].(float64)
+		description.WriteString(fmt.Sprintf(" Se aproxima por un modelo %s con constante de tiempo τ=%.3f segundos (%s).",
+			strings.ToUpper(processType), tau, rawData["metodo_modelo_proceso"]))
+	}
+
+	// Agregar información sobre la entrada aplicada
+	description.WriteString(" " + excitationDescription(rawData, inputVoltage))
 
 	return description.String()
 }
@@ -1301,6 +1501,39 @@ func generateTechnicalSummary(rawData map[string]interface{}, poles []map[string
 		summary["tiempo_subida"] = riseTime
 	}
 
+	// Métricas transitorias extendidas
+	for key, label := range map[string]string{
+		"settling_band":      "banda_establecimiento",
+		"settled":            "establecido",
+		"peak_time":          "tiempo_pico",
+		"delay_time":         "tiempo_retardo",
+		"undershoot":         "subpico_porcentaje",
+		"decay_ratio":        "razon_decaimiento",
+		"oscillation_period": "periodo_oscilacion",
+		"oscillations":       "numero_oscilaciones",
+		"iae":                "iae",
+		"ise":                "ise",
+		"itae":               "itae",
+	} {
+		if value, ok := rawData[key]; ok {
+			summary[label] = value
+		}
+	}
+
+	// Valor final estimado, ganancia estática y métricas según ambas convenciones
+	for _, key := range []string{"valor_final_estimado", "ganancia_estatica", "metodo_valor_final", "respuesta_estacionaria"} {
+		if value, ok := rawData[key]; ok {
+			summary[key] = value
+		}
+	}
+	if fromFinal, ok := rawData["metricas_valor_final"]; ok {
+		conventions := map[string]interface{}{"valor_final": fromFinal}
+		if fromReference, ok := rawData["metricas_referencia"]; ok {
+			conventions["referencia"] = fromReference
+		}
+		summary["convenciones_metricas"] = conventions
+	}
+
 	// Información de calidad de datos
 	if puntosOriginales, ok := rawData["puntos_originales"]; ok {
 		summary["puntos_datos_originales"] = puntosOriginales
@@ -1313,42 +1546,86 @@ func generateTechnicalSummary(rawData map[string]interface{}, poles []map[string
 	return summary
 }
 
-// Función auxiliar para extraer métricas adicionales durante el procesamiento
-func extractPerformanceMetrics(timeData, outputData []float64, inputVoltage float64) map[string]interface{} {
-	metrics := make(map[string]interface{})
-
-	// Calcular métricas de desempeño
-	metrics["max_overshoot"] = calculateMaxOvershoot(outputData, inputVoltage)
-	metrics["settling_time"] = calculateSettlingTime(timeData, outputData, inputVoltage)
-	metrics["rise_time"] = calculateRiseTime(timeData, outputData, inputVoltage)
-	metrics["steady_state_error"] = calculateSteadyStateError(outputData, inputVoltage)
-
-	return metrics
-}
-
-// calculateSteadyStateError calcula el error de estado estable
-func calculateSteadyStateError(outputData []float64, inputVoltage float64) float64 {
-	if len(outputData) == 0 {
-		return 0
+// extractPerformanceMetrics calcula las métricas de la respuesta transitoria respecto del valor
+// final estimado (finalValue; si es nil, el promedio del tramo final) con la banda de
+// establecimiento indicada. También las calcula respecto de la referencia (nivel inicial más
+// la amplitud aplicada, es decir, suponiendo ganancia unitaria) para comparar ambas
+// convenciones, y el error de estado estable.
+func extractPerformanceMetrics(timeData, outputData []float64, inputVoltage, settlingBand float64, finalValue *control.FinalValueEstimate) map[string]interface{} {
+	initial, final := control.StepLevels(outputData)
+	if finalValue != nil {
+		initial, final = finalValue.InitialValue, finalValue.FinalValue
+	}
+	metrics := map[string]interface{}{
+		"steady_state_error": calculateSteadyStateError(initial, final, inputVoltage),
+	}
+	if finalValue != nil {
+		metrics["valor_final_estimado"] = finalValue.FinalValue
+		metrics["ganancia_estatica"] = finalValue.DCGain
+		metrics["metodo_valor_final"] = finalValue.Method
+		metrics["respuesta_estacionaria"] = finalValue.Stationary
+		metrics["deriva_final"] = finalValue.TailDrift
 	}
 
-	// Usar el promedio de los últimos 10% de los datos como valor de estado estable
-	startIdx := int(float64(len(outputData)) * 0.9)
-	if startIdx >= len(outputData) {
-		startIdx = len(outputData) - 1
+	stepMetrics, err := control.StepResponseMetrics(timeData, outputData, initial, final, settlingBand)
+	if err != nil {
+		log.Printf("No se pudieron calcular las métricas transitorias: %v", err)
+		return metrics
+	}
+
+	metrics["max_overshoot"] = stepMetrics.Overshoot
+	metrics["settling_time"] = stepMetrics.SettlingTime
+	metrics["rise_time"] = stepMetrics.RiseTime
+	metrics["settling_band"] = stepMetrics.SettlingBand
+	metrics["settled"] = stepMetrics.Settled
+	metrics["peak_time"] = stepMetrics.PeakTime
+	metrics["peak_value"] = stepMetrics.PeakValue
+	metrics["delay_time"] = stepMetrics.DelayTime
+	metrics["undershoot"] = stepMetrics.Undershoot
+	metrics["decay_ratio"] = stepMetrics.DecayRatio
+	metrics["oscillation_period"] = stepMetrics.OscillationPeriod
+	metrics["oscillations"] = stepMetrics.Oscillations
+	metrics["iae"] = stepMetrics.IAE
+	metrics["ise"] = stepMetrics.ISE
+	metrics["itae"] = stepMetrics.ITAE
+	metrics["metricas_valor_final"] = stepMetricsSummary(stepMetrics)
+
+	// La misma respuesta medida contra la referencia, como hacían las métricas originales
+	if inputVoltage != 0 {
+		if reference, err := control.StepResponseMetrics(timeData, outputData, initial, initial+inputVoltage, settlingBand); err == nil {
+			metrics["metricas_referencia"] = stepMetricsSummary(reference)
+		}
 	}
+	return metrics
+}
 
-	steadyStateSum := 0.0
-	count := 0
-	for i := startIdx; i < len(outputData); i++ {
-		steadyStateSum += outputData[i]
-		count++
+// stepMetricsSummary resume las métricas transitorias para el resumen técnico
+func stepMetricsSummary(m *control.StepMetrics) map[string]interface{} {
+	return map[string]interface{}{
+		"valor_inicial":          m.InitialValue,
+		"valor_final":            m.FinalValue,
+		"sobrepico_porcentaje":   m.Overshoot,
+		"subpico_porcentaje":     m.Undershoot,
+		"tiempo_subida":          m.RiseTime,
+		"tiempo_retardo":         m.DelayTime,
+		"tiempo_pico":            m.PeakTime,
+		"tiempo_establecimiento": m.SettlingTime,
+		"banda_establecimiento":  m.SettlingBand,
+		"establecido":            m.Settled,
+		"razon_decaimiento":      m.DecayRatio,
+		"periodo_oscilacion":     m.OscillationPeriod,
+		"numero_oscilaciones":    m.Oscillations,
+		"iae":                    m.IAE,
+		"ise":                    m.ISE,
+		"itae":                   m.ITAE,
 	}
+}
 
-	if count == 0 {
+// calculateSteadyStateError calcula el error de estado estable como porcentaje de la
+// referencia: compara el salto estimado (del nivel inicial al final) con la amplitud aplicada
+func calculateSteadyStateError(initial, final, inputVoltage float64) float64 {
+	if inputVoltage == 0 {
 		return 0
 	}
-
-	steadyStateValue := steadyStateSum / float64(count)
-	return math.Abs(inputVoltage-steadyStateValue) / math.Abs(inputVoltage) * 100 // Porcentaje
+	return math.Abs(inputVoltage-(final-initial)) / math.Abs(inputVoltage) * 100 // Porcentaje
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"backend/database"
+	"backend/jobs"
+	"backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Intervalo de consulta a la base de datos, para análisis procesados por otra instancia
+	eventsPollInterval = 2 * time.Second
+	// Intervalo de los comentarios keep-alive que evitan que los proxies cierren la conexión
+	eventsKeepAliveInterval = 15 * time.Second
+)
+
+// GetAnalysisEventsHandler transmite el progreso de un análisis mediante Server-Sent Events.
+// Cada evento "progress" contiene el estado y la etapa actual; el flujo termina
+// cuando el análisis alcanza un estado final.
+func GetAnalysisEventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtener el análisis verificando los permisos del usuario
+		analysis, _, ok := loadAuthorizedAnalysis(c)
+		if !ok {
+			return
+		}
+
+		// Suscribirse antes de leer el estado para no perder transiciones intermedias
+		events, unsubscribe := jobs.Subscribe(analysis.ID)
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // Desactivar el buffering de nginx
+		c.Status(http.StatusOK)
+
+		// Enviar el estado actual como primer evento
+		last := snapshotEvent(analysis)
+		sendProgressEvent(c, last)
+		if analysis.IsFinished() {
+			return
+		}
+
+		poll := time.NewTicker(eventsPollInterval)
+		defer poll.Stop()
+		keepAlive := time.NewTicker(eventsKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+
+			case event := <-events:
+				last = event
+				sendProgressEvent(c, event)
+				if models.IsFinalAnalysisStatus(event.Status) {
+					return
+				}
+
+			case <-poll.C:
+				var current models.AnalysisRequest
+				if err := database.DB.First(&current, analysis.ID).Error; err != nil {
+					sendProgressEvent(c, jobs.ProgressEvent{
+						AnalysisID:   analysis.ID,
+						Status:       models.AnalysisStatusFailed,
+						ErrorCode:    jobs.ErrCodeRequestNotFound,
+						ErrorMessage: "La solicitud de análisis ya no existe",
+						Timestamp:    time.Now(),
+					})
+					return
+				}
+
+				if current.Status != last.Status || current.Stage != last.Stage {
+					last = snapshotEvent(&current)
+					sendProgressEvent(c, last)
+				}
+				if current.IsFinished() {
+					return
+				}
+
+			case <-keepAlive.C:
+				c.Writer.WriteString(": keep-alive\n\n")
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+// snapshotEvent construye un evento de progreso a partir del estado guardado de la solicitud
+func snapshotEvent(analysis *models.AnalysisRequest) jobs.ProgressEvent {
+	return jobs.ProgressEvent{
+		AnalysisID:   analysis.ID,
+		Status:       analysis.Status,
+		Stage:        analysis.Stage,
+		ErrorCode:    analysis.ErrorCode,
+		ErrorMessage: analysis.ErrorMessage,
+		Timestamp:    time.Now(),
+	}
+}
+
+// sendProgressEvent escribe un evento SSE y lo envía de inmediato al cliente
+func sendProgressEvent(c *gin.Context, event jobs.ProgressEvent) {
+	c.SSEvent("progress", event)
+	c.Writer.Flush()
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/control"
+	"backend/dsp"
+)
+
+const (
+	// Puntos por defecto de cada respuesta simulada devuelta por el endpoint de lazo cerrado
+	defaultClosedLoopPoints = 500
+	// Orden máximo del numerador y del denominador de un controlador transfer_function
+	maxControllerOrder = 10
+)
+
+// ClosedLoopRequest es el controlador propuesto y los parámetros de la simulación. Se indica
+// exactamente uno de pid, lead_lag o transfer_function.
+type ClosedLoopRequest struct {
+	PID              *control.PIDGains `json:"pid"`
+	LeadLag          *control.LeadLag  `json:"lead_lag"`
+	TransferFunction *struct {
+		Num []float64 `json:"num"`
+		Den []float64 `json:"den"`
+	} `json:"transfer_function"`
+	Reference    *float64 `json:"reference"`     // Amplitud del escalón de referencia (por defecto 1)
+	Disturbance  *float64 `json:"disturbance"`   // Amplitud de la perturbación de carga (por defecto 1)
+	Horizon      float64  `json:"horizon"`       // Duración simulada (s); por defecto según la planta
+	SettlingBand float64  `json:"settling_band"` // Por defecto 0.02
+	Points       int      `json:"points"`        // Puntos de cada respuesta devuelta (por defecto 500)
+}
+
+// controller construye C(s) a partir de la solicitud
+func (r ClosedLoopRequest) controller() (control.TransferFunction, string, error) {
+	specified := 0
+	for _, set := range []bool{r.PID != nil, r.LeadLag != nil, r.TransferFunction != nil} {
+		if set {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return control.TransferFunction{}, "", errors.New("indique exactamente uno de pid, lead_lag o transfer_function")
+	}
+	switch {
+	case r.PID != nil:
+		tf, err := r.PID.TransferFunction()
+		return tf, "pid", err
+	case r.LeadLag != nil:
+		tf, err := r.LeadLag.TransferFunction()
+		return tf, "lead_lag", err
+	default:
+		if len(r.TransferFunction.Num) > maxControllerOrder+1 || len(r.TransferFunction.Den) > maxControllerOrder+1 {
+			return control.TransferFunction{}, "", fmt.Errorf("el orden de transfer_function no puede superar %d", maxControllerOrder)
+		}
+		tf, err := control.NewTransferFunction(r.TransferFunction.Num, r.TransferFunction.Den, 0)
+		return tf, "transfer_function", err
+	}
+}
+
+// SimulateClosedLoopHandler simula el lazo cerrado de la planta identificada con el controlador
+// propuesto por el usuario: la respuesta a un escalón de referencia y a una perturbación de
+// carga en la entrada de la planta, con el esfuerzo de control, los polos del lazo cerrado y
+// las mismas métricas que se calculan sobre la respuesta medida
+func SimulateClosedLoopHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ClosedLoopRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		controller, kind, err := req.controller()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := (control.StepMetricsOptions{SettlingBand: req.SettlingBand}).Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Horizon < 0 || math.IsNaN(req.Horizon) || math.IsInf(req.Horizon, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "horizon debe ser un número positivo"})
+			return
+		}
+		if req.Points == 0 {
+			req.Points = defaultClosedLoopPoints
+		}
+		if req.Points < 2 || req.Points > maxGraphPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "points debe estar entre 2 y " + strconv.Itoa(maxGraphPoints)})
+			return
+		}
+		reference, disturbance := 1.0, 1.0
+		if req.Reference != nil {
+			reference = *req.Reference
+		}
+		if req.Disturbance != nil {
+			disturbance = *req.Disturbance
+		}
+
+		analysis, result, graphData, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+		plant, err := resultPlant(analysis, result, graphData)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo construir el modelo: " + err.Error()})
+			return
+		}
+
+		horizon := req.Horizon
+		if horizon == 0 {
+			horizon = control.ClosedLoopHorizon(plant.Model)
+		}
+		band := (&control.StepMetricsOptions{SettlingBand: req.SettlingBand}).Band()
+
+		prediction, _, err := control.PredictClosedLoop(plant.Model, controller, horizon, band)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo simular el lazo cerrado: " + err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"analysis_id":      analysis.ID,
+			"plant":            plant.Model,
+			"controller_type":  kind,
+			"controller":       controller,
+			"horizon":          horizon,
+			"stable":           prediction.Stable,
+			"poles":            prediction.Poles,
+			"gain_margin_db":   prediction.GainMargin,
+			"phase_margin_deg": prediction.PhaseMargin,
+		}
+
+		// Seguimiento de la referencia
+		if reference != 0 {
+			step, err := control.SimulateClosedLoop(plant.Model, controller, horizon, reference, 0)
+			if err != nil {
+				response["step_error"] = err.Error()
+			} else {
+				response["step_response"] = decimateClosedLoop(step, req.Points)
+				response["step_effort"] = controlEffort(step)
+				if prediction.Stable {
+					response["step_metrics"] = extractPerformanceMetrics(step.Time, step.Output, reference, band, nil)
+				}
+			}
+		}
+
+		// Rechazo de la perturbación de carga con referencia nula
+		if disturbance != 0 {
+			load, err := control.SimulateClosedLoop(plant.Model, controller, horizon, 0, disturbance)
+			if err != nil {
+				response["disturbance_error"] = err.Error()
+			} else {
+				response["disturbance_response"] = decimateClosedLoop(load, req.Points)
+				response["disturbance_effort"] = controlEffort(load)
+				if prediction.Stable {
+					if metrics, err := control.DisturbanceResponseMetrics(load.Time, load.Output, 0, band); err == nil {
+						response["disturbance_metrics"] = metrics
+					}
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// controlEffort resume el esfuerzo de control de una simulación
+func controlEffort(r *control.ClosedLoopResponse) gin.H {
+	var peak, energy float64
+	for i, u := range r.Control {
+		peak = math.Max(peak, math.Abs(u))
+		if i > 0 {
+			energy += (r.Time[i] - r.Time[i-1]) * (r.Control[i-1]*r.Control[i-1] + u*u) / 2
+		}
+	}
+	return gin.H{
+		"max_abs": peak,
+		"initial": r.Control[0],
+		"final":   r.Control[len(r.Control)-1],
+		"energy":  energy, // ∫u² dt
+	}
+}
+
+// decimateClosedLoop reduce la respuesta simulada conservando los puntos que LTTB elige en la
+// salida y en el esfuerzo de control, para no perder el pico de la acción derivativa
+func decimateClosedLoop(r *control.ClosedLoopResponse, points int) *control.ClosedLoopResponse {
+	seen := map[int]bool{}
+	var indices []int
+	for _, series := range [][]float64{r.Output, r.Control} {
+		for _, i := range dsp.LTTB(r.Time, series, points) {
+			if !seen[i] {
+				seen[i] = true
+				indices = append(indices, i)
+			}
+		}
+	}
+	sort.Ints(indices)
+	return &control.ClosedLoopResponse{
+		Time:    pickIndices(r.Time, indices),
+		Output:  pickIndices(r.Output, indices),
+		Control: pickIndices(r.Control, indices),
+	}
+}
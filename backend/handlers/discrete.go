@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"gorm.io/datatypes"
+
+	"backend/control"
+	"backend/models"
+	"backend/parser"
+)
+
+// identifyStepDiscreteModel identifica el modelo discreto sobre la serie completa, que conserva
+// el tramo anterior al escalón, con la entrada reconstruida a partir del instante del escalón
+func identifyStepDiscreteModel(series *parser.Series, options *control.DiscreteOptions, deadTime *control.DeadTimeEstimate, inputVoltage float64) (*control.DiscreteIdentification, *models.DiscreteModel, error) {
+	if inputVoltage == 0 {
+		inputVoltage = 1
+	}
+	u := make([]float64, len(series.Time))
+	for i, ti := range series.Time {
+		if ti >= deadTime.StepTime {
+			u[i] = inputVoltage
+		}
+	}
+	t, y, u := averageBlocks(series.Time, series.Output, u, maxFitPoints)
+	return identifyDiscreteModel(t, y, u, options, deadTime.DeadTime, false)
+}
+
+// identifyDiscreteModel identifica el modelo discreto pedido en las opciones y arma el registro
+// que se guarda con el resultado. Las series deben estar muestreadas de forma uniforme; se
+// trabaja con desviaciones respecto al nivel inicial o, si removeMean, respecto a la media
+// (entradas medidas que no parten del reposo). deadTime (s) centra la búsqueda del retardo.
+func identifyDiscreteModel(t, y, u []float64, options *control.DiscreteOptions, deadTime float64, removeMean bool) (*control.DiscreteIdentification, *models.DiscreteModel, error) {
+	if len(t) < 3 || len(y) != len(t) || len(u) != len(t) {
+		return nil, nil, errors.New("se necesitan al menos 3 muestras de entrada y salida")
+	}
+	ts := (t[len(t)-1] - t[0]) / float64(len(t)-1)
+
+	y0, _ := control.StepLevels(y)
+	u0 := u[0]
+	if removeMean {
+		y0, u0 = calculateMean(y), calculateMean(u)
+	}
+	dy, du := make([]float64, len(y)), make([]float64, len(u))
+	for i := range y {
+		dy[i], du[i] = y[i]-y0, u[i]-u0
+	}
+
+	delayGuess := int(math.Round(deadTime/ts)) + 1
+	id, err := control.IdentifyDiscrete(dy, du, ts, *options, delayGuess)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := id.Model
+	a, _ := json.Marshal(m.A)
+	b, _ := json.Marshal(m.B)
+	tf, _ := json.Marshal(m.TransferFunction())
+	candidates, _ := json.Marshal(id.Candidates)
+	record := &models.DiscreteModel{
+		Structure:        m.Structure,
+		Na:               m.Na,
+		Nb:               m.Nb,
+		Nc:               m.Nc,
+		Nk:               m.Nk,
+		SamplingPeriod:   m.SamplingPeriod,
+		A:                datatypes.JSON(a),
+		B:                datatypes.JSON(b),
+		TransferFunction: datatypes.JSON(tf),
+		NoiseVariance:    m.NoiseVariance,
+		AIC:              m.AIC,
+		BIC:              m.BIC,
+		FitPercent:       m.FitPercent,
+		Criterion:        id.Criterion,
+		Candidates:       datatypes.JSON(candidates),
+	}
+	if m.C != nil {
+		c, _ := json.Marshal(m.C)
+		record.C = datatypes.JSON(c)
+	}
+	if continuous, err := m.TransferFunction().Continuous(control.ConversionZOH); err == nil {
+		data, _ := json.Marshal(continuous)
+		record.ContinuousZOH = datatypes.JSON(data)
+	}
+	if continuous, err := m.TransferFunction().Continuous(control.ConversionTustin); err == nil {
+		data, _ := json.Marshal(continuous)
+		record.ContinuousTustin = datatypes.JSON(data)
+	}
+	return id, record, nil
+}
+
+// discreteModelSummary resume el modelo discreto elegido y la comparación de órdenes
+func discreteModelSummary(id *control.DiscreteIdentification) map[string]interface{} {
+	m := id.Model
+	orders := make([]map[string]interface{}, len(id.Candidates))
+	for i, c := range id.Candidates {
+		orders[i] = map[string]interface{}{
+			"na":                c.Na,
+			"nb":                c.Nb,
+			"nk":                c.Nk,
+			"aic":               c.AIC,
+			"bic":               c.BIC,
+			"porcentaje_ajuste": c.FitPercent,
+		}
+		if m.Structure == control.DiscreteARMAX {
+			orders[i]["nc"] = c.Nc
+		}
+	}
+	summary := map[string]interface{}{
+		"estructura":        m.Structure,
+		"na":                m.Na,
+		"nb":                m.Nb,
+		"nk":                m.Nk,
+		"periodo_muestreo":  m.SamplingPeriod,
+		"criterio":          id.Criterion,
+		"aic":               m.AIC,
+		"bic":               m.BIC,
+		"varianza_ruido":    m.NoiseVariance,
+		"porcentaje_ajuste": m.FitPercent,
+		"comparacion":       orders,
+	}
+	if m.Structure == control.DiscreteARMAX {
+		summary["nc"] = m.Nc
+	}
+	return summary
+}
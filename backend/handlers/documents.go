@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UploadDocumentHandler guarda un archivo después de leerlo y validar la señal. Si la señal
+// no puede analizarse responde 422 con los motivos, la vista previa y el reporte de lectura.
 func UploadDocumentHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Obtener ID del usuario del contexto (si está autenticado)
@@ -23,34 +26,40 @@ func UploadDocumentHandler() gin.HandlerFunc {
 			userID = &id
 		}
 
-		// Limitar el tamaño máximo de la petición
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MaxFileSize)
+		// Leer el archivo del formulario y validar la señal antes de guardarla
+		upload, validation, ok := readSignalUpload(c)
+		if !ok {
+			return
+		}
+		if !validation.Valid {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      signalProblemsMessage(validation),
+				"validation": validation,
+			})
+			return
+		}
 
-		// Obtener el archivo del formulario
-		file, header, err := c.Request.FormFile("file")
+		// Crear el backend de almacenamiento configurado
+		storage, err := utils.NewStorage()
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error al obtener el archivo: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error de configuración del almacenamiento: " + err.Error()})
 			return
 		}
-		defer file.Close()
-
-		// Crear un manejador de almacenamiento Cloudinary
-		cloudStorage := utils.NewCloudinaryStorage()
 
-		// Subir el archivo a Cloudinary
-		fileURL, err := cloudStorage.UploadFile(file, header)
+		// Subir el archivo al almacenamiento
+		fileRef, err := storage.Put(upload.filename, bytes.NewReader(upload.data))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al subir el archivo: " + err.Error()})
 			return
 		}
 
-		log.Printf("Archivo subido exitosamente a Cloudinary: %s", fileURL)
+		log.Printf("Archivo subido exitosamente: %s", fileRef)
 
 		// Crear documento en la base de datos
 		document := models.Document{
 			UserID:           userID,
-			FilePath:         fileURL, // Guardamos la URL de Cloudinary
-			OriginalFilename: header.Filename,
+			FilePath:         fileRef, // Referencia del archivo en el almacenamiento
+			OriginalFilename: upload.filename,
 			UploadDate:       time.Now(),
 			IsDeleted:        false,
 		}
@@ -61,14 +70,34 @@ func UploadDocumentHandler() gin.HandlerFunc {
 			return
 		}
 
+		// Guardar la serie leída para que los análisis no vuelvan a descargar el archivo; si
+		// falla, el primer análisis la leerá del archivo
+		stored := models.NewDocumentSeries(document.ID, upload.options, upload.series, upload.report)
+		if err := database.DB.Create(stored).Error; err != nil {
+			log.Printf("No se pudo guardar la serie del documento %d: %v", document.ID, err)
+		}
+
 		// Preparar la respuesta
 		response := document.ToDocumentResponse(0) // Nuevo documento, sin análisis aún
+		response.Preview = validation.Preview
 
 		// Enviar respuesta exitosa
 		c.JSON(http.StatusCreated, response)
 	}
 }
 
+// ValidateDocumentHandler valida un archivo como UploadDocumentHandler sin guardarlo y
+// devuelve los problemas encontrados, la vista previa y el reporte de lectura
+func ValidateDocumentHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, validation, ok := readSignalUpload(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, validation)
+	}
+}
+
 func GetUserDocumentsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Obtener ID del usuario del contexto de Gin
@@ -163,12 +192,19 @@ func PermanentDeleteDocumentHandler() gin.HandlerFunc {
 			return
 		}
 
-		// Guardar la URL para eliminar el archivo después
-		fileURL := document.FilePath
+		// Guardar la referencia para eliminar el archivo después
+		fileRef := document.FilePath
 
 		// Iniciar transacción
 		tx := database.DB.Begin()
 
+		// Eliminar los modelos discretos de los resultados de este documento
+		if err := tx.Where("result_id IN (SELECT r.id FROM results r JOIN analysis_requests a ON a.id = r.analysis_request_id WHERE a.document_id = ?)", document.ID).Delete(&models.DiscreteModel{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar modelos discretos asociados: " + err.Error()})
+			return
+		}
+
 		// Eliminar todos los resultados asociados con análisis de este documento
 		if err := tx.Where("analysis_request_id IN (SELECT id FROM analysis_requests WHERE document_id = ?)", document.ID).Delete(&models.Result{}).Error; err != nil {
 			tx.Rollback()
@@ -176,6 +212,20 @@ func PermanentDeleteDocumentHandler() gin.HandlerFunc {
 			return
 		}
 
+		// Eliminar los trabajos de la cola asociados con análisis de este documento
+		if err := tx.Where("analysis_request_id IN (SELECT id FROM analysis_requests WHERE document_id = ?)", document.ID).Delete(&models.AnalysisJob{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar trabajos de análisis asociados: " + err.Error()})
+			return
+		}
+
+		// Eliminar las series guardadas del documento
+		if err := tx.Where("document_id = ?", document.ID).Delete(&models.DocumentSeries{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar las series del documento: " + err.Error()})
+			return
+		}
+
 		// Eliminar todos los análisis asociados con este documento
 		if err := tx.Where("document_id = ?", document.ID).Delete(&models.AnalysisRequest{}).Error; err != nil {
 			tx.Rollback()
@@ -196,11 +246,12 @@ func PermanentDeleteDocumentHandler() gin.HandlerFunc {
 			return
 		}
 
-		// Eliminar el archivo de Cloudinary
-		cloudStorage := utils.NewCloudinaryStorage()
-		if err := cloudStorage.DeleteFile(fileURL); err != nil {
+		// Eliminar el archivo del almacenamiento
+		if storage, err := utils.NewStorage(); err != nil {
+			log.Printf("Error de configuración del almacenamiento: %v", err)
+		} else if err := storage.Delete(fileRef); err != nil {
 			// Solo registrar el error, pero no fallar la respuesta
-			log.Printf("Error al eliminar archivo de Cloudinary %s: %v", fileURL, err)
+			log.Printf("Error al eliminar archivo %s: %v", fileRef, err)
 		}
 
 		// Enviar respuesta exitosa
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+
+	"backend/control"
+	"backend/database"
+	"backend/dsp"
+	"backend/jobs"
+	"backend/models"
+)
+
+// equivalentStepPoints es la cantidad de muestras de la respuesta al escalón equivalente
+const equivalentStepPoints = 500
+
+// processExcitationAnalysis identifica un modelo de segundo orden con retardo a partir de la
+// entrada realmente aplicada (impulso, rampa o entrada medida como PRBS o chirp) y calcula las
+// métricas temporales sobre la respuesta al escalón unitario equivalente del modelo
+func processExcitationAnalysis(analysis *models.AnalysisRequest, signal *analysisSignal) error {
+	analysisID := analysis.ID
+	series, report, preprocessing := signal.series, signal.report, signal.preprocessing
+	excitation := signal.options.Excitation
+	timeData, outputData, inputData, samplingPeriod := series.Time, series.Output, series.Input, series.SamplingPeriod
+
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStageOptimizing)
+
+	// Reducir la cantidad de muestras promediando por bloques (filtro antialiasing)
+	fitTime, fitOutput, fitInput := averageBlocks(timeData, outputData, inputData, maxFitPoints)
+
+	// Construir la señal de entrada
+	var input []float64
+	var startTime float64
+	switch excitation.Type {
+	case models.ExcitationMeasured:
+		input = fitInput
+		startTime = fitTime[0]
+	case models.ExcitationImpulse, models.ExcitationRamp:
+		startTime = detectResponseOnset(fitTime, fitOutput)
+		if excitation.StartTime != nil {
+			startTime = *excitation.StartTime
+		}
+		if startTime < fitTime[0] || startTime >= fitTime[len(fitTime)-1] {
+			return jobs.Permanent(jobs.ErrCodeAnalysisFailed, "El instante de aplicación de la entrada está fuera del rango de tiempo del archivo", nil)
+		}
+		input = synthesizeInput(excitation.Type, fitTime, startTime, analysis.InputVoltage)
+	}
+
+	log.Printf("Análisis %d con excitación %s: %d muestras para el ajuste, inicio en t=%f", analysisID, excitation.Type, len(fitTime), startTime)
+
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStageIdentifying)
+
+	structure := control.ModelStructure{NumOrder: 0, DenOrder: 2, Delay: true}
+	fit, err := control.FitTransferFunctionInput(fitTime, input, fitOutput, structure)
+	if err != nil {
+		return jobs.Permanent(jobs.ErrCodeAnalysisFailed, "No se pudo identificar el modelo con la entrada aplicada: "+err.Error(), err)
+	}
+	log.Printf("Modelo con excitación %s: polos=%v, K=%f, θ=%f, rms=%f", excitation.Type, fit.Poles, fit.DCGain, fit.Model.Delay, fit.ResidualRMS)
+
+	// Interpretación de segundo orden (solo si el modelo ajustado es estable)
+	analyticModel, err := control.SecondOrderFromFit(fit)
+	if err != nil {
+		log.Printf("No se pudo interpretar el modelo como segundo orden: %v", err)
+	}
+	systemType := "desconocido"
+	poles := polesToMaps(fit.Poles)
+	if analyticModel != nil {
+		systemType = analyticSystemType(analyticModel)
+		poles = polesToMaps(analyticModel.Poles)
+	}
+
+	// Validación: simular el modelo con la entrada real y comparar con la salida medida
+	simulated := simulateWithInput(fit, fitTime, input)
+	var modelValidation map[string]interface{}
+	if quality, err := control.EvaluateFit(fitOutput, simulated); err != nil {
+		log.Printf("No se pudo validar el modelo: %v", err)
+	} else {
+		modelValidation = fitQualitySummary(quality)
+		modelValidation["ganancia"] = fit.DCGain
+		modelValidation["retardo"] = fit.Model.Delay
+		log.Printf("Validación del modelo: ajuste=%.2f%%, R2=%.4f", quality.FitPercent, quality.RSquared)
+	}
+
+	// Gráfica: salida, entrada y simulación en los instantes que LTTB elige sobre la salida
+	indices := dsp.LTTB(fitTime, fitOutput, defaultGraphPoints)
+	graphData := models.GraphData{
+		Time:      pickIndices(fitTime, indices),
+		Output:    pickIndices(fitOutput, indices),
+		Input:     pickIndices(input, indices),
+		Simulated: pickIndices(simulated, indices),
+	}
+
+	rawData := map[string]interface{}{
+		"excitacion":         excitation.Type,
+		"voltaje_entrada":    analysis.InputVoltage,
+		"puntos_originales":  len(timeData),
+		"puntos_optimizados": len(fitTime),
+		"sampling_period":    samplingPeriod,
+		"tiempo_inicial":     fitTime[0],
+		"tiempo_final":       fitTime[len(fitTime)-1],
+		"valor_inicial":      fitOutput[0],
+		"valor_final":        fitOutput[len(fitOutput)-1],
+		"inicio_excitacion":  startTime,
+		"tiempo_muerto":      fit.Model.Delay,
+		"ml_omitido":         "los modelos ML están entrenados con respuestas al escalón",
+		"lectura":            report,
+	}
+	addInstrumentData(rawData, report)
+	addPreprocessingData(rawData, preprocessing)
+	if excitation.Type == models.ExcitationMeasured {
+		rawData["columna_entrada"] = excitation.Column()
+	}
+
+	// Métricas temporales sobre la respuesta al escalón unitario equivalente del modelo
+	for key, value := range equivalentStepMetrics(fit.Model, fitTime[len(fitTime)-1]-fitTime[0], signal.options.Metrics.Band()) {
+		rawData[key] = value
+	}
+
+	// Modelo discreto ARX/ARMAX/OE, si se pidió, con la misma entrada
+	var discreteModel *control.DiscreteIdentification
+	var discreteRecord *models.DiscreteModel
+	if signal.options.Discrete != nil {
+		discreteModel, discreteRecord, err = identifyDiscreteModel(fitTime, fitOutput, input, signal.options.Discrete, fit.Model.Delay, excitation.Type == models.ExcitationMeasured)
+		if err != nil {
+			log.Printf("No se pudo identificar el modelo discreto: %v", err)
+		}
+	}
+
+	jobs.ReportStage(database.DB, analysisID, models.AnalysisStagePersisting)
+
+	description := generateSystemDescription(systemType, rawData, poles, analysis.InputVoltage)
+
+	technicalSummary := generateTechnicalSummary(rawData, poles)
+	technicalSummary["excitacion"] = excitationSummary(excitation, analysis.InputVoltage, startTime)
+	technicalSummary["modelo_ajustado"] = fit
+	if analyticModel != nil {
+		technicalSummary["modelo_analitico"] = analyticModelSummary(analyticModel, nil, nil, nil, nil)
+	}
+	if modelValidation != nil {
+		technicalSummary["validacion_modelo"] = modelValidation
+	}
+	if margins, err := control.Margins(fit.Model); err == nil {
+		technicalSummary["margenes_estabilidad"] = frequencySummary(margins)
+	}
+	if discreteModel != nil {
+		technicalSummary["modelo_discreto"] = discreteModelSummary(discreteModel)
+	}
+
+	// Los ceros solo se guardan con los polos del modelo ajustado, no con los de segundo orden
+	polesData := map[string]interface{}{"polos": poles}
+	if analyticModel == nil && len(fit.Zeros) > 0 {
+		polesData["ceros"] = polesToMaps(fit.Zeros)
+	}
+	polesJSON, _ := json.Marshal(polesData)
+	rawDataJSON, _ := json.Marshal(rawData)
+	graphDataJSON, _ := json.Marshal(graphData)
+	technicalSummaryJSON, _ := json.Marshal(technicalSummary)
+
+	result := models.Result{
+		AnalysisRequestID: analysisID,
+		SystemType:        systemType,
+		Description:       description,
+		Poles:             datatypes.JSON(polesJSON),
+		RawData:           datatypes.JSON(rawDataJSON),
+		GraphData:         datatypes.JSON(graphDataJSON),
+		TechnicalSummary:  datatypes.JSON(technicalSummaryJSON),
+		IsLatest:          true,
+		CreatedAt:         time.Now(),
+		DeadTime:          &fit.Model.Delay,
+		StepTime:          &startTime,
+		DiscreteModel:     discreteRecord,
+	}
+	if analyticModel != nil {
+		analyticModelJSON, _ := json.Marshal(analyticModel)
+		result.AnalyticModel = datatypes.JSON(analyticModelJSON)
+		result.FitPolo1Real = &analyticModel.Poles[0].Real
+		result.FitPolo1Imag = &analyticModel.Poles[0].Imag
+		result.FitPolo2Real = &analyticModel.Poles[1].Real
+		result.FitPolo2Imag = &analyticModel.Poles[1].Imag
+	}
+
+	if err := saveAnalysisResult(analysisID, analysis.DocumentID, &result); err != nil {
+		return err
+	}
+
+	log.Printf("Análisis %d con excitación %s completado exitosamente", analysisID, excitation.Type)
+	return nil
+}
+
+// averageBlocks promedia bloques consecutivos de muestras para no superar maxPoints.
+// input puede ser nil (entrada sintetizada), en cuyo caso se devuelve nil.
+func averageBlocks(t, y, u []float64, maxPoints int) ([]float64, []float64, []float64) {
+	block := (len(t) + maxPoints - 1) / maxPoints
+	if block <= 1 {
+		return t, y, u
+	}
+
+	var outT, outY, outU []float64
+	for start := 0; start < len(t); start += block {
+		end := start + block
+		if end > len(t) {
+			end = len(t)
+		}
+		outT = append(outT, calculateMean(t[start:end]))
+		outY = append(outY, calculateMean(y[start:end]))
+		if u != nil {
+			outU = append(outU, calculateMean(u[start:end]))
+		}
+	}
+	return outT, outY, outU
+}
+
+// detectResponseOnset estima el instante en que se aplicó la entrada: localiza el primer
+// apartamiento claro de la salida respecto a su nivel inicial (2% del rango), retrocede hasta
+// el nivel de ruido de las primeras muestras y adelanta el resultado otro tanto, porque la
+// respuesta a un impulso o una rampa emerge del ruido con retraso. Un inicio anticipado lo
+// absorbe el retardo del modelo; uno tardío no.
+func detectResponseOnset(t, y []float64) float64 {
+	head := len(y) / 50
+	if head < 5 {
+		head = 5
+	}
+	if head > len(y) {
+		head = len(y)
+	}
+	initial := calculateMean(y[:head])
+	noise := 3 * calculateStandardDeviation(y[:head])
+	threshold := math.Max(0.02*(calculateMax(y)-calculateMin(y)), noise)
+
+	for i, v := range y {
+		if math.Abs(v-initial) <= threshold {
+			continue
+		}
+		j := i
+		for j > 0 && math.Abs(y[j-1]-initial) > noise {
+			j--
+		}
+		if j == 0 {
+			return t[0]
+		}
+		return math.Max(t[0], t[j-1]-(t[i]-t[j]))
+	}
+	return t[0]
+}
+
+// synthesizeInput genera la entrada retenida entre muestras: un pulso de una muestra con el
+// área indicada (impulso) o una rampa de la pendiente indicada, ambos aplicados en start
+func synthesizeInput(kind string, t []float64, start, amplitude float64) []float64 {
+	u := make([]float64, len(t))
+
+	// Última muestra anterior o igual al inicio
+	k := 0
+	for k+1 < len(t) && t[k+1] <= start {
+		k++
+	}
+
+	switch kind {
+	case models.ExcitationImpulse:
+		u[k] = amplitude / (t[k+1] - t[k])
+	case models.ExcitationRamp:
+		for i := k + 1; i < len(t); i++ {
+			u[i] = amplitude * (t[i] - start)
+		}
+	}
+	return u
+}
+
+// simulateWithInput simula el modelo ajustado ante la entrada dada, partiendo del nivel
+// inicial de la salida
+func simulateWithInput(fit *control.TransferFunctionFit, t, u []float64) []float64 {
+	du := make([]float64, len(u))
+	for i := range u {
+		du[i] = u[i] - u[0]
+	}
+	y := fit.Model.Lsim(t, du)
+	for i := range y {
+		y[i] += fit.InitialValue
+	}
+	return y
+}
+
+// equivalentStepMetrics calcula las métricas temporales sobre la respuesta del modelo a un
+// escalón unitario, en un horizonte que cubre el experimento y el establecimiento del modelo
+func equivalentStepMetrics(tf control.TransferFunction, duration, settlingBand float64) map[string]interface{} {
+	horizon := duration
+	slowest := math.Inf(1)
+	for _, p := range tf.Poles() {
+		slowest = math.Min(slowest, math.Abs(real(p)))
+	}
+	if slowest > 0 && !math.IsInf(slowest, 1) {
+		horizon = math.Max(horizon, tf.Delay+10/slowest)
+	}
+
+	t := make([]float64, equivalentStepPoints)
+	for i := range t {
+		t[i] = horizon * float64(i) / float64(equivalentStepPoints-1)
+	}
+	y := tf.StepResponse(t)
+
+	// El valor final del escalón unitario es la ganancia estática del modelo
+	gain := tf.DCGain()
+	finalValue := &control.FinalValueEstimate{FinalValue: gain, TailAverage: y[len(y)-1], Stationary: true, Method: control.FinalValueModel, DCGain: gain}
+	metrics := extractPerformanceMetrics(t, y, 1, settlingBand, finalValue)
+	metrics["metricas_escalon_equivalente"] = true
+	return metrics
+}
+
+// excitationSummary resume la entrada aplicada para el resumen técnico
+func excitationSummary(excitation *models.ExcitationOptions, amplitude, startTime float64) map[string]interface{} {
+	summary := map[string]interface{}{
+		"tipo":   excitation.Type,
+		"inicio": startTime,
+	}
+	switch excitation.Type {
+	case models.ExcitationImpulse:
+		summary["area"] = amplitude
+	case models.ExcitationRamp:
+		summary["pendiente"] = amplitude
+	case models.ExcitationMeasured:
+		summary["columna_entrada"] = excitation.Column()
+	}
+	if excitation.StartTime == nil && excitation.Type != models.ExcitationMeasured {
+		summary["inicio_detectado"] = true
+	}
+	return summary
+}
+
+// excitationDescription describe la entrada aplicada en el análisis
+func excitationDescription(rawData map[string]interface{}, inputVoltage float64) string {
+	switch rawData["excitacion"] {
+	case models.ExcitationImpulse:
+		return fmt.Sprintf("Análisis realizado con un impulso de área %.3g; las métricas corresponden a la respuesta al escalón unitario del modelo identificado.", inputVoltage)
+	case models.ExcitationRamp:
+		return fmt.Sprintf("Análisis realizado con una rampa de pendiente %.3g/s; las métricas corresponden a la respuesta al escalón unitario del modelo identificado.", inputVoltage)
+	case models.ExcitationMeasured:
+		return fmt.Sprintf("Análisis realizado con la entrada medida en la columna %v del archivo; las métricas corresponden a la respuesta al escalón unitario del modelo identificado.", rawData["columna_entrada"])
+	}
+	return fmt.Sprintf("Análisis realizado con voltaje de entrada de %.1fV.", inputVoltage)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/control"
+	"backend/models"
+)
+
+// TransferFunctionFitRequest es la estructura del modelo solicitada para el ajuste
+type TransferFunctionFitRequest struct {
+	NumOrder int  `json:"num_order"`
+	DenOrder int  `json:"den_order" binding:"required"`
+	Delay    bool `json:"delay"`
+}
+
+// FitTransferFunctionHandler vuelve a ajustar un análisis almacenado con una función de
+// transferencia de la estructura indicada, sin necesidad de volver a subir el archivo
+func FitTransferFunctionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TransferFunctionFitRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		structure := control.ModelStructure{
+			NumOrder: req.NumOrder,
+			DenOrder: req.DenOrder,
+			Delay:    req.Delay,
+		}
+		if err := structure.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		analysis, result, _, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+		if analysis.Mode == models.AnalysisModeSpectral {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "El análisis espectral no identifica una función de transferencia"})
+			return
+		}
+
+		// El ajuste usa la serie a resolución completa, no la gráfica reducida
+		signal, err := loadAnalysisSignal(analysis, nil)
+		if err != nil {
+			respondSignalError(c, err)
+			return
+		}
+		// Con excitaciones distintas del escalón se ajusta con la entrada medida o sintetizada
+		// que usó el análisis, como en processExcitationAnalysis
+		series := analysisGraph(analysis, signal, result)
+		step := signal.options.ExcitationType() == models.ExcitationStep
+		if !step && len(series.Input) != len(series.Time) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo reconstruir la entrada aplicada en el análisis"})
+			return
+		}
+		fitTime, fitOutput, fitInput := averageBlocks(series.Time, series.Output, series.Input, maxFitPoints)
+
+		var fit *control.TransferFunctionFit
+		if step {
+			fit, err = control.FitTransferFunction(fitTime, fitOutput, analysis.InputVoltage, structure)
+		} else {
+			fit, err = control.FitTransferFunctionInput(fitTime, fitInput, fitOutput, structure)
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo ajustar el modelo: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"analysis_id": analysis.ID,
+			"fit":         fit,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/control"
+	"backend/models"
+)
+
+// Límites de la malla de frecuencias del endpoint de respuesta en frecuencia
+const (
+	defaultFrequencyPoints = 200
+	maxFrequencyPoints     = 2000
+)
+
+// GetFrequencyResponseHandler devuelve los datos de Bode y Nyquist del modelo identificado
+// y sus márgenes de estabilidad. Parámetros opcionales: wmin, wmax (rad/s) y points.
+func GetFrequencyResponseHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		analysis, result, graphData, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+
+		tf, err := resultTransferFunction(analysis, result, graphData)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo construir el modelo: " + err.Error()})
+			return
+		}
+
+		wmin, wmax := control.DefaultFrequencyRange(tf)
+		points := defaultFrequencyPoints
+		minQuery, ok := optionalFloatQuery(c, "wmin")
+		if !ok {
+			return
+		}
+		maxQuery, ok := optionalFloatQuery(c, "wmax")
+		if !ok {
+			return
+		}
+		if minQuery != nil {
+			if wmin = *minQuery; wmin <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "wmin debe ser un número positivo"})
+				return
+			}
+		}
+		if maxQuery != nil {
+			if wmax = *maxQuery; wmax <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "wmax debe ser un número positivo"})
+				return
+			}
+		}
+		if wmax <= wmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wmax debe ser mayor que wmin"})
+			return
+		}
+		if v := c.Query("points"); v != "" {
+			if points, err = strconv.Atoi(v); err != nil || points < 2 || points > maxFrequencyPoints {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "points debe estar entre 2 y " + strconv.Itoa(maxFrequencyPoints)})
+				return
+			}
+		}
+
+		margins, err := control.Margins(tf)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"analysis_id": analysis.ID,
+			"model":       tf,
+			"wmin":        wmin,
+			"wmax":        wmax,
+			"response":    control.Bode(tf, control.LogSpace(wmin, wmax, points)),
+			"margins":     margins,
+		})
+	}
+}
+
+// resultTransferFunction reconstruye G(s) de un resultado: del ajuste analítico de segundo
+// orden si existe y, si no, de los polos (y ceros, si los hay) almacenados
+func resultTransferFunction(analysis *models.AnalysisRequest, result *models.Result, graphData *models.GraphData) (control.TransferFunction, error) {
+	if len(result.AnalyticModel) > 0 {
+		var m control.SecondOrderModel
+		if err := json.Unmarshal(result.AnalyticModel, &m); err == nil {
+			if tf, err := m.TransferFunction(); err == nil {
+				return tf, nil
+			}
+		}
+	}
+
+	var polesData struct {
+		Polos []map[string]float64 `json:"polos"`
+		Ceros []map[string]float64 `json:"ceros"`
+	}
+	if err := json.Unmarshal(result.Poles, &polesData); err != nil || len(polesData.Polos) == 0 {
+		return control.TransferFunction{}, errors.New("el resultado no contiene polos")
+	}
+	return identifiedTransferFunction(polesData.Ceros, polesData.Polos, modelGain(graphData.Output, nil, analysis.InputVoltage))
+}
+
+// frequencySummary resume los márgenes de estabilidad para el resumen técnico
+func frequencySummary(m *control.StabilityMargins) map[string]interface{} {
+	summary := map[string]interface{}{}
+	if m.DCGainDB != nil {
+		summary["ganancia_estatica_db"] = *m.DCGainDB
+	}
+	if m.GainMarginDB != nil {
+		summary["margen_ganancia_db"] = *m.GainMarginDB
+		summary["frecuencia_cruce_fase"] = *m.PhaseCrossoverW
+	}
+	if m.PhaseMarginDeg != nil {
+		summary["margen_fase_grados"] = *m.PhaseMarginDeg
+		summary["frecuencia_cruce_ganancia"] = *m.GainCrossoverW
+	}
+	if m.BandwidthW != nil {
+		summary["ancho_banda"] = *m.BandwidthW
+	}
+	if m.ResonantPeakDB != nil {
+		summary["pico_resonancia_db"] = *m.ResonantPeakDB
+		summary["frecuencia_resonancia"] = *m.ResonantFrequency
+	}
+	if m.StableClosedLoop != nil {
+		summary["estable_lazo_cerrado"] = *m.StableClosedLoop
+	}
+	return summary
+}
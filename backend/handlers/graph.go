@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/dsp"
+	"backend/jobs"
+	"backend/models"
+)
+
+// Resolución de las gráficas
+const (
+	defaultGraphPoints        = 300   // Puntos de la gráfica guardada en el resultado
+	defaultGraphRequestPoints = 1000  // Puntos por defecto de GET /analysis/:id/graph
+	maxGraphPoints            = 50000 // Máximo de puntos que puede pedir el cliente
+	maxFitPoints              = 20000 // Muestras usadas en la identificación (promediado por bloques)
+)
+
+// Métodos de decimación de las gráficas
+const (
+	DecimationLTTB = "lttb" // Largest-Triangle-Three-Buckets (por defecto)
+	DecimationRDP  = "rdp"  // Ramer–Douglas–Peucker
+)
+
+// GetAnalysisGraphHandler devuelve la gráfica de un análisis con la resolución pedida
+// (?points=, por defecto 1000), el método de decimación (?method=lttb o rdp) y,
+// opcionalmente, solo el intervalo de tiempo [start, end] en segundos para ampliar una zona a
+// resolución completa. La serie se reconstruye a resolución completa desde la serie guardada
+// del documento con las mismas opciones de lectura y preprocesamiento del análisis; la
+// respuesta simulada se interpola de la guardada en el resultado.
+func GetAnalysisGraphHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		points := defaultGraphRequestPoints
+		if raw := c.Query("points"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 2 || n > maxGraphPoints {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "points debe ser un entero entre 2 y " + strconv.Itoa(maxGraphPoints)})
+				return
+			}
+			points = n
+		}
+		start, ok := optionalFloatQuery(c, "start")
+		if !ok {
+			return
+		}
+		end, ok := optionalFloatQuery(c, "end")
+		if !ok {
+			return
+		}
+		if start != nil && end != nil && *end <= *start {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end debe ser mayor que start"})
+			return
+		}
+		method := c.DefaultQuery("method", DecimationLTTB)
+		if method != DecimationLTTB && method != DecimationRDP {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "method debe ser lttb o rdp"})
+			return
+		}
+
+		analysis, result, stored, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+
+		signal, err := loadAnalysisSignal(analysis, nil)
+		if err != nil {
+			respondSignalError(c, err)
+			return
+		}
+		full := analysisGraph(analysis, signal, result)
+		lo, hi := models.SeriesRange(full.Time, start, end)
+		if hi-lo == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No hay muestras en el intervalo pedido"})
+			return
+		}
+		full.Time, full.Output = full.Time[lo:hi], full.Output[lo:hi]
+		if len(full.Input) >= hi {
+			full.Input = full.Input[lo:hi]
+		}
+
+		indices := decimationIndices(full.Time, full.Output, points, method)
+		graph := models.GraphData{
+			Time:   pickIndices(full.Time, indices),
+			Output: pickIndices(full.Output, indices),
+		}
+		if len(full.Input) == len(full.Time) {
+			graph.Input = pickIndices(full.Input, indices)
+		}
+		if len(stored.Simulated) == len(stored.Time) && len(stored.Simulated) > 0 {
+			graph.Simulated = interpolateAt(stored.Time, stored.Simulated, graph.Time)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"analysis_id":   analysis.ID,
+			"method":        method,
+			"points":        len(indices),
+			"source_points": len(full.Time),
+			"start":         full.Time[0],
+			"end":           full.Time[len(full.Time)-1],
+			"graph_data":    graph,
+		})
+	}
+}
+
+// analysisGraph reconstruye a resolución completa la serie que analizó cada modo: la señal
+// completa en el modo espectral y con excitaciones distintas del escalón (con la entrada
+// medida o sintetizada), y la respuesta recortada con el tiempo corregido en la respuesta al
+// escalón
+func analysisGraph(analysis *models.AnalysisRequest, signal *analysisSignal, result *models.Result) models.GraphData {
+	series, options := signal.series, signal.options
+	graph := models.GraphData{Time: series.Time, Output: series.Output}
+	if analysis.Mode == models.AnalysisModeSpectral {
+		return graph
+	}
+
+	switch excitation := options.ExcitationType(); excitation {
+	case models.ExcitationStep:
+		graph.Time, graph.Output, _ = optimizeDataPoints(series.Time, series.Output, analysis.InputVoltage,
+			series.SamplingPeriod, options.Preprocessing.AutoTrimEnabled())
+	case models.ExcitationMeasured:
+		graph.Input = series.Input
+	default:
+		var rawData struct {
+			Start *float64 `json:"inicio_excitacion"`
+		}
+		if err := json.Unmarshal(result.RawData, &rawData); err == nil && rawData.Start != nil {
+			graph.Input = synthesizeInput(excitation, series.Time, *rawData.Start, analysis.InputVoltage)
+		}
+	}
+	return graph
+}
+
+// optionalFloatQuery lee un parámetro numérico opcional de la URL; si es inválido responde
+// con el error y devuelve ok=false
+func optionalFloatQuery(c *gin.Context, name string) (*float64, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " debe ser un número"})
+		return nil, false
+	}
+	return &value, true
+}
+
+// respondSignalError responde al cliente con el error de loadAnalysisSignal
+func respondSignalError(c *gin.Context, err error) {
+	var jobErr *jobs.Error
+	switch {
+	case !errors.As(err, &jobErr):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer la señal del análisis"})
+	case jobErr.Code == jobs.ErrCodeDocumentNotFound || jobErr.Code == jobs.ErrCodeFileNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": jobErr.Message, "code": jobErr.Code})
+	case jobErr.Retryable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": jobErr.Message, "code": jobErr.Code})
+	default:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": jobErr.Message, "code": jobErr.Code})
+	}
+}
+
+// decimationIndices elige los índices de la serie que se conservan en la gráfica
+func decimationIndices(t, y []float64, points int, method string) []int {
+	if method == DecimationRDP {
+		return dsp.RDP(t, y, points, 0)
+	}
+	return dsp.LTTB(t, y, points)
+}
+
+// decimateSeries reduce una serie a points puntos con LTTB
+func decimateSeries(t, y []float64, points int) ([]float64, []float64) {
+	indices := dsp.LTTB(t, y, points)
+	return pickIndices(t, indices), pickIndices(y, indices)
+}
+
+// pickIndices devuelve los valores de x en los índices indicados
+func pickIndices(x []float64, indices []int) []float64 {
+	out := make([]float64, len(indices))
+	for k, i := range indices {
+		out[k] = x[i]
+	}
+	return out
+}
+
+// interpolateAt interpola linealmente la serie (t, y) en los instantes at (crecientes); fuera
+// del rango se mantiene el valor del extremo
+func interpolateAt(t, y, at []float64) []float64 {
+	out := make([]float64, len(at))
+	j := 0
+	for k, x := range at {
+		for j < len(t)-2 && t[j+1] < x {
+			j++
+		}
+		switch {
+		case len(t) == 1 || x <= t[0]:
+			out[k] = y[0]
+		case x >= t[len(t)-1]:
+			out[k] = y[len(y)-1]
+		default:
+			out[k] = y[j] + (y[j+1]-y[j])*(x-t[j])/(t[j+1]-t[j])
+		}
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"math"
+	"math/cmplx"
+
+	"backend/control"
+	"backend/models"
+	"backend/parser"
+)
+
+// analyticSystemType clasifica el sistema según el factor de amortiguamiento del modelo analítico
+func analyticSystemType(model *control.SecondOrderModel) string {
+	switch {
+	case math.Abs(model.Zeta-1) < 0.02:
+		return "criticamente_amortiguado"
+	case model.Zeta < 1:
+		return "subamortiguado"
+	default:
+		return "sobreamortiguado"
+	}
+}
+
+// polesToMaps convierte polos al formato {"real", "imag"} usado en Result.Poles
+func polesToMaps(poles []control.Complex) []map[string]float64 {
+	maps := make([]map[string]float64, len(poles))
+	for i, p := range poles {
+		maps[i] = map[string]float64{"real": p.Real, "imag": p.Imag}
+	}
+	return maps
+}
+
+// analyticModelSummary resume el modelo analítico y lo compara con los polos del modelo ML (si existen)
+func analyticModelSummary(model *control.SecondOrderModel, mlPolo1Real, mlPolo1Imag, mlPolo2Real, mlPolo2Imag *float64) map[string]interface{} {
+	summary := map[string]interface{}{
+		"metodo":                 model.Method,
+		"ganancia":               model.Gain,
+		"frecuencia_natural":     model.Wn,
+		"factor_amortiguamiento": model.Zeta,
+		"rms_ajuste":             model.RMS,
+		"polos":                  polesToMaps(model.Poles),
+	}
+
+	if mlPolo1Real == nil || mlPolo1Imag == nil || mlPolo2Real == nil || mlPolo2Imag == nil {
+		return summary
+	}
+
+	// Distancia de cada polo ML al polo analítico más cercano en el plano s
+	mlPoles := []complex128{complex(*mlPolo1Real, *mlPolo1Imag), complex(*mlPolo2Real, *mlPolo2Imag)}
+	distances := make([]float64, len(mlPoles))
+	for i, mlPole := range mlPoles {
+		distances[i] = math.Inf(1)
+		for _, fitPole := range model.Poles {
+			distances[i] = math.Min(distances[i], cmplx.Abs(mlPole-fitPole.ToComplex()))
+		}
+	}
+
+	// Frecuencia natural equivalente de los polos ML (media geométrica de sus módulos)
+	mlWn := math.Sqrt(cmplx.Abs(mlPoles[0]) * cmplx.Abs(mlPoles[1]))
+
+	comparison := map[string]interface{}{
+		"distancia_polo1": distances[0],
+		"distancia_polo2": distances[1],
+	}
+	if model.Wn > 0 {
+		comparison["error_relativo_wn"] = math.Abs(mlWn-model.Wn) / model.Wn
+	}
+	summary["comparacion_ml"] = comparison
+
+	return summary
+}
+
+// modelGain devuelve la ganancia estática del modelo analítico o, si no existe, la estimada
+// a partir de los niveles inicial y final de la respuesta
+func modelGain(y []float64, model *control.SecondOrderModel, inputVoltage float64) float64 {
+	if model != nil {
+		return model.Gain
+	}
+	if inputVoltage == 0 {
+		inputVoltage = 1
+	}
+	initial, final := control.StepLevels(y)
+	return (final - initial) / inputVoltage
+}
+
+// identifiedTransferFunction construye G(s) a partir de los ceros y polos almacenados y la
+// ganancia estática
+func identifiedTransferFunction(zeros, poles []map[string]float64, gain float64) (control.TransferFunction, error) {
+	return control.NewTransferFunctionZPK(polesFromMaps(zeros), polesFromMaps(poles), gain, 0)
+}
+
+// polesFromMaps convierte polos o ceros en formato {"real", "imag"} a complex128
+func polesFromMaps(poles []map[string]float64) []complex128 {
+	out := make([]complex128, len(poles))
+	for i, p := range poles {
+		out[i] = complex(p["real"], p["imag"])
+	}
+	return out
+}
+
+// validateModel simula la respuesta al escalón del modelo que usan las demás vistas (el
+// analítico si existe, como en resultTransferFunction, y si no los polos almacenados) sobre el
+// vector de tiempo medido y calcula la bondad de ajuste. Devuelve la traza simulada y el
+// resumen.
+func validateModel(t, y []float64, poles []map[string]float64, model *control.SecondOrderModel, inputVoltage float64) ([]float64, map[string]interface{}, error) {
+	if inputVoltage == 0 {
+		inputVoltage = 1
+	}
+
+	// Nivel inicial, instante del escalón y polos: del modelo analítico si existe
+	initial, _ := control.StepLevels(y)
+	experiment := control.StepExperiment{StepTime: t[0], InitialValue: initial, Amplitude: inputVoltage}
+	simulatedPoles, source := polesFromMaps(poles), "polos_almacenados"
+	if model != nil {
+		experiment.StepTime = model.StepTime
+		experiment.InitialValue = model.InitialValue
+		simulatedPoles = make([]complex128, len(model.Poles))
+		for i, p := range model.Poles {
+			simulatedPoles[i] = p.ToComplex()
+		}
+		source = "analitico"
+	}
+	gain := modelGain(y, model, inputVoltage)
+
+	simulated, err := control.SimulateStep(nil, simulatedPoles, gain, t, experiment)
+	if err != nil {
+		return nil, nil, err
+	}
+	quality, err := control.EvaluateFit(y, simulated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := fitQualitySummary(quality)
+	summary["modelo"] = source
+	summary["ganancia"] = gain
+	summary["instante_escalon"] = experiment.StepTime
+	return simulated, summary, nil
+}
+
+// fitQualitySummary resume la bondad de ajuste para el resumen técnico
+func fitQualitySummary(quality *control.FitQuality) map[string]interface{} {
+	return map[string]interface{}{
+		"porcentaje_ajuste": quality.FitPercent,
+		"r2":                quality.RSquared,
+		"rms":               quality.RMS,
+		"residuo_maximo":    quality.MaxResidual,
+		"residuos_blancos":  quality.Whiteness.White,
+		"prueba_blancura":   quality.Whiteness,
+	}
+}
+
+// estimateStepDeadTime estima el tiempo muerto de la respuesta al escalón sin recortar. El
+// instante del escalón es el indicado en la excitación, el flanco de la entrada medida si se
+// asignó una columna de entrada o, si no se conoce, el inicio de la respuesta.
+func estimateStepDeadTime(series *parser.Series, excitation *models.ExcitationOptions, finalValue *control.FinalValueEstimate) (*control.DeadTimeEstimate, error) {
+	stepTime, source := math.NaN(), ""
+	if excitation != nil && excitation.StartTime != nil {
+		stepTime, source = *excitation.StartTime, control.StepTimeGiven
+	} else if t, ok := control.StepTimeFromInput(series.Time, series.Input); ok {
+		stepTime, source = t, control.StepTimeInput
+	}
+
+	initial, final := control.StepLevels(series.Output)
+	if finalValue != nil {
+		final = finalValue.FinalValue
+	}
+	return control.EstimateDeadTime(series.Time, series.Output, initial, final, stepTime, source)
+}
+
+// deadTimeSummary resume la estimación del tiempo muerto para el resumen técnico
+func deadTimeSummary(d *control.DeadTimeEstimate) map[string]interface{} {
+	return map[string]interface{}{
+		"tiempo_muerto":           d.DeadTime,
+		"metodo":                  d.Method,
+		"instante_escalon":        d.StepTime,
+		"fuente_instante_escalon": d.StepTimeSource,
+		"tiempo_muerto_umbral":    d.Threshold,
+		"tiempo_muerto_tangente":  d.Tangent,
+		"nivel_umbral":            d.ThresholdLevel,
+		"pendiente_maxima":        d.MaxSlope,
+		"instante_pendiente":      d.MaxSlopeTime,
+	}
+}
+
+// identifyStepProcessModel identifica los modelos FOPDT y SOPDT sobre la serie sin recortar,
+// promediada por bloques, desde el instante del escalón y con el valor final estimado
+func identifyStepProcessModel(series *parser.Series, deadTime *control.DeadTimeEstimate, finalValue *control.FinalValueEstimate, inputVoltage float64) (*control.ProcessIdentification, error) {
+	if inputVoltage == 0 {
+		inputVoltage = 1
+	}
+	t, y, _ := averageBlocks(series.Time, series.Output, nil, maxFitPoints)
+	initial, final := control.StepLevels(series.Output)
+	if finalValue != nil {
+		final = finalValue.FinalValue
+	}
+	return control.IdentifyProcessModels(t, y, inputVoltage, initial, final, deadTime.StepTime)
+}
+
+// processModelSummary resume los modelos de proceso y compara el error de cada método
+func processModelSummary(id *control.ProcessIdentification) map[string]interface{} {
+	methods := make([]map[string]interface{}, len(id.Candidates))
+	for i, m := range id.Candidates {
+		methods[i] = map[string]interface{}{
+			"metodo":            m.Method,
+			"tipo":              m.Type,
+			"ganancia":          m.Gain,
+			"tau":               m.Tau,
+			"tiempo_muerto":     m.DeadTime,
+			"rms":               m.RMS,
+			"porcentaje_ajuste": m.FitPercent,
+		}
+		if m.Type == control.ProcessSOPDT {
+			methods[i]["tau2"] = m.Tau2
+		}
+	}
+	return map[string]interface{}{
+		"tipo":              id.Best.Type,
+		"metodo":            id.Best.Method,
+		"ganancia":          id.Best.Gain,
+		"tau":               id.Best.Tau,
+		"tau2":              id.Best.Tau2,
+		"tiempo_muerto":     id.Best.DeadTime,
+		"porcentaje_ajuste": id.Best.FitPercent,
+		"instante_escalon":  id.StepTime,
+		"comparacion":       methods,
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/dsp"
+	"backend/parser"
+)
+
+// Filas rechazadas citadas en los mensajes de error de lectura
+const rejectedRowsInMessage = 3
+
+// noDataMessage explica por qué un archivo no produjo datos, citando las primeras filas rechazadas
+func noDataMessage(report *parser.Report) string {
+	message := "El archivo no contiene filas numéricas de tiempo y salida"
+	if report == nil || len(report.Rejected) == 0 {
+		return message
+	}
+
+	var reasons []string
+	for i, row := range report.Rejected {
+		if i == rejectedRowsInMessage {
+			break
+		}
+		reasons = append(reasons, fmt.Sprintf("línea %d: %s", row.Line, row.Reason))
+	}
+	return fmt.Sprintf("%s (%d filas rechazadas; %s)", message, report.RowsRejected, strings.Join(reasons, "; "))
+}
+
+// addInstrumentData agrega al rawData los metadatos del instrumento que generó el archivo
+// (longitud de registro, intervalo de muestreo, escala vertical, disparo...)
+func addInstrumentData(rawData map[string]interface{}, report *parser.Report) {
+	if report != nil && report.Instrument != nil {
+		rawData["instrumento"] = report.Instrument
+	}
+}
+
+// preprocessSeries aplica a la serie leída el preprocesamiento de la solicitud
+func preprocessSeries(series *parser.Series, opts dsp.PreprocessOptions) (*parser.Series, []dsp.AppliedStep, error) {
+	signal, err := dsp.Preprocess(series.Time, series.Output, series.Input, series.SamplingPeriod, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &parser.Series{
+		Time:           signal.Time,
+		Output:         signal.Output,
+		Input:          signal.Input,
+		SamplingPeriod: signal.SamplingPeriod,
+	}, signal.Applied, nil
+}
+
+// addPreprocessingData agrega al rawData los pasos de preprocesamiento aplicados, con sus
+// parámetros efectivos, para poder reproducir el resultado
+func addPreprocessingData(rawData map[string]interface{}, applied []dsp.AppliedStep) {
+	if len(applied) > 0 {
+		rawData["preprocesamiento"] = applied
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/control"
+)
+
+// GetRootLocusHandler devuelve el lugar de las raíces del sistema identificado en lazo abierto:
+// las ramas con la ganancia de cada punto, las asíntotas, los puntos de ruptura y los cruces
+// con el eje imaginario. Parámetros opcionales: points, kmax y zeta (devuelve además las
+// ganancias con las que un polo tiene ese amortiguamiento).
+func GetRootLocusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var options control.RootLocusOptions
+		var err error
+		if v := c.Query("points"); v != "" {
+			if options.Points, err = strconv.Atoi(v); err != nil || options.Points < 2 || options.Points > control.MaxRootLocusPoints {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "points debe estar entre 2 y " + strconv.Itoa(control.MaxRootLocusPoints)})
+				return
+			}
+		}
+		kmax, ok := optionalFloatQuery(c, "kmax")
+		if !ok {
+			return
+		}
+		if kmax != nil {
+			if *kmax <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "kmax debe ser un número positivo"})
+				return
+			}
+			options.MaxGain = *kmax
+		}
+		zeta, ok := optionalFloatQuery(c, "zeta")
+		if !ok {
+			return
+		}
+		if zeta != nil && !(*zeta > 0 && *zeta < 1) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "zeta debe estar entre 0 y 1"})
+			return
+		}
+
+		analysis, result, graphData, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+
+		tf, err := resultTransferFunction(analysis, result, graphData)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo construir el modelo: " + err.Error()})
+			return
+		}
+
+		locus, err := control.ComputeRootLocus(tf, options)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"analysis_id": analysis.ID,
+			"model":       tf,
+			"root_locus":  locus,
+		}
+		if zeta != nil {
+			gains, err := control.GainsForDamping(tf, *zeta)
+			if err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			response["damping_gains"] = gains
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend/database"
+	"backend/dsp"
+	"backend/jobs"
+	"backend/models"
+	"backend/parser"
+	"backend/utils"
+)
+
+// analysisSignal es la señal de un análisis tal como la recibe cada modo: leída del archivo
+// del documento y preprocesada según las opciones de la solicitud
+type analysisSignal struct {
+	series        *parser.Series
+	report        *parser.Report
+	options       models.AnalysisOptions
+	preprocessing []dsp.AppliedStep
+}
+
+// loadAnalysisSignal obtiene la serie del documento del análisis leída con las opciones de la
+// solicitud y aplica el preprocesamiento. La serie se lee de la base de datos si ya se guardó
+// con las mismas opciones de lectura; si no, se descarga y se lee el archivo y se guarda para
+// los siguientes análisis. stage recibe cada etapa alcanzada (puede ser nil). Los errores son
+// jobs.Error con el código y el mensaje para el usuario.
+func loadAnalysisSignal(analysis *models.AnalysisRequest, stage func(string)) (*analysisSignal, error) {
+	analysisID := analysis.ID
+	if stage == nil {
+		stage = func(string) {}
+	}
+
+	// Opciones del análisis: lectura del archivo y entrada aplicada
+	options, err := analysis.ParseOptions()
+	if err != nil {
+		log.Printf("Opciones inválidas en el análisis %d, se usan los valores por defecto: %v", analysisID, err)
+	}
+	excitation := options.ExcitationType()
+	var parseOptions parser.Options
+	if options.Parsing != nil {
+		parseOptions = *options.Parsing
+	}
+	// La entrada medida se lee de la columna indicada en la excitación si no se asignó otra
+	if analysis.Mode == models.AnalysisModeStepResponse && excitation == models.ExcitationMeasured && parseOptions.Columns.Input == nil {
+		parseOptions.Columns.Input = &parser.ColumnRef{Index: options.Excitation.Column()}
+	}
+
+	stage(models.AnalysisStageLoadingSeries)
+
+	// Serie guardada por un análisis anterior con las mismas opciones de lectura
+	series, report, err := loadStoredSeries(analysis.DocumentID, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		if series, report, err = parseDocumentSeries(analysis.DocumentID, parseOptions, stage); err != nil {
+			return nil, err
+		}
+	}
+
+	// Preprocesamiento declarado en la solicitud (filtros, remuestreo, recorte...)
+	var preprocessing []dsp.AppliedStep
+	if options.Preprocessing != nil && len(options.Preprocessing.Steps) > 0 {
+		stage(models.AnalysisStagePreprocessing)
+		if series, preprocessing, err = preprocessSeries(series, *options.Preprocessing); err != nil {
+			return nil, jobs.Permanent(jobs.ErrCodeInvalidPreprocessing, "No se pudo preprocesar la señal: "+err.Error(), err)
+		}
+		log.Printf("Preprocesamiento del análisis %d: %d pasos, %d muestras resultantes", analysisID, len(preprocessing), len(series.Time))
+	}
+
+	return &analysisSignal{series: series, report: report, options: options, preprocessing: preprocessing}, nil
+}
+
+// loadStoredSeries lee la serie guardada del documento con las opciones de lectura dadas.
+// Devuelve nil sin error si no hay serie guardada; una serie dañada se elimina para volver a
+// leer el archivo.
+func loadStoredSeries(documentID uint, parseOptions parser.Options) (*parser.Series, *parser.Report, error) {
+	var stored models.DocumentSeries
+	err := database.DB.Where("document_id = ? AND options_hash = ?", documentID, models.ParseOptionsHash(parseOptions)).First(&stored).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, jobs.Transient(jobs.ErrCodePersistFailed, "No se pudo leer la serie guardada del documento", err)
+	}
+
+	series, report, err := stored.Series()
+	if err != nil {
+		log.Printf("Serie guardada %d del documento %d descartada: %v", stored.ID, documentID, err)
+		if err := database.DB.Delete(&stored).Error; err != nil {
+			log.Printf("No se pudo eliminar la serie guardada %d: %v", stored.ID, err)
+		}
+		return nil, nil, nil
+	}
+
+	log.Printf("Leídos %d puntos de datos de la serie guardada del documento %d", stored.Samples, documentID)
+	return series, report, nil
+}
+
+// parseDocumentSeries descarga y lee el archivo del documento y guarda la serie resultante
+func parseDocumentSeries(documentID uint, parseOptions parser.Options, stage func(string)) (*parser.Series, *parser.Report, error) {
+	// Obtener la URL del archivo
+	var document models.Document
+	if err := database.DB.First(&document, documentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, jobs.Permanent(jobs.ErrCodeDocumentNotFound, "El documento a analizar ya no existe", err)
+		}
+		return nil, nil, jobs.Transient(jobs.ErrCodePersistFailed, "No se pudo leer el documento", err)
+	}
+
+	stage(models.AnalysisStageDownloading)
+
+	// Crear el backend de almacenamiento configurado
+	storage, err := utils.NewStorage()
+	if err != nil {
+		return nil, nil, jobs.Transient(jobs.ErrCodeFileUnavailable, "El almacenamiento de archivos no está configurado correctamente", err)
+	}
+
+	// Obtener el archivo del almacenamiento
+	fileReader, err := storage.Get(document.FilePath)
+	if err != nil {
+		if errors.Is(err, utils.ErrFileNotFound) {
+			return nil, nil, jobs.Permanent(jobs.ErrCodeFileNotFound, "El archivo del documento ya no está disponible en el almacenamiento", err)
+		}
+		return nil, nil, jobs.Transient(jobs.ErrCodeFileUnavailable, "No se pudo descargar el archivo del documento", err)
+	}
+	defer fileReader.Close()
+
+	stage(models.AnalysisStageParsingCSV)
+
+	// Leer y procesar el CSV
+	series, report, err := parser.Parse(fileReader, parseOptions)
+	if err != nil {
+		switch {
+		case errors.Is(err, parser.ErrNoData):
+			return nil, nil, jobs.Permanent(jobs.ErrCodeNoNumericData, noDataMessage(report), err)
+		case errors.Is(err, parser.ErrInvalidColumns):
+			return nil, nil, jobs.Permanent(jobs.ErrCodeInvalidColumns, err.Error(), err)
+		case errors.Is(err, parser.ErrInvalidOptions):
+			return nil, nil, jobs.Permanent(jobs.ErrCodeInvalidColumns, err.Error(), err)
+		case errors.Is(err, parser.ErrUnsupportedFormat), errors.Is(err, parser.ErrMalformed):
+			return nil, nil, jobs.Permanent(jobs.ErrCodeInvalidFormat, err.Error(), err)
+		}
+		return nil, nil, jobs.Transient(jobs.ErrCodeFileUnreadable, "No se pudo leer el archivo de datos", err)
+	}
+
+	log.Printf("Leídos %d puntos de datos del archivo %s (%d filas rechazadas, delimitador %q, decimal %q, período de muestreo %e de %s)",
+		report.RowsAccepted, report.Format, report.RowsRejected, report.Delimiter, report.DecimalSeparator, series.SamplingPeriod, report.SamplingPeriodSource)
+
+	// Guardar la serie para los siguientes análisis; si otro análisis la guardó a la vez se
+	// conserva la existente. Un fallo aquí no impide continuar con el análisis.
+	stored := models.NewDocumentSeries(documentID, parseOptions, series, report)
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(stored).Error; err != nil {
+		log.Printf("No se pudo guardar la serie del documento %d: %v", documentID, err)
+	}
+
+	return series, report, nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+
+	"backend/database"
+	"backend/dsp"
+	"backend/jobs"
+	"backend/models"
+	"backend/parser"
+)
+
+// Tipo de sistema registrado en los resultados del modo espectral
+const spectralSystemType = "espectral"
+
+// processSpectralAnalysis calcula el contenido espectral de la señal completa del CSV
+// (FFT con ventana, PSD de Welch, frecuencia dominante y SNR) y guarda el resultado
+func processSpectralAnalysis(analysis *models.AnalysisRequest, series *parser.Series, report *parser.Report, preprocessing []dsp.AppliedStep) error {
+	timeData, outputData, samplingPeriod := series.Time, series.Output, series.SamplingPeriod
+
+	jobs.ReportStage(database.DB, analysis.ID, models.AnalysisStageSpectral)
+
+	var spectralOptions dsp.SpectralOptions
+	if options, err := analysis.ParseOptions(); err != nil {
+		log.Printf("Opciones inválidas en el análisis %d, se usan los valores por defecto: %v", analysis.ID, err)
+	} else if options.Spectral != nil {
+		spectralOptions = *options.Spectral
+	}
+
+	spectrum, err := dsp.Analyze(outputData, samplingPeriod, spectralOptions)
+	if err != nil {
+		return jobs.Permanent(jobs.ErrCodeAnalysisFailed, "No se pudo calcular el espectro de la señal: "+err.Error(), err)
+	}
+
+	log.Printf("Análisis espectral %d: fs=%.3f Hz, dominante=%.4f Hz", analysis.ID, spectrum.SampleRate, spectrum.DominantFrequency)
+
+	// La señal en el tiempo se conserva (reducida) para graficarla junto al espectro
+	reducedTime, reducedOutput := decimateSeries(timeData, outputData, defaultGraphPoints)
+	graphData := models.GraphData{Time: reducedTime, Output: reducedOutput}
+
+	rawData := map[string]interface{}{
+		"modo":               models.AnalysisModeSpectral,
+		"voltaje_entrada":    analysis.InputVoltage,
+		"puntos_originales":  len(timeData),
+		"puntos_optimizados": len(reducedTime),
+		"sampling_period":    samplingPeriod,
+		"tiempo_inicial":     timeData[0],
+		"tiempo_final":       timeData[len(timeData)-1],
+		"valor_inicial":      outputData[0],
+		"valor_final":        outputData[len(outputData)-1],
+		"lectura":            report,
+	}
+	addInstrumentData(rawData, report)
+	addPreprocessingData(rawData, preprocessing)
+
+	technicalSummary := map[string]interface{}{
+		"analisis_espectral": spectralSummary(spectrum),
+	}
+
+	polesJSON, _ := json.Marshal(map[string]interface{}{"polos": []map[string]float64{}})
+	rawDataJSON, _ := json.Marshal(rawData)
+	graphDataJSON, _ := json.Marshal(graphData)
+	technicalSummaryJSON, _ := json.Marshal(technicalSummary)
+	spectrumJSON, _ := json.Marshal(spectrum)
+
+	jobs.ReportStage(database.DB, analysis.ID, models.AnalysisStagePersisting)
+
+	result := models.Result{
+		AnalysisRequestID: analysis.ID,
+		SystemType:        spectralSystemType,
+		Description:       generateSpectralDescription(spectrum),
+		Poles:             datatypes.JSON(polesJSON),
+		RawData:           datatypes.JSON(rawDataJSON),
+		GraphData:         datatypes.JSON(graphDataJSON),
+		TechnicalSummary:  datatypes.JSON(technicalSummaryJSON),
+		Spectrum:          datatypes.JSON(spectrumJSON),
+		IsLatest:          true,
+		CreatedAt:         time.Now(),
+	}
+
+	if err := saveAnalysisResult(analysis.ID, analysis.DocumentID, &result); err != nil {
+		return err
+	}
+
+	log.Printf("Análisis espectral %d completado exitosamente con %d muestras", analysis.ID, len(outputData))
+	return nil
+}
+
+// spectralSummary resume el análisis espectral para el resumen técnico
+func spectralSummary(s *dsp.SpectralAnalysis) map[string]interface{} {
+	summary := map[string]interface{}{
+		"ventana":               s.Window,
+		"frecuencia_muestreo":   s.SampleRate,
+		"resolucion_frecuencia": s.FrequencyResolution,
+		"segmentos_welch":       s.Segments,
+		"frecuencia_dominante":  s.DominantFrequency,
+		"amplitud_dominante":    s.DominantAmplitude,
+		"potencia_total":        s.TotalPower,
+	}
+	if s.SNRDB != nil {
+		summary["snr_db"] = *s.SNRDB
+	}
+	return summary
+}
+
+// generateSpectralDescription genera una descripción legible del análisis espectral
+func generateSpectralDescription(s *dsp.SpectralAnalysis) string {
+	var description strings.Builder
+
+	description.WriteString(fmt.Sprintf("Análisis espectral de %d muestras a %.3f Hz con ventana %s. ", s.Samples, s.SampleRate, s.Window))
+	description.WriteString(fmt.Sprintf("La componente dominante está en %.4f Hz con amplitud %.4g. ", s.DominantFrequency, s.DominantAmplitude))
+
+	if s.SNRDB != nil {
+		switch {
+		case *s.SNRDB >= 20:
+			description.WriteString(fmt.Sprintf("La relación señal/ruido es alta (%.1f dB). ", *s.SNRDB))
+		case *s.SNRDB >= 6:
+			description.WriteString(fmt.Sprintf("La relación señal/ruido es moderada (%.1f dB). ", *s.SNRDB))
+		default:
+			description.WriteString(fmt.Sprintf("La relación señal/ruido es baja (%.1f dB); la señal está dominada por ruido. ", *s.SNRDB))
+		}
+	}
+
+	return strings.TrimSpace(description.String())
+}
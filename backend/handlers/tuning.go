@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/control"
+	"backend/models"
+)
+
+// TuneControllerHandler recomienda ganancias PI/PID para la planta identificada con las reglas
+// de Ziegler–Nichols, Cohen–Coon, IMC, SIMC, AMIGO y asignación de polos, y predice el
+// sobrepico y el tiempo de establecimiento del lazo cerrado con cada juego. El cuerpo
+// (opcional) son las control.TuningOptions.
+func TuneControllerHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var options control.TuningOptions
+		if err := c.ShouldBindJSON(&options); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := options.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		analysis, result, graphData, ok := loadAnalysisResult(c)
+		if !ok {
+			return
+		}
+
+		plant, err := resultPlant(analysis, result, graphData)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo construir el modelo: " + err.Error()})
+			return
+		}
+
+		tuning, err := control.TunePID(plant, options)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No se pudo sintonizar el controlador: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"analysis_id": analysis.ID,
+			"tuning":      tuning,
+		})
+	}
+}
+
+// resultPlant reconstruye la planta de un resultado: el mejor modelo de proceso FOPDT/SOPDT si
+// se identificó o, si no, la función de transferencia identificada (ver resultTransferFunction)
+// con el tiempo muerto estimado
+func resultPlant(analysis *models.AnalysisRequest, result *models.Result, graphData *models.GraphData) (control.TuningPlant, error) {
+	if len(result.ProcessModel) > 0 {
+		var id control.ProcessIdentification
+		if err := json.Unmarshal(result.ProcessModel, &id); err == nil && id.Best.Tau > 0 {
+			if tf, err := id.Best.TransferFunction(); err == nil {
+				return control.TuningPlant{Model: tf, Process: &id.Best}, nil
+			}
+		}
+	}
+
+	tf, err := resultTransferFunction(analysis, result, graphData)
+	if err != nil {
+		return control.TuningPlant{}, err
+	}
+	if result.DeadTime != nil {
+		tf.Delay = *result.DeadTime
+	}
+	return control.TuningPlant{Model: tf}, nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"backend/control"
+	"backend/models"
+	"backend/parser"
+	"backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Requisitos de una señal subida y tamaño de su vista previa
+const (
+	minSignalSamples = 100 // Muestras necesarias para identificar un modelo
+	sparklinePoints  = 64  // Puntos de la vista previa de la salida
+	minStepFraction  = 0.1 // Salto mínimo entre el nivel inicial y el final, relativo al rango de la salida
+	minStepNoise     = 3.0 // Salto mínimo relativo a la desviación estándar del tramo final
+)
+
+// signalUpload es un archivo recibido en el formulario junto con las opciones de lectura y,
+// si pudo leerse, la serie resultante
+type signalUpload struct {
+	filename string
+	data     []byte
+	options  parser.Options
+	series   *parser.Series
+	report   *parser.Report
+}
+
+// readSignalUpload lee el archivo y las opciones del formulario (campos file, parsing,
+// mode y excitation) y valida la señal. Si la petición es inválida responde con el error y
+// devuelve ok=false.
+func readSignalUpload(c *gin.Context) (*signalUpload, *models.SignalValidation, bool) {
+	// Limitar el tamaño máximo de la petición
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MaxFileSize)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error al obtener el archivo: " + err.Error()})
+		return nil, nil, false
+	}
+	defer file.Close()
+
+	var opts parser.Options
+	if raw := c.PostForm("parsing"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parsing no es un JSON válido: " + err.Error()})
+			return nil, nil, false
+		}
+		if err := opts.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, nil, false
+		}
+	}
+
+	// El escalón solo se exige en la identificación con entrada escalón (la del formulario principal)
+	mode, excitation := c.PostForm("mode"), c.PostForm("excitation")
+	if !models.IsValidAnalysisMode(mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode debe ser step_response o spectral"})
+		return nil, nil, false
+	}
+	if excitation != "" {
+		if err := (models.ExcitationOptions{Type: excitation}).Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "excitation debe ser step, impulse, ramp o measured"})
+			return nil, nil, false
+		}
+	}
+	requireStep := mode != models.AnalysisModeSpectral && (excitation == "" || excitation == models.ExcitationStep)
+
+	if ext := strings.ToLower(filepath.Ext(header.Filename)); !parser.SupportedExtension(ext) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Tipo de archivo no permitido (se admiten %s)", parser.SupportedExtensions())})
+		return nil, nil, false
+	}
+	data, err := readUploadedFile(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error al leer el archivo: " + err.Error()})
+		return nil, nil, false
+	}
+
+	upload := &signalUpload{filename: header.Filename, data: data, options: opts}
+	return upload, validateSignalFile(upload, requireStep), true
+}
+
+// readUploadedFile lee el archivo completo; el tamaño ya está limitado por MaxBytesReader
+func readUploadedFile(file multipart.File) ([]byte, error) {
+	data, err := io.ReadAll(file)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, errors.New("el archivo es demasiado grande (máximo 10MB)")
+	}
+	return data, err
+}
+
+// validateSignalFile lee la señal del archivo y comprueba que pueda analizarse
+func validateSignalFile(upload *signalUpload, requireStep bool) *models.SignalValidation {
+	validation := &models.SignalValidation{}
+	unreadable := func(message string) *models.SignalValidation {
+		validation.Problems = []models.SignalProblem{{Code: models.SignalProblemUnreadable, Message: message}}
+		return validation
+	}
+
+	head := upload.data[:min(len(upload.data), parser.SignatureLength)]
+	if err := parser.CheckSignature(upload.filename, head); err != nil {
+		return unreadable(err.Error())
+	}
+	series, report, err := parser.Parse(bytes.NewReader(upload.data), upload.options)
+	validation.Report = report
+	if err != nil {
+		if errors.Is(err, parser.ErrNoData) {
+			return unreadable(noDataMessage(report))
+		}
+		return unreadable(err.Error())
+	}
+
+	upload.series, upload.report = series, report
+	validation.Preview = signalPreview(series, report)
+	validation.Problems = signalProblems(series, report, requireStep)
+	validation.Valid = len(validation.Problems) == 0
+	return validation
+}
+
+// signalProblems detecta los motivos por los que la señal no puede analizarse
+func signalProblems(series *parser.Series, report *parser.Report, requireStep bool) []models.SignalProblem {
+	var problems []models.SignalProblem
+	add := func(code, format string, args ...interface{}) {
+		problems = append(problems, models.SignalProblem{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	n := len(series.Time)
+	if n < minSignalSamples {
+		add(models.SignalProblemTooFewSamples, "la señal tiene %d muestras y se necesitan al menos %d", n, minSignalSamples)
+	}
+	for i := 1; i < n; i++ {
+		if series.Time[i] <= series.Time[i-1] {
+			add(models.SignalProblemNonMonotonic, "el tiempo no es creciente: la muestra %d (t=%g s) no es posterior a la anterior (t=%g s)",
+				i+1, series.Time[i], series.Time[i-1])
+			break
+		}
+	}
+	if report.RowsNonFinite > 0 {
+		add(models.SignalProblemNonFinite, "el archivo tiene %d filas con valores NaN o infinitos", report.RowsNonFinite)
+	}
+	if requireStep && n >= minSignalSamples {
+		if ok, initial, final := detectStep(series.Output); !ok {
+			add(models.SignalProblemNoStep, "no se detecta un escalón: la salida pasa de %g a %g, un cambio indistinguible de sus variaciones", initial, final)
+		}
+	}
+	return problems
+}
+
+// detectStep comprueba que la salida cambie de nivel: el salto entre el nivel inicial y el
+// final debe ser una fracción apreciable del rango de la señal y superar el ruido del tramo final
+func detectStep(y []float64) (bool, float64, float64) {
+	initial, final := control.StepLevels(y)
+	jump := math.Abs(final - initial)
+	span := calculateMax(y) - calculateMin(y)
+	if jump == 0 || span == 0 {
+		return false, initial, final
+	}
+	noise := calculateStandardDeviation(y[len(y)-max(len(y)/10, 1):])
+	return jump >= minStepFraction*span && jump >= minStepNoise*noise, initial, final
+}
+
+// signalPreview resume la señal: muestras, período, duración, extremos y una vista reducida
+func signalPreview(series *parser.Series, report *parser.Report) *models.SignalPreview {
+	n := len(series.Output)
+	preview := &models.SignalPreview{
+		Samples:              n,
+		SamplingPeriod:       series.SamplingPeriod,
+		SamplingPeriodSource: report.SamplingPeriodSource,
+		Min:                  calculateMin(series.Output),
+		Max:                  calculateMax(series.Output),
+		Sparkline:            []float64{},
+	}
+	if n > 0 {
+		preview.Duration = series.Time[n-1] - series.Time[0]
+	}
+
+	// Promedio de tramos consecutivos de igual longitud
+	buckets := min(n, sparklinePoints)
+	for b := 0; b < buckets; b++ {
+		start, end := b*n/buckets, (b+1)*n/buckets
+		preview.Sparkline = append(preview.Sparkline, calculateMean(series.Output[start:end]))
+	}
+	return preview
+}
+
+// signalProblemsMessage une los problemas de validación en un mensaje para el usuario
+func signalProblemsMessage(validation *models.SignalValidation) string {
+	messages := make([]string, len(validation.Problems))
+	for i, p := range validation.Problems {
+		messages[i] = p.Message
+	}
+	return "El archivo no puede analizarse: " + strings.Join(messages, "; ")
+}
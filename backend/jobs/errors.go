@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Códigos de error legibles por máquina para los fallos de análisis
+const (
+	ErrCodeRequestNotFound      = "request_not_found"
+	ErrCodeDocumentNotFound     = "document_not_found"
+	ErrCodeFileNotFound         = "file_not_found"
+	ErrCodeFileUnavailable      = "file_unavailable"
+	ErrCodeFileUnreadable       = "file_unreadable"
+	ErrCodeNoNumericData        = "no_numeric_data"
+	ErrCodeInvalidColumns       = "invalid_columns"
+	ErrCodeInvalidFormat        = "invalid_format"
+	ErrCodeInvalidPreprocessing = "invalid_preprocessing"
+	ErrCodeAnalysisFailed       = "analysis_failed"
+	ErrCodePersistFailed        = "persist_failed"
+	ErrCodeInternal             = "internal_error"
+)
+
+// Error es un fallo de análisis con código, mensaje para el usuario y
+// la indicación de si tiene sentido reintentarlo
+type Error struct {
+	Code      string
+	Message   string
+	Retryable bool
+	Err       error
+}
+
+// Error implementa la interfaz error
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap devuelve el error original
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Permanent crea un error que no se reintenta (los datos nunca serán válidos)
+func Permanent(code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Transient crea un error que se reintenta con backoff (red, base de datos, etc.)
+func Transient(code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Retryable: true, Err: err}
+}
+
+// classify obtiene el código, el mensaje y si es reintentable para cualquier error.
+// Los errores sin clasificar se consideran internos y reintentables.
+func classify(err error) (code, message string, retryable bool) {
+	var jobErr *Error
+	if errors.As(err, &jobErr) {
+		return jobErr.Code, jobErr.Message, jobErr.Retryable
+	}
+	return ErrCodeInternal, "Error interno al procesar el análisis", true
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"backend/models"
+	"gorm.io/gorm"
+)
+
+// ProgressEvent describe un cambio de estado o de etapa de un análisis
+type ProgressEvent struct {
+	AnalysisID   uint      `json:"analysis_id"`
+	Status       string    `json:"status"`
+	Stage        string    `json:"stage,omitempty"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// broker distribuye los eventos de progreso a los suscriptores de cada análisis
+type broker struct {
+	mu   sync.Mutex
+	subs map[uint]map[chan ProgressEvent]struct{}
+}
+
+var progress = &broker{subs: make(map[uint]map[chan ProgressEvent]struct{})}
+
+// Subscribe devuelve un canal con los eventos de progreso del análisis indicado
+// y una función para cancelar la suscripción
+func Subscribe(analysisID uint) (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, 16)
+
+	progress.mu.Lock()
+	if progress.subs[analysisID] == nil {
+		progress.subs[analysisID] = make(map[chan ProgressEvent]struct{})
+	}
+	progress.subs[analysisID][ch] = struct{}{}
+	progress.mu.Unlock()
+
+	unsubscribe := func() {
+		progress.mu.Lock()
+		delete(progress.subs[analysisID], ch)
+		if len(progress.subs[analysisID]) == 0 {
+			delete(progress.subs, analysisID)
+		}
+		progress.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// publish envía un evento a todos los suscriptores sin bloquear al worker
+func publish(event ProgressEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	for ch := range progress.subs[event.AnalysisID] {
+		select {
+		case ch <- event:
+		default:
+			// Un cliente lento pierde eventos intermedios, pero el estado final se consulta en la base de datos
+		}
+	}
+}
+
+// ReportStage registra la etapa actual del pipeline y la notifica a los suscriptores
+func ReportStage(db *gorm.DB, analysisID uint, stage string) {
+	if err := db.Model(&models.AnalysisRequest{}).Where("id = ?", analysisID).Update("stage", stage).Error; err != nil {
+		log.Printf("Error al registrar etapa %s del análisis %d: %v", stage, analysisID, err)
+	}
+
+	publish(ProgressEvent{
+		AnalysisID: analysisID,
+		Status:     models.AnalysisStatusRunning,
+		Stage:      stage,
+	})
+}
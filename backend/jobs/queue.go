@@ -0,0 +1,444 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/models"
+	"gorm.io/gorm"
+)
+
+// Handler procesa la solicitud de análisis asociada a un trabajo
+type Handler func(analysisID uint) error
+
+// Config contiene la configuración del pool de workers y de los reintentos
+type Config struct {
+	Workers      int           // Número de workers concurrentes
+	MaxAttempts  int           // Intentos máximos antes de marcar el trabajo como fallido
+	PollInterval time.Duration // Intervalo de sondeo cuando la cola está vacía
+	BaseBackoff  time.Duration // Espera antes del primer reintento
+	MaxBackoff   time.Duration // Espera máxima entre reintentos
+	LeaseTimeout time.Duration // Tiempo tras el cual un trabajo "running" se considera abandonado
+}
+
+// ConfigFromEnv construye la configuración a partir de variables de entorno
+func ConfigFromEnv() Config {
+	return Config{
+		Workers:      envInt("ANALYSIS_WORKERS", 2),
+		MaxAttempts:  envInt("ANALYSIS_MAX_ATTEMPTS", 5),
+		PollInterval: envDuration("ANALYSIS_POLL_INTERVAL", 2*time.Second),
+		BaseBackoff:  envDuration("ANALYSIS_RETRY_BACKOFF", 5*time.Second),
+		MaxBackoff:   envDuration("ANALYSIS_RETRY_MAX_BACKOFF", 5*time.Minute),
+		LeaseTimeout: envDuration("ANALYSIS_JOB_LEASE", 10*time.Minute),
+	}
+}
+
+// Queue es una cola de trabajos respaldada por PostgreSQL
+type Queue struct {
+	db       *gorm.DB
+	handler  Handler
+	cfg      Config
+	workerID string
+	wake     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// defaultQueue es la cola activa en este proceso, usada por Enqueue para despertar a los workers
+var defaultQueue *Queue
+
+// NewQueue crea una nueva cola de trabajos
+func NewQueue(db *gorm.DB, handler Handler, cfg Config) *Queue {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Queue{
+		db:       db,
+		handler:  handler,
+		cfg:      cfg,
+		workerID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start recupera los trabajos pendientes y lanza el pool de workers
+func (q *Queue) Start() error {
+	if err := q.recoverPending(); err != nil {
+		return err
+	}
+
+	defaultQueue = q
+
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(i)
+	}
+
+	log.Printf("Cola de análisis iniciada con %d workers (%s)", q.cfg.Workers, q.workerID)
+	return nil
+}
+
+// Stop detiene los workers y espera a que terminen el trabajo en curso
+func (q *Queue) Stop() {
+	close(q.stop)
+	q.wg.Wait()
+	if defaultQueue == q {
+		defaultQueue = nil
+	}
+	log.Println("Cola de análisis detenida")
+}
+
+// Enqueue agrega un trabajo para la solicitud de análisis indicada.
+// Puede recibir una transacción para que la solicitud y su trabajo se creen juntos.
+func Enqueue(db *gorm.DB, analysisID uint) error {
+	maxAttempts := envInt("ANALYSIS_MAX_ATTEMPTS", 5)
+	if defaultQueue != nil {
+		maxAttempts = defaultQueue.cfg.MaxAttempts
+	}
+
+	now := time.Now()
+	job := models.AnalysisJob{
+		AnalysisRequestID: analysisID,
+		Status:            models.JobStatusQueued,
+		MaxAttempts:       maxAttempts,
+		RunAt:             now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := db.Create(&job).Error; err != nil {
+		return fmt.Errorf("error al encolar análisis %d: %v", analysisID, err)
+	}
+
+	return nil
+}
+
+// Notify despierta a un worker ocioso para que revise la cola de inmediato
+func Notify() {
+	if q := defaultQueue; q != nil {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Cancel cancela el trabajo de una solicitud si todavía no ha comenzado.
+// Devuelve false si el trabajo ya está en ejecución o terminado.
+func Cancel(db *gorm.DB, analysisID uint) (bool, error) {
+	cancelled := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&models.AnalysisJob{}).
+			Where("analysis_request_id = ? AND status = ?", analysisID, models.JobStatusQueued).
+			Updates(map[string]interface{}{"status": models.JobStatusCancelled, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		cancelled = true
+		return tx.Model(&models.AnalysisRequest{}).Where("id = ?", analysisID).Updates(map[string]interface{}{
+			"status":      models.AnalysisStatusCancelled,
+			"finished_at": now,
+		}).Error
+	})
+
+	if cancelled && err == nil {
+		publish(ProgressEvent{AnalysisID: analysisID, Status: models.AnalysisStatusCancelled})
+	}
+
+	return cancelled, err
+}
+
+// recoverPending encola las solicitudes sin procesar que no tienen trabajo asociado
+// (por ejemplo, las creadas antes de existir la cola) y libera trabajos abandonados
+func (q *Queue) recoverPending() error {
+	now := time.Now()
+
+	result := q.db.Exec(`
+		INSERT INTO analysis_jobs (analysis_request_id, status, attempts, max_attempts, run_at, created_at, updated_at)
+		SELECT ar.id, ?, 0, ?, ?, ?, ?
+		FROM analysis_requests ar
+		WHERE ar.status IN (?, ?)
+		AND NOT EXISTS (SELECT 1 FROM analysis_jobs j WHERE j.analysis_request_id = ar.id)
+	`, models.JobStatusQueued, q.cfg.MaxAttempts, now, now, now,
+		models.AnalysisStatusQueued, models.AnalysisStatusRunning)
+	if result.Error != nil {
+		return fmt.Errorf("error al recuperar solicitudes pendientes: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Encoladas %d solicitudes de análisis pendientes", result.RowsAffected)
+	}
+
+	result = q.db.Model(&models.AnalysisJob{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, now.Add(-q.cfg.LeaseTimeout)).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusQueued,
+			"locked_at":  nil,
+			"locked_by":  "",
+			"run_at":     now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error al liberar trabajos abandonados: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Liberados %d trabajos de análisis abandonados", result.RowsAffected)
+	}
+
+	return nil
+}
+
+// worker procesa trabajos hasta que se detiene la cola
+func (q *Queue) worker(n int) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := q.claim()
+		if err != nil {
+			log.Printf("Worker %d: error al reclamar trabajo: %v", n, err)
+		}
+
+		if job == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(q.cfg.PollInterval):
+			}
+			continue
+		}
+
+		q.run(job)
+	}
+}
+
+// claim toma el siguiente trabajo disponible usando FOR UPDATE SKIP LOCKED,
+// de modo que varios workers (o instancias) nunca procesen el mismo trabajo
+func (q *Queue) claim() (*models.AnalysisJob, error) {
+	now := time.Now()
+
+	var job models.AnalysisJob
+	err := q.db.Raw(`
+		UPDATE analysis_jobs
+		SET status = ?, attempts = attempts + 1, locked_at = ?, locked_by = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM analysis_jobs
+			WHERE (status = ? AND run_at <= ?)
+			   OR (status = ? AND locked_at < ?)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, models.JobStatusRunning, now, q.workerID, now,
+		models.JobStatusQueued, now,
+		models.JobStatusRunning, now.Add(-q.cfg.LeaseTimeout),
+	).Scan(&job).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if job.ID == 0 {
+		return nil, nil
+	}
+
+	// Reflejar en la solicitud que el análisis está en ejecución
+	if err := q.db.Model(&models.AnalysisRequest{}).Where("id = ?", job.AnalysisRequestID).Updates(map[string]interface{}{
+		"status":        models.AnalysisStatusRunning,
+		"stage":         "",
+		"started_at":    now,
+		"error_code":    "",
+		"error_message": "",
+	}).Error; err != nil {
+		log.Printf("Error al marcar análisis %d como en ejecución: %v", job.AnalysisRequestID, err)
+	}
+
+	publish(ProgressEvent{AnalysisID: job.AnalysisRequestID, Status: models.AnalysisStatusRunning})
+
+	return &job, nil
+}
+
+// run ejecuta el handler de un trabajo y registra su resultado
+func (q *Queue) run(job *models.AnalysisJob) {
+	// Un trabajo reclamado tras agotar sus intentos (p. ej. por abandono) se da por fallido
+	if job.Attempts > job.MaxAttempts {
+		q.fail(job, Permanent(ErrCodeInternal, "Se agotaron los intentos permitidos para el análisis",
+			fmt.Errorf("%d intentos", job.MaxAttempts)))
+		return
+	}
+
+	log.Printf("Procesando análisis %d (intento %d/%d)", job.AnalysisRequestID, job.Attempts, job.MaxAttempts)
+
+	// Renovar el lease mientras el análisis se ejecuta para que otro worker no lo reclame
+	done := make(chan struct{})
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		q.heartbeat(job, done)
+	}()
+	err := q.execute(job.AnalysisRequestID)
+	close(done)
+	<-heartbeat
+
+	if err != nil {
+		q.fail(job, err)
+		return
+	}
+
+	now := time.Now()
+	result := q.owned(job).Updates(map[string]interface{}{
+		"status":     models.JobStatusDone,
+		"locked_at":  nil,
+		"locked_by":  "",
+		"last_error": "",
+		"updated_at": now,
+	})
+	if result.Error != nil {
+		log.Printf("Error al marcar trabajo %d como completado: %v", job.ID, result.Error)
+	} else if result.RowsAffected == 0 {
+		log.Printf("Trabajo %d completado tras perder el lease: otro worker lo reclamó", job.ID)
+		return
+	}
+
+	publish(ProgressEvent{AnalysisID: job.AnalysisRequestID, Status: models.AnalysisStatusSucceeded})
+}
+
+// owned restringe una actualización al trabajo mientras siga reclamado por este worker en
+// este intento; si el lease expiró y otro worker lo reclamó, no afecta a ninguna fila
+func (q *Queue) owned(job *models.AnalysisJob) *gorm.DB {
+	return q.db.Model(&models.AnalysisJob{}).Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?",
+		job.ID, models.JobStatusRunning, q.workerID, job.Attempts)
+}
+
+// heartbeat renueva locked_at a un tercio del lease hasta que se cierra done
+func (q *Queue) heartbeat(job *models.AnalysisJob, done <-chan struct{}) {
+	ticker := time.NewTicker(q.cfg.LeaseTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			result := q.owned(job).Updates(map[string]interface{}{"locked_at": now, "updated_at": now})
+			if result.Error != nil {
+				log.Printf("Error al renovar el lease del trabajo %d: %v", job.ID, result.Error)
+			} else if result.RowsAffected == 0 {
+				log.Printf("Trabajo %d: el lease se perdió, otro worker lo reclamó", job.ID)
+				return
+			}
+		}
+	}
+}
+
+// execute invoca el handler protegiéndose de pánicos
+func (q *Queue) execute(analysisID uint) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pánico durante el análisis: %v", r)
+		}
+	}()
+
+	return q.handler(analysisID)
+}
+
+// fail reprograma el trabajo con backoff exponencial o lo marca como fallido definitivamente
+func (q *Queue) fail(job *models.AnalysisJob, cause error) {
+	code, message, retryable := classify(cause)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"locked_at":  nil,
+		"locked_by":  "",
+		"last_error": cause.Error(),
+		"updated_at": now,
+	}
+	requestUpdates := map[string]interface{}{}
+
+	if !retryable || job.Attempts >= job.MaxAttempts {
+		updates["status"] = models.JobStatusFailed
+		requestUpdates["status"] = models.AnalysisStatusFailed
+		requestUpdates["error_code"] = code
+		requestUpdates["error_message"] = message
+		requestUpdates["finished_at"] = now
+		log.Printf("Análisis %d fallido definitivamente tras %d intentos: %v", job.AnalysisRequestID, job.Attempts, cause)
+	} else {
+		delay := q.backoff(job.Attempts)
+		updates["status"] = models.JobStatusQueued
+		updates["run_at"] = now.Add(delay)
+		requestUpdates["status"] = models.AnalysisStatusQueued
+		log.Printf("Análisis %d falló (intento %d/%d), reintentando en %v: %v", job.AnalysisRequestID, job.Attempts, job.MaxAttempts, delay, cause)
+	}
+
+	result := q.owned(job).Updates(updates)
+	if result.Error != nil {
+		log.Printf("Error al registrar fallo del trabajo %d: %v", job.ID, result.Error)
+	} else if result.RowsAffected == 0 {
+		// El trabajo lo reclamó otro worker: su estado ya no es responsabilidad de este
+		log.Printf("Fallo del trabajo %d ignorado tras perder el lease: %v", job.ID, cause)
+		return
+	}
+
+	if err := q.db.Model(&models.AnalysisRequest{}).Where("id = ?", job.AnalysisRequestID).Updates(requestUpdates).Error; err != nil {
+		log.Printf("Error al registrar fallo del análisis %d: %v", job.AnalysisRequestID, err)
+	}
+
+	event := ProgressEvent{AnalysisID: job.AnalysisRequestID, Status: requestUpdates["status"].(string)}
+	if event.Status == models.AnalysisStatusFailed {
+		event.ErrorCode = code
+		event.ErrorMessage = message
+	}
+	publish(event)
+}
+
+// backoff calcula la espera antes del siguiente intento (exponencial con jitter)
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := float64(q.cfg.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(q.cfg.MaxBackoff) {
+		delay = float64(q.cfg.MaxBackoff)
+	}
+
+	// Jitter de ±20% para evitar que los reintentos se sincronicen
+	jitter := 0.8 + rand.Float64()*0.4
+	return time.Duration(delay * jitter)
+}
+
+// envInt obtiene una variable de entorno entera, o devuelve un valor predeterminado
+func envInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// envDuration obtiene una variable de entorno de duración (p. ej. "5s"), o devuelve un valor predeterminado
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -10,6 +15,7 @@ import (
 
 	"backend/database"
 	"backend/handlers"
+	"backend/jobs"
 	"backend/middleware"
 )
 
@@ -22,6 +28,12 @@ func main() {
 		log.Fatalf("Error al inicializar la base de datos: %v", err)
 	}
 
+	// Iniciar la cola persistente de análisis (retoma las solicitudes pendientes)
+	analysisQueue := jobs.NewQueue(database.DB, handlers.ProcessAnalysisJob, jobs.ConfigFromEnv())
+	if err := analysisQueue.Start(); err != nil {
+		log.Fatalf("Error al iniciar la cola de análisis: %v", err)
+	}
+
 	// Configurar Gin para producción si es necesario
 	if os.Getenv("ENV") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -66,6 +78,7 @@ func main() {
 	documents.Use(middleware.OptionalAuthMiddleware())
 	{
 		documents.POST("", handlers.UploadDocumentHandler())
+		documents.POST("/validate", handlers.ValidateDocumentHandler())
 	}
 
 	// Rutas para análisis (disponible para todos, autenticación opcional)
@@ -74,6 +87,14 @@ func main() {
 	{
 		analysis.POST("", handlers.CreateAnalysisRequestHandler())
 		analysis.GET("/:id", handlers.GetAnalysisResultHandler())
+		analysis.GET("/:id/events", middleware.QueryTokenAuthMiddleware(), handlers.GetAnalysisEventsHandler())
+		analysis.POST("/:id/cancel", handlers.CancelAnalysisRequestHandler())
+		analysis.POST("/:id/fit", handlers.FitTransferFunctionHandler())
+		analysis.GET("/:id/frequency-response", handlers.GetFrequencyResponseHandler())
+		analysis.GET("/:id/graph", handlers.GetAnalysisGraphHandler())
+		analysis.POST("/:id/tuning", handlers.TuneControllerHandler())
+		analysis.POST("/:id/closed-loop", handlers.SimulateClosedLoopHandler())
+		analysis.GET("/:id/root-locus", handlers.GetRootLocusHandler())
 	}
 
 	// Rutas protegidas (requieren autenticación)
@@ -106,6 +127,32 @@ func main() {
 		port = "8081"
 	}
 
-	log.Printf("Servidor Gin iniciado en el puerto %s\n", port)
-	log.Fatal(router.Run(":" + port))
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	go func() {
+		log.Printf("Servidor Gin iniciado en el puerto %s\n", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Esperar señal de terminación para apagar de forma ordenada
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Apagando servidor...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error al apagar el servidor: %v", err)
+	}
+
+	// Esperar a que los workers terminen el análisis en curso
+	analysisQueue.Stop()
+	database.CloseDB()
 }
@@ -77,6 +77,37 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// QueryTokenAuthMiddleware autentica con el parámetro "access_token" de la URL cuando no
+// hubo cabecera Authorization. Solo para rutas de streaming (EventSource no permite cabeceras).
+func QueryTokenAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Si ya hay un usuario autenticado por cabecera, no hacer nada
+		if _, ok := GetUserIDFromGin(c); ok {
+			c.Next()
+			return
+		}
+
+		token := c.Query("access_token")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		// Intentar validar el token
+		claims, err := utils.ValidateToken(token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// Guardar userID en el contexto de Gin
+		c.Set("userID", claims.UserID)
+
+		// Continuar con el siguiente handler
+		c.Next()
+	}
+}
+
 // GetUserIDFromGin extrae el ID de usuario del contexto de Gin
 func GetUserIDFromGin(c *gin.Context) (uint, bool) {
 	if userID, exists := c.Get("userID"); exists {
@@ -3,8 +3,12 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"log"
+	"regexp"
 )
 
+// accessTokenPattern detecta tokens enviados por query string para no escribirlos en los logs
+var accessTokenPattern = regexp.MustCompile(`access_token=[^&]*`)
+
 // LoggingMiddleware es un middleware personalizado de logging para Gin
 func LoggingMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -12,7 +16,7 @@ func LoggingMiddleware() gin.HandlerFunc {
 		log.Printf(
 			"%s %s %d %v %s",
 			param.Method,
-			param.Path,
+			accessTokenPattern.ReplaceAllString(param.Path, "access_token=***"),
 			param.StatusCode,
 			param.Latency,
 			param.ClientIP,
@@ -1,28 +1,163 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 
 	"gorm.io/datatypes"
+
+	"backend/control"
+	"backend/dsp"
+	"backend/parser"
+)
+
+// Estados del ciclo de vida de una solicitud de análisis
+const (
+	AnalysisStatusQueued    = "queued"
+	AnalysisStatusRunning   = "running"
+	AnalysisStatusSucceeded = "succeeded"
+	AnalysisStatusFailed    = "failed"
+	AnalysisStatusCancelled = "cancelled"
+)
+
+// Modos de análisis
+const (
+	AnalysisModeStepResponse = "step_response" // Identificación a partir de la respuesta al escalón
+	AnalysisModeSpectral     = "spectral"      // Contenido espectral de la señal (FFT, PSD de Welch)
+)
+
+// Tipos de excitación aplicada al sistema en el modo step_response
+const (
+	ExcitationStep     = "step"     // Escalón de amplitud InputVoltage
+	ExcitationImpulse  = "impulse"  // Impulso de área InputVoltage
+	ExcitationRamp     = "ramp"     // Rampa de pendiente InputVoltage (unidades/s)
+	ExcitationMeasured = "measured" // Entrada medida en una columna del CSV (PRBS, chirp...)
+)
+
+// DefaultInputColumn es la columna del CSV (base cero) que contiene la entrada medida por defecto
+const DefaultInputColumn = 2
+
+// Etapas del pipeline de análisis, notificadas en tiempo real mientras el estado es "running"
+const (
+	AnalysisStageLoadingSeries      = "loading_series"
+	AnalysisStageDownloading        = "downloading"
+	AnalysisStageParsingCSV         = "parsing_csv"
+	AnalysisStagePreprocessing      = "preprocessing"
+	AnalysisStageOptimizing         = "optimizing"
+	AnalysisStageIdentifying        = "identifying"
+	AnalysisStageExtractingFeatures = "extracting_features"
+	AnalysisStagePredictingType     = "ml_type_prediction"
+	AnalysisStagePredictingPoles    = "pole_prediction"
+	AnalysisStageSpectral           = "spectral_analysis"
+	AnalysisStagePersisting         = "persisting"
 )
 
 // AnalysisRequest representa una solicitud de análisis de un documento
 type AnalysisRequest struct {
-	ID           uint      `gorm:"primaryKey;type:serial" json:"id"`
-	DocumentID   uint      `gorm:"column:document_id;not null;index" json:"document_id"`
-	Document     Document  `gorm:"foreignKey:DocumentID" json:"-"`
-	InputVoltage float64   `gorm:"column:input_voltage;not null" json:"input_voltage"`
-	Comment      string    `gorm:"column:comment;size:500" json:"comment,omitempty"`
-	IsProcessed  bool      `gorm:"column:is_processed;default:false" json:"is_processed"`
-	CreatedAt    time.Time `gorm:"column:created_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"created_at"`
-	Results      []Result  `gorm:"foreignKey:AnalysisRequestID" json:"-"`
+	ID           uint           `gorm:"primaryKey;type:serial" json:"id"`
+	DocumentID   uint           `gorm:"column:document_id;not null;index" json:"document_id"`
+	Document     Document       `gorm:"foreignKey:DocumentID" json:"-"`
+	InputVoltage float64        `gorm:"column:input_voltage;not null" json:"input_voltage"`
+	Comment      string         `gorm:"column:comment;size:500" json:"comment,omitempty"`
+	Mode         string         `gorm:"column:mode;size:20;not null;default:step_response" json:"mode"`
+	Options      datatypes.JSON `gorm:"column:options;type:jsonb" json:"options,omitempty"` // AnalysisOptions
+	IsProcessed  bool           `gorm:"column:is_processed;default:false" json:"is_processed"`
+	Status       string         `gorm:"column:status;size:20;not null;default:queued" json:"status"`
+	Stage        string         `gorm:"column:stage;size:30" json:"stage,omitempty"`                   // Etapa actual del pipeline
+	ErrorCode    string         `gorm:"column:error_code;size:50" json:"error_code,omitempty"`         // Código legible por máquina
+	ErrorMessage string         `gorm:"column:error_message;size:1000" json:"error_message,omitempty"` // Mensaje para el usuario
+	StartedAt    *time.Time     `gorm:"column:started_at;type:timestamp with time zone" json:"started_at,omitempty"`
+	FinishedAt   *time.Time     `gorm:"column:finished_at;type:timestamp with time zone" json:"finished_at,omitempty"`
+	CreatedAt    time.Time      `gorm:"column:created_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"created_at"`
+	Results      []Result       `gorm:"foreignKey:AnalysisRequestID" json:"-"`
+}
+
+// IsFinished indica si la solicitud alcanzó un estado terminal
+func (a *AnalysisRequest) IsFinished() bool {
+	return IsFinalAnalysisStatus(a.Status)
+}
+
+// IsFinalAnalysisStatus indica si un estado de análisis es terminal
+func IsFinalAnalysisStatus(status string) bool {
+	return status == AnalysisStatusSucceeded || status == AnalysisStatusFailed || status == AnalysisStatusCancelled
+}
+
+// ParseOptions decodifica las opciones del análisis (vacías si no se guardaron)
+func (a *AnalysisRequest) ParseOptions() (AnalysisOptions, error) {
+	var opts AnalysisOptions
+	if len(a.Options) == 0 {
+		return opts, nil
+	}
+	err := json.Unmarshal(a.Options, &opts)
+	return opts, err
+}
+
+// IsValidAnalysisMode indica si un modo de análisis es válido (vacío equivale a step_response)
+func IsValidAnalysisMode(mode string) bool {
+	return mode == "" || mode == AnalysisModeStepResponse || mode == AnalysisModeSpectral
+}
+
+// AnalysisOptions son los parámetros opcionales de cada modo de análisis
+type AnalysisOptions struct {
+	Spectral      *dsp.SpectralOptions        `json:"spectral,omitempty"`
+	Excitation    *ExcitationOptions          `json:"excitation,omitempty"`
+	Parsing       *parser.Options             `json:"parsing,omitempty"`
+	Preprocessing *dsp.PreprocessOptions      `json:"preprocessing,omitempty"`
+	Metrics       *control.StepMetricsOptions `json:"metrics,omitempty"`
+	Discrete      *control.DiscreteOptions    `json:"discrete,omitempty"`
+}
+
+// ExcitationOptions describe la señal de entrada aplicada durante el experimento.
+// InputVoltage es la amplitud del escalón, el área del impulso o la pendiente de la rampa,
+// y se ignora cuando la entrada es medida.
+type ExcitationOptions struct {
+	Type        string   `json:"type"`                   // step, impulse, ramp o measured
+	InputColumn *int     `json:"input_column,omitempty"` // Columna de la entrada medida (base cero, por defecto 2; parsing.columns.input tiene prioridad)
+	StartTime   *float64 `json:"start_time,omitempty"`   // Instante del escalón o del impulso, o inicio de la rampa (por defecto se detecta)
+}
+
+// Validate comprueba que las opciones de excitación sean coherentes
+func (e ExcitationOptions) Validate() error {
+	switch e.Type {
+	case ExcitationStep, ExcitationImpulse, ExcitationRamp, ExcitationMeasured:
+	default:
+		return errors.New("excitation.type debe ser step, impulse, ramp o measured")
+	}
+	if e.InputColumn != nil && *e.InputColumn < 0 {
+		return errors.New("excitation.input_column no puede ser negativa")
+	}
+	return nil
+}
+
+// Column devuelve la columna del CSV con la entrada medida
+func (e ExcitationOptions) Column() int {
+	if e.InputColumn != nil {
+		return *e.InputColumn
+	}
+	return DefaultInputColumn
+}
+
+// ExcitationType devuelve el tipo de excitación de las opciones (escalón si no se indicó)
+func (o AnalysisOptions) ExcitationType() string {
+	if o.Excitation == nil || o.Excitation.Type == "" {
+		return ExcitationStep
+	}
+	return o.Excitation.Type
 }
 
 // AnalysisRequestCreate para solicitar un nuevo análisis
 type AnalysisRequestCreate struct {
-	DocumentID   uint    `json:"document_id"`
-	InputVoltage float64 `json:"input_voltage"`
-	Comment      string  `json:"comment,omitempty"`
+	DocumentID    uint                        `json:"document_id"`
+	InputVoltage  float64                     `json:"input_voltage"`
+	Comment       string                      `json:"comment,omitempty"`
+	Mode          string                      `json:"mode,omitempty"`          // step_response (por defecto) o spectral
+	Spectral      *dsp.SpectralOptions        `json:"spectral,omitempty"`      // Opciones del modo spectral
+	Excitation    *ExcitationOptions          `json:"excitation,omitempty"`    // Entrada aplicada (modo step_response, por defecto escalón)
+	Parsing       *parser.Options             `json:"parsing,omitempty"`       // Delimitador, separador decimal y asignación de columnas
+	Preprocessing *dsp.PreprocessOptions      `json:"preprocessing,omitempty"` // Filtrado, remuestreo y recorte antes del análisis
+	Metrics       *control.StepMetricsOptions `json:"metrics,omitempty"`       // Banda de establecimiento de las métricas transitorias
+	Discrete      *control.DiscreteOptions    `json:"discrete,omitempty"`      // Identificación discreta ARX/ARMAX/OE (se omite si no se indica)
 }
 
 // Result representa el resultado del análisis ML de un documento
@@ -45,12 +180,34 @@ type Result struct {
 	MLPolo2Real     *float64 `gorm:"column:ml_polo2_real" json:"ml_polo2_real,omitempty"`
 	MLPolo2Imag     *float64 `gorm:"column:ml_polo2_imag" json:"ml_polo2_imag,omitempty"`
 	MLConfidence    *float64 `gorm:"column:ml_confidence" json:"ml_confidence,omitempty"`
+
+	// Modelo de segundo orden identificado analíticamente en Go
+	AnalyticModel datatypes.JSON `gorm:"column:analytic_model;type:jsonb" json:"analytic_model,omitempty"`
+	FitPolo1Real  *float64       `gorm:"column:fit_polo1_real" json:"fit_polo1_real,omitempty"`
+	FitPolo1Imag  *float64       `gorm:"column:fit_polo1_imag" json:"fit_polo1_imag,omitempty"`
+	FitPolo2Real  *float64       `gorm:"column:fit_polo2_real" json:"fit_polo2_real,omitempty"`
+	FitPolo2Imag  *float64       `gorm:"column:fit_polo2_imag" json:"fit_polo2_imag,omitempty"`
+
+	// Tiempo muerto θ y el instante del escalón en la base de tiempo del archivo
+	DeadTime *float64 `gorm:"column:dead_time" json:"dead_time,omitempty"`
+	StepTime *float64 `gorm:"column:step_time" json:"step_time,omitempty"`
+
+	// Modelos de proceso con tiempo muerto (FOPDT/SOPDT) por cada método y el de mejor ajuste
+	ProcessModel datatypes.JSON `gorm:"column:process_model;type:jsonb" json:"process_model,omitempty"`
+
+	// Análisis espectral (modo spectral)
+	Spectrum datatypes.JSON `gorm:"column:spectrum;type:jsonb" json:"spectrum,omitempty"`
+
+	// Modelo discreto ARX/ARMAX/OE (solo si se pidió en las opciones)
+	DiscreteModel *DiscreteModel `gorm:"foreignKey:ResultID" json:"discrete_model,omitempty"`
 }
 
 // GraphData estructura para almacenar datos de tiempo y salida para gráficas
 type GraphData struct {
-	Time   []float64 `json:"time"`
-	Output []float64 `json:"output"`
+	Time      []float64 `json:"time"`
+	Output    []float64 `json:"output"`
+	Simulated []float64 `json:"simulated,omitempty"` // Respuesta simulada del modelo identificado
+	Input     []float64 `json:"input,omitempty"`     // Entrada aplicada (excitaciones distintas del escalón)
 }
 
 // ResultResponse es la respuesta completa de un análisis
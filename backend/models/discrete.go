@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// DiscreteModel es el modelo discreto (ARX, ARMAX u OE) identificado para un resultado, al
+// período de muestreo con el que se estimó. Los coeficientes están en potencias de z⁻¹:
+// A = [1, a1, ..., ana] (F en OE), B = [b1, ..., bnb] a partir de z^-nk y C = [1, c1, ..., cnc].
+type DiscreteModel struct {
+	ID               uint           `gorm:"primaryKey;type:serial" json:"id"`
+	ResultID         uint           `gorm:"column:result_id;not null;uniqueIndex" json:"result_id"`
+	Structure        string         `gorm:"column:structure;size:10;not null" json:"structure"`
+	Na               int            `gorm:"column:na;not null" json:"na"`
+	Nb               int            `gorm:"column:nb;not null" json:"nb"`
+	Nc               int            `gorm:"column:nc;not null;default:0" json:"nc"`
+	Nk               int            `gorm:"column:nk;not null" json:"nk"`
+	SamplingPeriod   float64        `gorm:"column:sampling_period;not null" json:"sampling_period"`
+	A                datatypes.JSON `gorm:"column:a;type:jsonb;not null" json:"a"`
+	B                datatypes.JSON `gorm:"column:b;type:jsonb;not null" json:"b"`
+	C                datatypes.JSON `gorm:"column:c;type:jsonb" json:"c,omitempty"`
+	TransferFunction datatypes.JSON `gorm:"column:transfer_function;type:jsonb" json:"transfer_function"` // B(z)/A(z) en potencias de z
+	NoiseVariance    float64        `gorm:"column:noise_variance" json:"noise_variance"`
+	AIC              float64        `gorm:"column:aic" json:"aic"`
+	BIC              float64        `gorm:"column:bic" json:"bic"`
+	FitPercent       float64        `gorm:"column:fit_percent" json:"fit_percent"`
+	Criterion        string         `gorm:"column:criterion;size:10" json:"criterion"`
+	Candidates       datatypes.JSON `gorm:"column:candidates;type:jsonb" json:"candidates,omitempty"`
+
+	// Equivalentes continuos de B(z)/A(z) (nulos si la conversión no existe)
+	ContinuousZOH    datatypes.JSON `gorm:"column:continuous_zoh;type:jsonb" json:"continuous_zoh,omitempty"`
+	ContinuousTustin datatypes.JSON `gorm:"column:continuous_tustin;type:jsonb" json:"continuous_tustin,omitempty"`
+
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"backend/parser"
 )
 
 // Document representa un archivo CSV subido al sistema
@@ -24,11 +26,46 @@ type DocumentUploadRequest struct {
 
 // DocumentResponse es la respuesta enviada al cliente
 type DocumentResponse struct {
-	ID               uint      `json:"id"`
-	OriginalFilename string    `json:"original_filename"`
-	UploadDate       time.Time `json:"upload_date"`
-	AnalysisCount    int       `json:"analysis_count"` // Número de análisis realizados
-	IsDeleted        bool      `json:"is_deleted"`
+	ID               uint           `json:"id"`
+	OriginalFilename string         `json:"original_filename"`
+	UploadDate       time.Time      `json:"upload_date"`
+	AnalysisCount    int            `json:"analysis_count"` // Número de análisis realizados
+	IsDeleted        bool           `json:"is_deleted"`
+	Preview          *SignalPreview `json:"preview,omitempty"` // Solo en la respuesta de la subida
+}
+
+// Códigos de los problemas detectados al validar la señal subida
+const (
+	SignalProblemUnreadable    = "unreadable"
+	SignalProblemTooFewSamples = "too_few_samples"
+	SignalProblemNonMonotonic  = "non_monotonic_time"
+	SignalProblemNonFinite     = "non_finite_values"
+	SignalProblemNoStep        = "no_step"
+)
+
+// SignalProblem es un motivo por el que la señal no puede analizarse
+type SignalProblem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SignalPreview resume la señal leída para mostrarla antes de analizarla
+type SignalPreview struct {
+	Samples              int       `json:"samples"`
+	SamplingPeriod       float64   `json:"sampling_period"`
+	SamplingPeriodSource string    `json:"sampling_period_source"`
+	Duration             float64   `json:"duration"`
+	Min                  float64   `json:"min"`
+	Max                  float64   `json:"max"`
+	Sparkline            []float64 `json:"sparkline"` // Salida promediada en tramos iguales
+}
+
+// SignalValidation es el resultado de validar un archivo antes de guardarlo
+type SignalValidation struct {
+	Valid    bool            `json:"valid"`
+	Problems []SignalProblem `json:"problems,omitempty"`
+	Preview  *SignalPreview  `json:"preview,omitempty"`
+	Report   *parser.Report  `json:"report,omitempty"`
 }
 
 // ToDocumentResponse convierte un Document a DocumentResponse
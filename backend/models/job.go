@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Estados posibles de un trabajo en la cola de análisis
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// AnalysisJob representa un trabajo persistente de la cola de análisis
+type AnalysisJob struct {
+	ID                uint       `gorm:"primaryKey;type:serial" json:"id"`
+	AnalysisRequestID uint       `gorm:"column:analysis_request_id;not null;uniqueIndex" json:"analysis_request_id"`
+	Status            string     `gorm:"column:status;size:20;not null;default:queued;index" json:"status"`
+	Attempts          int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	MaxAttempts       int        `gorm:"column:max_attempts;not null;default:5" json:"max_attempts"`
+	RunAt             time.Time  `gorm:"column:run_at;type:timestamp with time zone;not null;default:CURRENT_TIMESTAMP;index" json:"run_at"`
+	LockedAt          *time.Time `gorm:"column:locked_at;type:timestamp with time zone" json:"locked_at,omitempty"`
+	LockedBy          string     `gorm:"column:locked_by;size:100" json:"locked_by,omitempty"`
+	LastError         string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	CreatedAt         time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+
+	"backend/parser"
+)
+
+// ErrSeriesChecksum indica que las columnas guardadas no coinciden con su checksum
+var ErrSeriesChecksum = errors.New("las muestras guardadas no coinciden con su checksum")
+
+// DocumentSeries es la serie leída del archivo de un documento, guardada a resolución completa
+// para que los análisis no tengan que descargar y leer el archivo otra vez. Hay una por cada
+// combinación de documento y opciones de lectura; cada columna se guarda como un bloque de
+// float64 little-endian y el checksum SHA-256 cubre las tres columnas.
+type DocumentSeries struct {
+	ID             uint           `gorm:"primaryKey;type:serial" json:"id"`
+	DocumentID     uint           `gorm:"column:document_id;not null;uniqueIndex:idx_document_series_options" json:"document_id"`
+	OptionsHash    string         `gorm:"column:options_hash;size:64;not null;uniqueIndex:idx_document_series_options" json:"options_hash"`
+	Samples        int            `gorm:"column:samples;not null" json:"samples"`
+	SamplingPeriod float64        `gorm:"column:sampling_period;not null" json:"sampling_period"`
+	TimeData       []byte         `gorm:"column:time_data;type:bytea;not null" json:"-"`
+	OutputData     []byte         `gorm:"column:output_data;type:bytea;not null" json:"-"`
+	InputData      []byte         `gorm:"column:input_data;type:bytea" json:"-"` // Vacío si no hay entrada medida
+	Checksum       string         `gorm:"column:checksum;size:64;not null" json:"checksum"`
+	Report         datatypes.JSON `gorm:"column:report;type:jsonb" json:"report"`
+	CreatedAt      time.Time      `gorm:"column:created_at;type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ParseOptionsHash identifica las opciones de lectura con las que se obtuvo una serie
+func ParseOptionsHash(opts parser.Options) string {
+	data, _ := json.Marshal(opts)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewDocumentSeries codifica una serie leída para guardarla
+func NewDocumentSeries(documentID uint, opts parser.Options, series *parser.Series, report *parser.Report) *DocumentSeries {
+	reportJSON, _ := json.Marshal(report)
+	s := &DocumentSeries{
+		DocumentID:     documentID,
+		OptionsHash:    ParseOptionsHash(opts),
+		Samples:        len(series.Time),
+		SamplingPeriod: series.SamplingPeriod,
+		TimeData:       encodeFloat64s(series.Time),
+		OutputData:     encodeFloat64s(series.Output),
+		InputData:      encodeFloat64s(series.Input),
+		Report:         datatypes.JSON(reportJSON),
+	}
+	s.Checksum = s.computeChecksum()
+	return s
+}
+
+// Series decodifica la serie y el reporte de lectura, comprobando el checksum y las longitudes
+func (s *DocumentSeries) Series() (*parser.Series, *parser.Report, error) {
+	if s.computeChecksum() != s.Checksum {
+		return nil, nil, ErrSeriesChecksum
+	}
+	series := &parser.Series{
+		Time:           decodeFloat64s(s.TimeData),
+		Output:         decodeFloat64s(s.OutputData),
+		Input:          decodeFloat64s(s.InputData),
+		SamplingPeriod: s.SamplingPeriod,
+	}
+	if len(series.Time) != s.Samples || len(series.Output) != s.Samples || (len(series.Input) != 0 && len(series.Input) != s.Samples) {
+		return nil, nil, fmt.Errorf("la serie guardada tiene columnas de distinta longitud (se esperaban %d muestras)", s.Samples)
+	}
+	var report parser.Report
+	if len(s.Report) > 0 {
+		if err := json.Unmarshal(s.Report, &report); err != nil {
+			return nil, nil, fmt.Errorf("reporte de lectura inválido: %w", err)
+		}
+	}
+	return series, &report, nil
+}
+
+// computeChecksum calcula el SHA-256 de las tres columnas, cada una precedida de su longitud
+func (s *DocumentSeries) computeChecksum() string {
+	h := sha256.New()
+	var size [8]byte
+	for _, column := range [][]byte{s.TimeData, s.OutputData, s.InputData} {
+		binary.LittleEndian.PutUint64(size[:], uint64(len(column)))
+		h.Write(size[:])
+		h.Write(column)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SeriesRange devuelve los índices [lo, hi) de las muestras con start <= t <= end en un
+// vector de tiempo creciente; start o end nulos no limitan ese extremo
+func SeriesRange(t []float64, start, end *float64) (int, int) {
+	lo, hi := 0, len(t)
+	if start != nil {
+		lo = sort.SearchFloat64s(t, *start)
+	}
+	if end != nil {
+		hi = sort.Search(len(t), func(i int) bool { return t[i] > *end })
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// encodeFloat64s codifica valores como float64 little-endian
+func encodeFloat64s(values []float64) []byte {
+	out := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(out[8*i:], math.Float64bits(v))
+	}
+	return out
+}
+
+// decodeFloat64s decodifica un bloque de float64 little-endian (nil si está vacío)
+func decodeFloat64s(data []byte) []float64 {
+	if len(data) == 0 {
+		return nil
+	}
+	out := make([]float64, len(data)/8)
+	for i := range out {
+		out[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return out
+}
@@ -0,0 +1,336 @@
+package parser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Formatos binarios y científicos reconocidos
+const (
+	FormatWAV     = "wav"
+	FormatMAT     = "mat"
+	FormatHDF5    = "hdf5"
+	FormatParquet = "parquet"
+)
+
+// Límites de seguridad para archivos binarios: cantidad de valores de una variable y
+// tamaño de un bloque descomprimido
+const (
+	maxBinaryValues     = 20000000
+	maxDecompressedSize = 256 << 20
+)
+
+// Firmas de los formatos binarios
+var (
+	wavRIFF       = []byte("RIFF")
+	wavWAVE       = []byte("WAVE")
+	matV5Header   = []byte("MATLAB 5.0 MAT-file")
+	matV73Header  = []byte("MATLAB 7.3 MAT-file")
+	hdf5Signature = []byte{0x89, 'H', 'D', 'F', '\r', '\n', 0x1a, '\n'}
+	parquetMagic  = []byte("PAR1")
+)
+
+// Posiciones posibles del superbloque HDF5 (después de un bloque de usuario de 0, 512,
+// 1024... bytes; MATLAB 7.3 usa 512)
+var hdf5SuperblockOffsets = []int{0, 512, 1024, 2048}
+
+// SignatureLength es la cantidad de bytes iniciales que necesita CheckSignature
+const SignatureLength = 2048 + 8
+
+// Formato esperado para cada extensión admitida ("" para los formatos de texto)
+var extensionFormats = map[string]string{
+	".csv":     "",
+	".txt":     "",
+	".wav":     FormatWAV,
+	".mat":     FormatMAT,
+	".h5":      FormatHDF5,
+	".hdf5":    FormatHDF5,
+	".parquet": FormatParquet,
+}
+
+// SupportedExtension indica si la extensión (con punto) corresponde a un formato admitido
+func SupportedExtension(ext string) bool {
+	_, ok := extensionFormats[strings.ToLower(ext)]
+	return ok
+}
+
+// SupportedExtensions devuelve las extensiones admitidas para los mensajes de error
+func SupportedExtensions() string {
+	return ".csv, .txt, .wav, .mat, .h5, .hdf5, .parquet"
+}
+
+// CheckSignature verifica que el inicio del archivo (al menos SignatureLength bytes si
+// los hay) corresponda al formato que declara su extensión
+func CheckSignature(filename string, head []byte) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	format, ok := extensionFormats[ext]
+	if !ok {
+		return fmt.Errorf("la extensión %q no está admitida (se admiten %s)", ext, SupportedExtensions())
+	}
+	if len(head) == 0 {
+		return errors.New("el archivo está vacío")
+	}
+
+	detected := detectBinaryFormat(head)
+	switch format {
+	case "":
+		if detected != "" || bytes.IndexByte(head, 0) >= 0 && !isUTF16(head) {
+			return fmt.Errorf("el archivo %s no es de texto", ext)
+		}
+	case FormatWAV:
+		if detected != FormatWAV {
+			return errors.New("el archivo .wav no tiene la cabecera RIFF/WAVE")
+		}
+		if _, err := readWAVFormat(head); err != nil {
+			return err
+		}
+	case FormatMAT:
+		if detected != FormatMAT {
+			return errors.New("el archivo .mat no tiene la cabecera de MATLAB 5.0 o 7.3")
+		}
+	case FormatHDF5:
+		if detected != FormatHDF5 {
+			return errors.New("el archivo no tiene la firma de HDF5")
+		}
+	case FormatParquet:
+		if detected != FormatParquet {
+			return errors.New("el archivo .parquet no empieza con la firma PAR1")
+		}
+	}
+	return nil
+}
+
+// isUTF16 indica si el texto empieza con la marca de orden de bytes de UTF-16
+func isUTF16(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF})
+}
+
+// detectBinaryFormat reconoce los formatos binarios por su firma ("" si es texto)
+func detectBinaryFormat(data []byte) string {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[:4], wavRIFF) && bytes.Equal(data[8:12], wavWAVE):
+		return FormatWAV
+	case bytes.HasPrefix(data, matV5Header) || bytes.HasPrefix(data, matV73Header):
+		return FormatMAT
+	case bytes.HasPrefix(data, parquetMagic):
+		return FormatParquet
+	case hdf5Superblock(data) >= 0:
+		return FormatHDF5
+	}
+	return ""
+}
+
+// hdf5Superblock devuelve la posición del superbloque HDF5 (-1 si no hay)
+func hdf5Superblock(data []byte) int {
+	for _, off := range hdf5SuperblockOffsets {
+		if len(data) >= off+len(hdf5Signature) && bytes.Equal(data[off:off+len(hdf5Signature)], hdf5Signature) {
+			return off
+		}
+	}
+	return -1
+}
+
+// vector es una variable numérica de un archivo binario
+type vector struct {
+	name   string
+	unit   string
+	values []float64
+}
+
+// seriesFromVectors arma la serie a partir de las variables numéricas del archivo, que
+// hacen de columnas (en el orden en que aparecen) para la asignación de columnas. Si no hay
+// un vector de tiempo y el archivo declara el período de muestreo (o tiene una sola
+// variable) se agrega un vector de tiempo como columna 0.
+func seriesFromVectors(vectors []vector, opts Options, report *Report) (*Series, error) {
+	if len(vectors) == 0 {
+		return nil, ErrNoData
+	}
+	if opts.Columns.Time == nil && !hasTimeVector(vectors) && (report.declaredPeriod > 0 || len(vectors) == 1) {
+		dt := report.declaredPeriod
+		if dt <= 0 {
+			dt = DefaultSamplingPeriod
+			report.assumedPeriod = true
+		}
+		n := 0
+		for _, v := range vectors {
+			n = max(n, len(v.values))
+		}
+		t := make([]float64, n)
+		for i := range t {
+			t[i] = float64(i) * dt
+		}
+		vectors = append([]vector{{name: "time", unit: "s", values: t}}, vectors...)
+	}
+
+	headers := make([]string, len(vectors))
+	units := make([]string, len(vectors))
+	for i, v := range vectors {
+		headers[i], units[i] = v.name, v.unit
+	}
+	report.Headers = headers
+
+	columns, err := resolveColumns(headers, units, len(vectors), opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range columns {
+		report.Columns = append(report.Columns, c.info)
+	}
+
+	n := len(vectors[columns[0].info.Index].values)
+	for _, c := range columns[1:] {
+		if m := len(vectors[c.info.Index].values); m != n {
+			return nil, fmt.Errorf("%w: las variables %q y %q tienen distinta longitud (%d y %d)",
+				ErrInvalidColumns, columns[0].info.Name, c.info.Name, n, m)
+		}
+	}
+
+	series := &Series{}
+	row := make([]float64, len(columns))
+rows:
+	for i := 0; i < n; i++ {
+		for k, c := range columns {
+			v := vectors[c.info.Index].values[i]
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				report.RowsNonFinite++
+				report.reject(i+1, fmt.Sprintf("valor no finito en la columna %d (%s): %v", c.info.Index, c.info.Role, v))
+				continue rows
+			}
+			row[k] = v * c.scale
+		}
+		series.Time = append(series.Time, row[0])
+		series.Output = append(series.Output, row[1])
+		if len(columns) > 2 {
+			series.Input = append(series.Input, row[2])
+		}
+		report.RowsAccepted++
+	}
+	if report.RowsAccepted == 0 {
+		return nil, ErrNoData
+	}
+	return series, nil
+}
+
+// hasTimeVector indica si alguna variable tiene un nombre de tiempo reconocido
+func hasTimeVector(vectors []vector) bool {
+	for _, v := range vectors {
+		label, _ := splitHeaderUnit(v.name)
+		for _, n := range timeNames {
+			if strings.EqualFold(label, n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Nombres de variables escalares que declaran el período o la frecuencia de muestreo
+var (
+	periodScalarNames = []string{"ts", "dt", "sample_time", "sampletime", "sampling_period", "sample_period", "xinc"}
+	rateScalarNames   = []string{"fs", "sample_rate", "samplerate", "sampling_rate", "sampling_frequency"}
+)
+
+// readScalar registra una variable escalar en los metadatos y, si su nombre declara el
+// período o la frecuencia de muestreo, lo toma como período declarado
+func readScalar(report *Report, name string, value float64) {
+	report.Metadata[name] = fmt.Sprintf("%g", value)
+	if value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return
+	}
+	leaf := strings.ToLower(name[strings.LastIndexAny(name, "/.")+1:])
+	for _, n := range periodScalarNames {
+		if leaf == n {
+			report.declaredPeriod = value
+		}
+	}
+	for _, n := range rateScalarNames {
+		if leaf == n {
+			report.declaredPeriod = 1 / value
+		}
+	}
+}
+
+// splitMatrix convierte una matriz (filas × columnas en orden de filas) en vectores,
+// tomando como muestras la dimensión más larga
+func splitMatrix(name string, values []float64, rows, cols int) []vector {
+	if rows == 1 || cols == 1 {
+		return []vector{{name: name, values: values}}
+	}
+	if rows >= cols {
+		out := make([]vector, cols)
+		for j := range out {
+			col := make([]float64, rows)
+			for i := range col {
+				col[i] = values[i*cols+j]
+			}
+			out[j] = vector{name: fmt.Sprintf("%s_%d", name, j+1), values: col}
+		}
+		return out
+	}
+	out := make([]vector, rows)
+	for i := range out {
+		out[i] = vector{name: fmt.Sprintf("%s_%d", name, i+1), values: values[i*cols : (i+1)*cols]}
+	}
+	return out
+}
+
+// inflate descomprime un bloque zlib con un límite de tamaño
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxDecompressedSize {
+		return nil, fmt.Errorf("%w: bloque comprimido demasiado grande", ErrUnsupportedFormat)
+	}
+	return out, nil
+}
+
+// decodeNumbers convierte valores binarios de tamaño size (enteros con o sin signo o
+// flotantes) a float64
+func decodeNumbers(data []byte, order binary.ByteOrder, size int, float, signed bool) ([]float64, error) {
+	if size <= 0 || len(data)%size != 0 {
+		return nil, fmt.Errorf("%w: %d bytes no son múltiplo del tamaño de elemento %d", ErrMalformed, len(data), size)
+	}
+	out := make([]float64, len(data)/size)
+	for i := range out {
+		b := data[i*size : (i+1)*size]
+		switch {
+		case float && size == 4:
+			out[i] = float64(math.Float32frombits(order.Uint32(b)))
+		case float && size == 8:
+			out[i] = math.Float64frombits(order.Uint64(b))
+		case size == 1 && signed:
+			out[i] = float64(int8(b[0]))
+		case size == 1:
+			out[i] = float64(b[0])
+		case size == 2 && signed:
+			out[i] = float64(int16(order.Uint16(b)))
+		case size == 2:
+			out[i] = float64(order.Uint16(b))
+		case size == 4 && signed:
+			out[i] = float64(int32(order.Uint32(b)))
+		case size == 4:
+			out[i] = float64(order.Uint32(b))
+		case size == 8 && signed:
+			out[i] = float64(int64(order.Uint64(b)))
+		case size == 8:
+			out[i] = float64(order.Uint64(b))
+		default:
+			return nil, fmt.Errorf("%w: elementos de %d bytes", ErrUnsupportedFormat, size)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Las muestras de testdata (ver testdata/generate.go) tienen 100 valores cada 10 ms
+const (
+	sampleCount  = 100
+	samplePeriod = 0.01
+)
+
+func firstOrder(t float64) float64 { return 1 - math.Exp(-t/0.2) }
+
+func unitStep(t float64) float64 {
+	if t >= 0.245 {
+		return 1
+	}
+	return 0
+}
+
+func staircase(t float64) float64 {
+	switch {
+	case t < 0.245:
+		return 0
+	case t < 0.495:
+		return 0.5
+	}
+	return 1
+}
+
+func constant(v float64) func(float64) float64 {
+	return func(float64) float64 { return v }
+}
+
+// TestParseBinaryFormats lee las muestras de cada formato binario y compara el tiempo, la
+// salida y la entrada decodificados con la señal con que se generaron
+func TestParseBinaryFormats(t *testing.T) {
+	cases := []struct {
+		file      string
+		columns   ColumnMapping
+		format    string
+		output    func(float64) float64
+		input     func(float64) float64 // nil si no se lee la entrada
+		tolerance float64               // Además de 1e-12 por el redondeo del tiempo
+		source    string                // Origen del período de muestreo
+		metadata  map[string]string
+	}{
+		{
+			file:      "pcm16.wav",
+			columns:   ColumnMapping{Input: &ColumnRef{Name: "CH2"}},
+			format:    FormatWAV,
+			output:    firstOrder,
+			input:     constant(0.5),
+			tolerance: 1e-4,
+			source:    SamplingFromMetadata,
+			metadata:  map[string]string{"Title": "prueba", "Software": "generate.go", "Channels": "2", "Encoding": "pcm"},
+		},
+		{
+			file:      "float32.wav",
+			format:    FormatWAV,
+			output:    firstOrder,
+			tolerance: 1e-7,
+			source:    SamplingFromMetadata,
+			metadata:  map[string]string{"Bits Per Sample": "32", "Encoding": "float"},
+		},
+		{
+			file:     "compressed.mat",
+			columns:  ColumnMapping{Input: &ColumnRef{Name: "u"}},
+			format:   FormatMAT,
+			output:   firstOrder,
+			input:    unitStep,
+			source:   SamplingFromMetadata,
+			metadata: map[string]string{"Ts": "0.01"},
+		},
+		{
+			file:      "struct.mat",
+			columns:   ColumnMapping{Time: &ColumnRef{Name: "scope.time"}, Output: &ColumnRef{Name: "scope.output"}},
+			format:    FormatMAT,
+			output:    firstOrder,
+			tolerance: 1e-7,
+			source:    SamplingFromMetadata,
+			metadata:  map[string]string{"scope.fs": "100"},
+		},
+		{
+			file:    "contiguous.h5",
+			columns: ColumnMapping{Input: &ColumnRef{Name: "input"}},
+			format:  FormatHDF5,
+			output:  firstOrder,
+			input:   unitStep,
+			source:  SamplingFromData,
+		},
+		{
+			file:     "chunked.h5",
+			format:   FormatHDF5,
+			output:   firstOrder,
+			source:   SamplingFromMetadata,
+			metadata: map[string]string{"scope/dt": "0.01"},
+		},
+		{
+			file:     "plain.parquet",
+			columns:  ColumnMapping{Input: &ColumnRef{Name: "input"}},
+			format:   FormatParquet,
+			output:   firstOrder,
+			input:    unitStep,
+			source:   SamplingFromData,
+			metadata: map[string]string{"Time Origin": "1760000000.000000", "fuente": "generate.go"},
+		},
+		{
+			file:   "dictionary.parquet",
+			format: FormatParquet,
+			output: staircase,
+			source: SamplingFromData,
+		},
+		{
+			file:   "snappy.parquet",
+			format: FormatParquet,
+			output: unitStep,
+			source: SamplingFromData,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tolerance := max(tc.tolerance, 1e-12)
+			series, report, err := Parse(bytes.NewReader(data), Options{Columns: tc.columns})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Format != tc.format {
+				t.Errorf("formato %q, se esperaba %q", report.Format, tc.format)
+			}
+			if len(series.Time) != sampleCount || len(series.Output) != sampleCount || report.RowsAccepted != sampleCount {
+				t.Fatalf("%d tiempos, %d salidas y %d filas aceptadas, se esperaban %d",
+					len(series.Time), len(series.Output), report.RowsAccepted, sampleCount)
+			}
+			if math.Abs(series.SamplingPeriod-samplePeriod) > 1e-12 || report.SamplingPeriodSource != tc.source {
+				t.Errorf("período %g (%s), se esperaba %g (%s)", series.SamplingPeriod, report.SamplingPeriodSource, samplePeriod, tc.source)
+			}
+			for key, want := range tc.metadata {
+				if got := report.Metadata[key]; got != want {
+					t.Errorf("metadato %q = %q, se esperaba %q", key, got, want)
+				}
+			}
+
+			for i, ti := range series.Time {
+				if want := float64(i) * samplePeriod; math.Abs(ti-want) > 1e-9 {
+					t.Fatalf("tiempo[%d] = %g, se esperaba %g", i, ti, want)
+				}
+				if want := tc.output(ti); math.Abs(series.Output[i]-want) > tolerance {
+					t.Fatalf("salida[%d] = %g, se esperaba %g", i, series.Output[i], want)
+				}
+			}
+
+			if tc.input == nil {
+				if series.Input != nil {
+					t.Errorf("se leyó una entrada que no se pidió")
+				}
+				return
+			}
+			if len(series.Input) != sampleCount {
+				t.Fatalf("%d entradas, se esperaban %d", len(series.Input), sampleCount)
+			}
+			for i, ti := range series.Time {
+				if want := tc.input(ti); math.Abs(series.Input[i]-want) > tolerance {
+					t.Fatalf("entrada[%d] = %g, se esperaba %g", i, series.Input[i], want)
+				}
+			}
+		})
+	}
+}
+
+// FuzzParse comprueba que ningún archivo haga entrar en pánico al parser ni devuelva series
+// de longitudes distintas. Las muestras grandes hacen lenta la minimización:
+//
+//	go test ./parser -run '^$' -fuzz FuzzParse -fuzzminimizetime 2s
+func FuzzParse(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		if filepath.Ext(file) == ".go" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("tiempo;salida\n0;0\n0,01;0,5\n0,02;1\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		series, report, err := Parse(bytes.NewReader(data), Options{})
+		if err != nil {
+			return
+		}
+		if len(series.Output) != len(series.Time) || len(series.Output) != report.RowsAccepted {
+			t.Fatalf("%d tiempos, %d salidas y %d filas aceptadas", len(series.Time), len(series.Output), report.RowsAccepted)
+		}
+		if series.Input != nil && len(series.Input) != len(series.Time) {
+			t.Fatalf("%d entradas para %d tiempos", len(series.Input), len(series.Time))
+		}
+	})
+}
@@ -0,0 +1,572 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formatos de archivo reconocidos
+const (
+	FormatCSV       = "csv"
+	FormatRigol     = "rigol"
+	FormatTektronix = "tektronix"
+	FormatKeysight  = "keysight"
+	FormatLTspice   = "ltspice"
+)
+
+var (
+	// ErrUnsupportedFormat indica un archivo reconocido cuyo contenido no puede analizarse
+	ErrUnsupportedFormat = errors.New("formato de archivo no soportado")
+	// ErrMalformed indica un preámbulo de instrumento o una estructura binaria incompleta o inválida
+	ErrMalformed = errors.New("archivo con estructura inválida")
+)
+
+// Líneas iniciales examinadas para detectar el formato
+const detectLines = 40
+
+// Máximo de muestras al remuestrear exportaciones con paso variable
+const maxResampledPoints = 1000000
+
+// Nombres de trazas de LTspice: V(n001), I(R1), Ix(U1:OUT)...
+var traceNamePattern = regexp.MustCompile(`(?i)^(v|i|ix)\(.+\)$`)
+
+// Instrument son los metadatos del osciloscopio o simulador que generó el archivo
+type Instrument struct {
+	Vendor          string   `json:"vendor"`
+	Model           string   `json:"model,omitempty"`
+	Channel         string   `json:"channel,omitempty"`
+	RecordLength    int      `json:"record_length,omitempty"`
+	SampleInterval  float64  `json:"sample_interval,omitempty"` // s
+	VerticalScale   *float64 `json:"vertical_scale,omitempty"`  // Unidades por división
+	VerticalOffset  *float64 `json:"vertical_offset,omitempty"`
+	VerticalUnits   string   `json:"vertical_units,omitempty"`
+	HorizontalScale *float64 `json:"horizontal_scale,omitempty"` // s por división
+	TriggerPosition *float64 `json:"trigger_position,omitempty"` // Muestra del disparo
+	TriggerOffset   *float64 `json:"trigger_offset,omitempty"`   // Tiempo del disparo desde la primera muestra (s)
+	Traces          []string `json:"traces,omitempty"`           // Trazas exportadas (LTspice)
+	Runs            int      `json:"runs,omitempty"`             // Corridas .step del archivo (se usa la primera)
+	Resampled       bool     `json:"resampled,omitempty"`        // Remuestreado a paso uniforme
+	OriginalPoints  int      `json:"original_points,omitempty"`  // Muestras antes de remuestrear
+}
+
+// ValidFormat indica si el nombre de formato es válido (vacío equivale a detectarlo)
+func ValidFormat(format string) bool {
+	switch format {
+	case "", FormatCSV, FormatRigol, FormatTektronix, FormatKeysight, FormatLTspice,
+		FormatWAV, FormatMAT, FormatHDF5, FormatParquet:
+		return true
+	}
+	return false
+}
+
+// detectFormat reconoce el formato por la cabecera y las claves del preámbulo
+func detectFormat(lines []string) string {
+	var first []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			first = append(first, line)
+		}
+		if len(first) == detectLines {
+			break
+		}
+	}
+	if len(first) == 0 {
+		return FormatCSV
+	}
+
+	// LTspice: tabla separada por tabuladores con "time" (o "Freq.") y trazas V(...)/I(...)
+	if tabs := strings.Split(first[0], "\t"); len(tabs) >= 2 {
+		name := strings.ToLower(strings.TrimSpace(tabs[0]))
+		if (name == "time" || name == "freq.") && traceNamePattern.MatchString(strings.TrimSpace(tabs[1])) {
+			return FormatLTspice
+		}
+	}
+
+	head := splitFields(first[0], ',')
+	if len(head) == 0 {
+		return FormatCSV
+	}
+	var second []string
+	if len(first) > 1 {
+		second = splitFields(first[1], ',')
+	}
+
+	// Rigol: "X,CH1,Start,Increment", "X,CH1" + "Second,Volt" o "Time(s),CH1V,t0 = ...,tInc = ..."
+	if head[0] == "X" && (fieldIndex(head, "Increment") >= 0 || (len(second) > 0 && (second[0] == "Sequence" || second[0] == "Second"))) {
+		return FormatRigol
+	}
+	if strings.HasPrefix(strings.ToLower(head[0]), "time") && fieldWithPrefix(head, "tInc") >= 0 {
+		return FormatRigol
+	}
+
+	// Keysight InfiniiVision ("x-axis") o Infiniium (claves XInc/XOrg)
+	if strings.EqualFold(head[0], "x-axis") {
+		return FormatKeysight
+	}
+	keys := make(map[string]bool)
+	for _, line := range first {
+		if fields := splitFields(line, ','); len(fields) > 0 {
+			keys[strings.ToLower(fields[0])] = true
+		}
+	}
+	if keys["xinc"] && keys["xorg"] {
+		return FormatKeysight
+	}
+
+	// Tektronix: preámbulo con longitud de registro e intervalo de muestreo
+	if keys["record length"] && keys["sample interval"] && (keys["source"] || keys["vertical scale"] || keys["model"] || keys["model number"]) {
+		return FormatTektronix
+	}
+	return FormatCSV
+}
+
+// parseRigol lee las exportaciones CSV de Rigol. En la variante con índice de muestra
+// ("X,CH1,Start,Increment") el tiempo es Start + k·Increment.
+func parseRigol(lines []string, opts Options, report *Report) (*Series, error) {
+	lines = append([]string(nil), lines...)
+	inst := &Instrument{Vendor: "Rigol"}
+	report.Instrument = inst
+
+	idx := nextLine(lines, 0)
+	head := splitFields(lines[idx], ',')
+	lines[idx] = ""
+
+	var hints tableHints
+	switch {
+	case fieldIndex(head, "Increment") >= 0:
+		// X,CH1,Start,Increment / Sequence,Volt,<start>,<increment>
+		startCol, incCol := fieldIndex(head, "Start"), fieldIndex(head, "Increment")
+		unitsIdx := nextLine(lines, idx+1)
+		if startCol < 0 || unitsIdx < 0 {
+			return nil, fmt.Errorf("%w: faltan Start e Increment en la cabecera de Rigol", ErrMalformed)
+		}
+		units := splitFields(lines[unitsIdx], ',')
+		lines[unitsIdx] = ""
+		start, inc, ok := rigolTiming(units, startCol, incCol)
+		if !ok {
+			return nil, fmt.Errorf("%w: Start o Increment no son numéricos en la cabecera de Rigol", ErrMalformed)
+		}
+		report.Metadata["Start"] = units[startCol]
+		report.Metadata["Increment"] = units[incCol]
+
+		hints.headers = head[:startCol]
+		hints.units = append([]string{""}, units[1:startCol]...)
+		hints.sequence = &sequenceTime{start: start, increment: inc}
+		setTrigger(inst, start, inc)
+
+	case fieldWithPrefix(head, "tInc") >= 0:
+		// Time(s),CH1V,t0 = -6.000000e-03s, tInc = 2.000000e-06s
+		var start, inc float64
+		for _, f := range head {
+			key, value, found := strings.Cut(f, "=")
+			if !found {
+				hints.headers = append(hints.headers, f)
+				continue
+			}
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			report.Metadata[key] = value
+			v, _, ok := parseValue(value, '.')
+			if !ok {
+				continue
+			}
+			switch key {
+			case "t0":
+				start = v
+			case "tInc":
+				inc = v
+			}
+		}
+		if inc > 0 {
+			setTrigger(inst, start, inc)
+		}
+
+	default:
+		// X,CH1 / Second,Volt
+		hints.headers = head
+		if unitsIdx := nextLine(lines, idx+1); unitsIdx >= 0 {
+			if units := splitFields(lines[unitsIdx], ','); numericFields(units, '.') == 0 {
+				hints.units = units
+				lines[unitsIdx] = ""
+			}
+		}
+	}
+
+	series, err := parseTable(lines, opts, report, hints)
+	if err != nil {
+		return nil, err
+	}
+	if output := reportColumn(report, RoleOutput); output != nil {
+		inst.Channel = output.Name
+		inst.VerticalUnits = firstNonEmpty(output.BaseUnit, output.Unit)
+	}
+	inst.RecordLength = len(series.Time)
+	return series, nil
+}
+
+// rigolTiming lee Start e Increment de la línea de unidades de Rigol
+func rigolTiming(units []string, startCol, incCol int) (float64, float64, bool) {
+	if startCol >= len(units) || incCol >= len(units) {
+		return 0, 0, false
+	}
+	start, _, ok1 := parseValue(units[startCol], '.')
+	inc, _, ok2 := parseValue(units[incCol], '.')
+	return start, inc, ok1 && ok2 && inc > 0 && !math.IsInf(inc, 0)
+}
+
+// parseTektronix lee las exportaciones CSV de Tektronix. En el formato clásico (TDS/DPO)
+// el preámbulo ocupa las columnas 0-2 y los datos las columnas 3-4 de las mismas filas; en
+// el moderno (serie MSO) el preámbulo precede a una tabla TIME,CH1...
+func parseTektronix(lines []string, opts Options, report *Report) (*Series, error) {
+	inst := &Instrument{Vendor: "Tektronix"}
+	report.Instrument = inst
+
+	var series *Series
+	var err error
+	first := splitFields(lines[nextLine(lines, 0)], ',')
+	if len(first) >= 5 {
+		// Formato clásico: una tabla posicional de dos columnas con un solo canal
+		table := make([]string, len(lines))
+		for i, line := range lines {
+			fields := splitFields(line, ',')
+			if len(fields) >= 2 && fields[0] != "" {
+				report.Metadata[fields[0]] = fields[1]
+			}
+			if len(fields) >= 5 {
+				table[i] = fields[3] + "," + fields[4]
+			}
+		}
+		source := firstNonEmpty(metaString(report.Metadata, "Source"), "CH1")
+		opts.Delimiter, opts.DecimalSeparator, opts.Columns = ",", ".", ColumnMapping{}
+		series, err = parseTable(table, opts, report, tableHints{
+			headers: []string{"Time", source},
+			units:   []string{metaString(report.Metadata, "Horizontal Units"), metaString(report.Metadata, "Vertical Units")},
+		})
+	} else {
+		series, err = parseTable(lines, opts, report, tableHints{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta := report.Metadata
+	inst.Model = firstNonEmpty(metaString(meta, "Model Number"), metaString(meta, "Model"))
+	inst.Channel = metaString(meta, "Source")
+	if inst.Channel == "" {
+		if output := reportColumn(report, RoleOutput); output != nil {
+			inst.Channel = output.Name
+		}
+	}
+	if v := metaFloat(meta, "Record Length"); v != nil {
+		inst.RecordLength = int(*v)
+	}
+	if v := metaFloat(meta, "Sample Interval"); v != nil && *v > 0 {
+		inst.SampleInterval = *v
+	}
+	inst.VerticalScale = metaFloat(meta, "Vertical Scale")
+	inst.VerticalOffset = metaFloat(meta, "Vertical Offset")
+	inst.VerticalUnits = metaString(meta, "Vertical Units")
+	inst.HorizontalScale = metaFloat(meta, "Horizontal Scale")
+	if point := metaFloat(meta, "Trigger Point"); point != nil {
+		inst.TriggerPosition = point
+		if inst.SampleInterval > 0 {
+			offset := *point * inst.SampleInterval
+			inst.TriggerOffset = &offset
+		}
+	}
+	return series, nil
+}
+
+// parseKeysight lee las exportaciones CSV de Keysight/Agilent: InfiniiVision ("x-axis,1,2"
+// seguido de una línea de unidades) e Infiniium (preámbulo XInc/XOrg hasta la línea "Data",
+// con una o dos columnas de datos)
+func parseKeysight(lines []string, opts Options, report *Report) (*Series, error) {
+	lines = append([]string(nil), lines...)
+	inst := &Instrument{Vendor: "Keysight"}
+	report.Instrument = inst
+
+	idx := nextLine(lines, 0)
+	head := splitFields(lines[idx], ',')
+
+	var hints tableHints
+	if strings.EqualFold(head[0], "x-axis") {
+		lines[idx] = ""
+		hints.headers = make([]string, len(head))
+		hints.headers[0] = head[0]
+		for i, h := range head[1:] {
+			// Los canales analógicos se exportan por número
+			if _, err := strconv.Atoi(h); err == nil {
+				h = "CH" + h
+			}
+			hints.headers[i+1] = h
+		}
+		if unitsIdx := nextLine(lines, idx+1); unitsIdx >= 0 {
+			hints.units = splitFields(lines[unitsIdx], ',')
+			lines[unitsIdx] = ""
+		}
+	} else {
+		// Infiniium: preámbulo clave-valor hasta "Data"
+		dataIdx := -1
+		for i, line := range lines {
+			fields := splitFields(line, ',')
+			lines[i] = ""
+			if len(fields) == 0 {
+				continue
+			}
+			if strings.EqualFold(fields[0], "Data") {
+				dataIdx = i + 1
+				break
+			}
+			if len(fields) >= 2 {
+				report.Metadata[fields[0]] = fields[1]
+			}
+		}
+		if dataIdx < 0 {
+			return nil, fmt.Errorf("%w: falta la sección Data del archivo de Keysight", ErrMalformed)
+		}
+
+		xUnits, yUnits := metaString(report.Metadata, "XUnits"), metaString(report.Metadata, "YUnits")
+		if next := nextLine(lines, dataIdx); next >= 0 && len(splitFields(lines[next], ',')) == 1 {
+			// Solo valores: el tiempo se reconstruye con XOrg + k·XInc
+			xorg, xinc := metaFloat(report.Metadata, "XOrg"), metaFloat(report.Metadata, "XInc")
+			if xorg == nil || xinc == nil || *xinc <= 0 {
+				return nil, fmt.Errorf("%w: faltan XOrg o XInc para reconstruir el tiempo", ErrMalformed)
+			}
+			k := 0
+			for i := dataIdx; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" {
+					lines[i] = strconv.Itoa(k) + "," + strings.TrimSpace(lines[i])
+					k++
+				}
+			}
+			hints.sequence = &sequenceTime{start: *xorg, increment: *xinc}
+			hints.headers = []string{"Sample", "Y"}
+			hints.units = []string{"", yUnits}
+		} else {
+			hints.headers = []string{"Time", "Y"}
+			hints.units = []string{xUnits, yUnits}
+		}
+	}
+
+	series, err := parseTable(lines, opts, report, hints)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := report.Metadata
+	inst.Model = firstNonEmpty(metaString(meta, "Frame"), metaString(meta, "Model"))
+	if output := reportColumn(report, RoleOutput); output != nil {
+		inst.Channel = output.Name
+		inst.VerticalUnits = firstNonEmpty(metaString(meta, "YUnits"), output.BaseUnit, output.Unit)
+	}
+	inst.RecordLength = len(series.Time)
+	if v := metaFloat(meta, "Points"); v != nil {
+		inst.RecordLength = int(*v)
+	}
+	if xinc := metaFloat(meta, "XInc"); xinc != nil && *xinc > 0 {
+		if xorg := metaFloat(meta, "XOrg"); xorg != nil {
+			setTrigger(inst, *xorg, *xinc)
+		}
+		inst.SampleInterval = *xinc
+	}
+	return series, nil
+}
+
+// parseLTspice lee las exportaciones de formas de onda de LTspice (File > Export data as
+// text). Solo se admite el análisis transitorio; de un barrido .step se usa la primera
+// corrida. El paso de simulación es variable, por lo que la serie se remuestrea a paso uniforme.
+func parseLTspice(lines []string, opts Options, report *Report) (*Series, error) {
+	lines = append([]string(nil), lines...)
+	inst := &Instrument{Vendor: "LTspice"}
+	report.Instrument = inst
+
+	idx := nextLine(lines, 0)
+	head := strings.Split(lines[idx], "\t")
+	for i := range head {
+		head[i] = strings.TrimSpace(head[i])
+	}
+	if strings.EqualFold(head[0], "freq.") {
+		return nil, fmt.Errorf("%w: la exportación de LTspice es de un análisis AC; solo se admiten análisis transitorios", ErrUnsupportedFormat)
+	}
+	lines[idx] = ""
+	inst.Traces = head[1:]
+
+	// Barridos .step: cada corrida empieza con "Step Information"; se conserva la primera
+	runs := 0
+	for i := idx + 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "Step Information:") {
+			continue
+		}
+		runs++
+		if runs == 1 {
+			report.Metadata["Step Information"] = strings.TrimSpace(strings.TrimPrefix(line, "Step Information:"))
+			lines[i] = ""
+			continue
+		}
+		for j := i; j < len(lines); j++ {
+			lines[j] = ""
+		}
+		break
+	}
+	inst.Runs = runs
+
+	opts.Delimiter = "tab"
+	units := make([]string, len(head))
+	units[0] = "s"
+	series, err := parseTable(lines, opts, report, tableHints{headers: head, units: units})
+	if err != nil {
+		return nil, err
+	}
+	if output := reportColumn(report, RoleOutput); output != nil {
+		inst.Channel = output.Name
+	}
+	inst.RecordLength = len(series.Time)
+
+	if dt, resampled := resampleUniform(series); resampled {
+		inst.Resampled = true
+		inst.OriginalPoints = inst.RecordLength
+		inst.RecordLength = len(series.Time)
+		inst.SampleInterval = dt
+	}
+	return series, nil
+}
+
+// resampleUniform interpola linealmente la serie sobre una malla uniforme con la mediana
+// de los pasos si el muestreo es variable. Devuelve el paso y si hubo que remuestrear.
+func resampleUniform(series *Series) (float64, bool) {
+	t := series.Time
+	dt := medianStep(t)
+	if dt <= 0 {
+		return 0, false
+	}
+	uniform := true
+	for i := 1; i < len(t); i++ {
+		if math.Abs(t[i]-t[i-1]-dt) > 0.01*dt {
+			uniform = false
+			break
+		}
+	}
+	if uniform {
+		return dt, false
+	}
+
+	span := t[len(t)-1] - t[0]
+	n := int(span/dt) + 1
+	if n > maxResampledPoints {
+		n = maxResampledPoints
+		dt = span / float64(n-1)
+	}
+
+	grid := make([]float64, n)
+	for i := range grid {
+		grid[i] = t[0] + float64(i)*dt
+	}
+	series.Output = interpolate(t, series.Output, grid)
+	if series.Input != nil {
+		series.Input = interpolate(t, series.Input, grid)
+	}
+	series.Time = grid
+	return dt, true
+}
+
+// interpolate evalúa por interpolación lineal la serie (t, y) en los instantes crecientes x
+func interpolate(t, y, x []float64) []float64 {
+	out := make([]float64, len(x))
+	k := 0
+	for i, xi := range x {
+		for k+1 < len(t)-1 && t[k+1] <= xi {
+			k++
+		}
+		if k+1 >= len(t) || t[k+1] <= t[k] {
+			out[i] = y[k]
+			continue
+		}
+		frac := (xi - t[k]) / (t[k+1] - t[k])
+		frac = math.Max(0, math.Min(1, frac))
+		out[i] = y[k] + frac*(y[k+1]-y[k])
+	}
+	return out
+}
+
+// setTrigger registra la posición del disparo (en t = 0) a partir del tiempo de la primera
+// muestra y el intervalo de muestreo
+func setTrigger(inst *Instrument, start, interval float64) {
+	offset := -start
+	position := offset / interval
+	inst.SampleInterval = interval
+	inst.TriggerOffset = &offset
+	inst.TriggerPosition = &position
+}
+
+// nextLine devuelve el índice de la primera línea no vacía desde from (-1 si no hay)
+func nextLine(lines []string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldIndex devuelve la posición del campo con el nombre dado (-1 si no está)
+func fieldIndex(fields []string, name string) int {
+	for i, f := range fields {
+		if strings.EqualFold(f, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldWithPrefix devuelve la posición del primer campo que empieza con prefix (-1 si no hay)
+func fieldWithPrefix(fields []string, prefix string) int {
+	for i, f := range fields {
+		if strings.HasPrefix(strings.TrimSpace(f), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// reportColumn devuelve la columna leída con el rol dado
+func reportColumn(report *Report, role string) *ColumnInfo {
+	for i := range report.Columns {
+		if report.Columns[i].Role == role {
+			return &report.Columns[i]
+		}
+	}
+	return nil
+}
+
+// metaString busca una clave del preámbulo sin distinguir mayúsculas
+func metaString(meta map[string]string, key string) string {
+	for k, v := range meta {
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// metaFloat busca una clave numérica del preámbulo (nil si no está o no es numérica)
+func metaFloat(meta map[string]string, key string) *float64 {
+	v, _, ok := parseValue(metaString(meta, key), '.')
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// firstNonEmpty devuelve el primer texto no vacío
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,990 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Tipos de mensaje de cabecera de objeto HDF5 que se interpretan
+const (
+	h5MsgDataspace    = 0x01
+	h5MsgLinkInfo     = 0x02
+	h5MsgDatatype     = 0x03
+	h5MsgLink         = 0x06
+	h5MsgLayout       = 0x08
+	h5MsgFilters      = 0x0B
+	h5MsgAttribute    = 0x0C
+	h5MsgContinuation = 0x10
+	h5MsgSymbolTable  = 0x11
+)
+
+// Filtros HDF5 admitidos
+const (
+	h5FilterDeflate    = 1
+	h5FilterShuffle    = 2
+	h5FilterFletcher32 = 3
+)
+
+// Límites del recorrido: profundidad de grupos y mensajes por objeto
+const (
+	maxHDF5Depth    = 16
+	maxHDF5Messages = 4096
+)
+
+// Clases de MATLAB (atributo MATLAB_class de los archivos v7.3) que no son numéricas
+var matlabNonNumeric = map[string]bool{"char": true, "logical": true, "cell": true, "struct": true, "function_handle": true}
+
+// h5File es un archivo HDF5 en memoria
+type h5File struct {
+	data        []byte
+	base        int // Dirección base: las direcciones son relativas a ella
+	offsetSize  int
+	lengthSize  int
+	report      *Report
+	visited     map[uint64]bool
+	datasets    []h5Dataset
+	values      int // Valores leídos entre todos los conjuntos
+	unsupported []string
+}
+
+// h5Message es un mensaje de una cabecera de objeto
+type h5Message struct {
+	kind int
+	data []byte
+}
+
+// h5Dataset es un conjunto de datos numérico encontrado en el archivo
+type h5Dataset struct {
+	path   string
+	dims   []int
+	values []float64
+}
+
+// h5Type es un tipo numérico HDF5
+type h5Type struct {
+	size   int
+	float  bool
+	signed bool
+	order  binary.ByteOrder
+}
+
+// parseHDF5 lee un archivo HDF5 (incluidos los MAT v7.3). Se recorren los grupos desde la
+// raíz y cada conjunto de datos numérico de una o dos dimensiones aporta columnas como las
+// variables de MATLAB; los conjuntos de un solo valor pasan a los metadatos. Se admiten
+// superbloques 0 a 3, grupos con tabla de símbolos o con enlaces compactos y datos
+// compactos, contiguos o por bloques (índice B-tree v1) con deflate y shuffle.
+func parseHDF5(data []byte, opts Options, report *Report) (*Series, error) {
+	f := &h5File{data: data, report: report, visited: make(map[uint64]bool)}
+	root, err := f.readSuperblock()
+	if err != nil {
+		return nil, err
+	}
+	if err := f.walk(root, "", 0); err != nil {
+		return nil, err
+	}
+	if len(f.unsupported) > 0 {
+		report.Metadata["Omitted"] = strings.Join(f.unsupported, "; ")
+	}
+
+	var vectors []vector
+	for _, ds := range f.datasets {
+		total := 1
+		for _, d := range ds.dims {
+			total *= d
+		}
+		switch {
+		case total == 1:
+			readScalar(report, ds.path, ds.values[0])
+		case len(ds.dims) == 1:
+			vectors = append(vectors, vector{name: ds.path, values: ds.values})
+		case len(ds.dims) == 2:
+			vectors = append(vectors, splitMatrix(ds.path, ds.values, ds.dims[0], ds.dims[1])...)
+		}
+	}
+	shortenNames(vectors)
+	return seriesFromVectors(vectors, opts, report)
+}
+
+// shortenNames usa el último componente de la ruta como nombre si no se repite
+func shortenNames(vectors []vector) {
+	count := make(map[string]int)
+	leaf := func(path string) string { return path[strings.LastIndex(path, "/")+1:] }
+	for _, v := range vectors {
+		count[leaf(v.name)]++
+	}
+	for i, v := range vectors {
+		if count[leaf(v.name)] == 1 {
+			vectors[i].name = leaf(v.name)
+		}
+	}
+}
+
+// readSuperblock lee el superbloque y devuelve la dirección de la cabecera del grupo raíz
+func (f *h5File) readSuperblock() (uint64, error) {
+	pos := hdf5Superblock(f.data)
+	if pos < 0 {
+		return 0, fmt.Errorf("%w: falta la firma de HDF5", ErrMalformed)
+	}
+	sb := pos + len(hdf5Signature)
+	if sb+4 > len(f.data) {
+		return 0, fmt.Errorf("%w: superbloque HDF5 incompleto", ErrMalformed)
+	}
+	version := int(f.data[sb])
+	f.report.Metadata["HDF5 Superblock"] = fmt.Sprint(version)
+
+	var cur int
+	switch version {
+	case 0, 1:
+		if sb+16 > len(f.data) {
+			return 0, fmt.Errorf("%w: superbloque HDF5 incompleto", ErrMalformed)
+		}
+		f.offsetSize, f.lengthSize = int(f.data[sb+5]), int(f.data[sb+6])
+		cur = sb + 16
+		if version == 1 {
+			cur += 4
+		}
+	case 2, 3:
+		f.offsetSize, f.lengthSize = int(f.data[sb+1]), int(f.data[sb+2])
+		cur = sb + 4
+	default:
+		return 0, fmt.Errorf("%w: superbloque HDF5 versión %d", ErrUnsupportedFormat, version)
+	}
+	if !validSize(f.offsetSize) || !validSize(f.lengthSize) {
+		return 0, fmt.Errorf("%w: tamaños de dirección HDF5 inválidos", ErrMalformed)
+	}
+
+	base, ok := f.uint(cur, f.offsetSize)
+	if !ok {
+		return 0, fmt.Errorf("%w: superbloque HDF5 incompleto", ErrMalformed)
+	}
+	f.base = int(base)
+	if version <= 1 {
+		// Dirección base, espacio libre, fin de archivo, controlador y entrada de la raíz
+		// (nombre y cabecera de objeto)
+		root, ok := f.uint(cur+5*f.offsetSize, f.offsetSize)
+		if !ok {
+			return 0, fmt.Errorf("%w: superbloque HDF5 incompleto", ErrMalformed)
+		}
+		return root, nil
+	}
+	// Dirección base, extensión, fin de archivo y cabecera del grupo raíz
+	root, ok := f.uint(cur+3*f.offsetSize, f.offsetSize)
+	if !ok {
+		return 0, fmt.Errorf("%w: superbloque HDF5 incompleto", ErrMalformed)
+	}
+	return root, nil
+}
+
+// validSize indica si el tamaño de dirección o longitud es admitido
+func validSize(n int) bool {
+	return n == 2 || n == 4 || n == 8
+}
+
+// uint lee un entero sin signo little-endian de n bytes en la posición absoluta off
+func (f *h5File) uint(off, n int) (uint64, bool) {
+	if off < 0 || off+n > len(f.data) {
+		return 0, false
+	}
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(f.data[off+i])
+	}
+	return v, true
+}
+
+// at convierte una dirección del archivo en posición absoluta; la dirección indefinida
+// (todos los bits en 1) y las posiciones fuera del archivo devuelven -1
+func (f *h5File) at(addr uint64) int {
+	if addr == math.MaxUint64>>(64-8*f.offsetSize) {
+		return -1
+	}
+	pos := uint64(f.base) + addr
+	if pos >= uint64(len(f.data)) {
+		return -1
+	}
+	return int(pos)
+}
+
+// slice devuelve n bytes desde la dirección addr
+func (f *h5File) slice(addr uint64, n int) ([]byte, error) {
+	pos := f.at(addr)
+	if pos < 0 || n < 0 || pos+n > len(f.data) {
+		return nil, fmt.Errorf("%w: bloque HDF5 fuera del archivo", ErrMalformed)
+	}
+	return f.data[pos : pos+n], nil
+}
+
+// walk recorre un objeto: si es un grupo visita sus miembros y si es un conjunto de datos
+// numérico lo lee
+func (f *h5File) walk(addr uint64, path string, depth int) error {
+	if depth > maxHDF5Depth || f.visited[addr] {
+		return nil
+	}
+	f.visited[addr] = true
+
+	messages, err := f.objectHeader(addr)
+	if err != nil {
+		return err
+	}
+
+	var links []h5Link
+	isDataset := false
+	for _, m := range messages {
+		switch m.kind {
+		case h5MsgSymbolTable:
+			found, err := f.symbolTable(m.data)
+			if err != nil {
+				return err
+			}
+			links = append(links, found...)
+		case h5MsgLink:
+			if link, ok := f.linkMessage(m.data); ok {
+				links = append(links, link)
+			}
+		case h5MsgLinkInfo:
+			if f.denseLinks(m.data) {
+				f.unsupported = append(f.unsupported, fmt.Sprintf("%s: grupo con almacenamiento denso de enlaces", "/"+path))
+			}
+		case h5MsgLayout:
+			isDataset = true
+		}
+	}
+
+	if isDataset {
+		return f.dataset(messages, path)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].name < links[j].name })
+	for _, link := range links {
+		// Los grupos "#refs#" y "#subsystem#" de MATLAB guardan datos internos
+		if strings.HasPrefix(link.name, "#") {
+			continue
+		}
+		child := link.name
+		if path != "" {
+			child = path + "/" + link.name
+		}
+		if err := f.walk(link.addr, child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectHeader lee los mensajes de una cabecera de objeto (versiones 1 y 2), siguiendo los
+// bloques de continuación
+func (f *h5File) objectHeader(addr uint64) ([]h5Message, error) {
+	pos := f.at(addr)
+	if pos < 0 || pos+16 > len(f.data) {
+		return nil, fmt.Errorf("%w: cabecera de objeto HDF5 fuera del archivo", ErrMalformed)
+	}
+
+	var messages []h5Message
+	if bytes.Equal(f.data[pos:pos+4], []byte("OHDR")) {
+		return f.objectHeaderV2(pos)
+	}
+	if f.data[pos] != 1 {
+		return nil, fmt.Errorf("%w: cabecera de objeto HDF5 versión %d", ErrUnsupportedFormat, f.data[pos])
+	}
+
+	size := int(binary.LittleEndian.Uint32(f.data[pos+8:]))
+	blocks := [][2]int{{pos + 16, pos + 16 + size}}
+	for len(blocks) > 0 && len(messages) < maxHDF5Messages {
+		start, end := blocks[0][0], blocks[0][1]
+		blocks = blocks[1:]
+		if end > len(f.data) {
+			return nil, fmt.Errorf("%w: cabecera de objeto HDF5 truncada", ErrMalformed)
+		}
+		for off := start; off+8 <= end; {
+			kind := int(binary.LittleEndian.Uint16(f.data[off:]))
+			n := int(binary.LittleEndian.Uint16(f.data[off+2:]))
+			body := off + 8
+			if body+n > end {
+				return nil, fmt.Errorf("%w: mensaje HDF5 truncado", ErrMalformed)
+			}
+			m := h5Message{kind: kind, data: f.data[body : body+n]}
+			if kind == h5MsgContinuation {
+				cont, err := f.continuation(m.data)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, cont)
+			} else {
+				messages = append(messages, m)
+			}
+			off = body + n
+		}
+	}
+	return messages, nil
+}
+
+// objectHeaderV2 lee una cabecera de objeto versión 2 ("OHDR" y bloques "OCHK")
+func (f *h5File) objectHeaderV2(pos int) ([]h5Message, error) {
+	flags := f.data[pos+5]
+	off := pos + 6
+	if flags&0x20 != 0 {
+		off += 16 // Tiempos de acceso, modificación, cambio y creación
+	}
+	if flags&0x10 != 0 {
+		off += 4 // Límites de almacenamiento de atributos
+	}
+	sizeBytes := 1 << (flags & 0x03)
+	size, ok := f.uint(off, sizeBytes)
+	if !ok {
+		return nil, fmt.Errorf("%w: cabecera de objeto HDF5 truncada", ErrMalformed)
+	}
+	off += sizeBytes
+
+	var messages []h5Message
+	blocks := [][2]int{{off, off + int(size)}}
+	for len(blocks) > 0 && len(messages) < maxHDF5Messages {
+		start, end := blocks[0][0], blocks[0][1]
+		blocks = blocks[1:]
+		if end > len(f.data) || start > end {
+			return nil, fmt.Errorf("%w: cabecera de objeto HDF5 truncada", ErrMalformed)
+		}
+		header := 4
+		if flags&0x04 != 0 {
+			header = 6 // Orden de creación
+		}
+		for off := start; off+header <= end; {
+			kind := int(f.data[off])
+			n := int(binary.LittleEndian.Uint16(f.data[off+1:]))
+			body := off + header
+			if body+n > end {
+				// Espacio libre al final del bloque
+				break
+			}
+			m := h5Message{kind: kind, data: f.data[body : body+n]}
+			if kind == h5MsgContinuation {
+				cont, err := f.continuation(m.data)
+				if err != nil {
+					return nil, err
+				}
+				// Los bloques de continuación empiezan con "OCHK" y terminan con la suma de control
+				if cont[1]-cont[0] < 8 || !bytes.Equal(f.data[cont[0]:cont[0]+4], []byte("OCHK")) {
+					return nil, fmt.Errorf("%w: bloque de continuación HDF5 inválido", ErrMalformed)
+				}
+				blocks = append(blocks, [2]int{cont[0] + 4, cont[1] - 4})
+			} else if kind != 0 {
+				messages = append(messages, m)
+			}
+			off = body + n
+		}
+	}
+	return messages, nil
+}
+
+// continuation devuelve el rango absoluto de un bloque de continuación
+func (f *h5File) continuation(data []byte) ([2]int, error) {
+	if len(data) < f.offsetSize+f.lengthSize {
+		return [2]int{}, fmt.Errorf("%w: mensaje de continuación HDF5 truncado", ErrMalformed)
+	}
+	addr := leUint(data, f.offsetSize)
+	length := leUint(data[f.offsetSize:], f.lengthSize)
+	pos := f.at(addr)
+	if pos < 0 || uint64(pos)+length > uint64(len(f.data)) {
+		return [2]int{}, fmt.Errorf("%w: bloque de continuación HDF5 fuera del archivo", ErrMalformed)
+	}
+	return [2]int{pos, pos + int(length)}, nil
+}
+
+// leUint lee un entero sin signo little-endian de n bytes
+func leUint(b []byte, n int) uint64 {
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// h5Link es un miembro de un grupo
+type h5Link struct {
+	name string
+	addr uint64
+}
+
+// symbolTable lee los miembros de un grupo con tabla de símbolos (B-tree v1 y heap local)
+func (f *h5File) symbolTable(data []byte) ([]h5Link, error) {
+	if len(data) < 2*f.offsetSize {
+		return nil, fmt.Errorf("%w: tabla de símbolos HDF5 truncada", ErrMalformed)
+	}
+	btree := leUint(data, f.offsetSize)
+	heap, err := f.localHeap(leUint(data[f.offsetSize:], f.offsetSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var links []h5Link
+	err = f.btreeV1(btree, 0, func(child uint64, _ []byte) error {
+		found, err := f.symbolNode(child, heap)
+		links = append(links, found...)
+		return err
+	})
+	return links, err
+}
+
+// localHeap devuelve el segmento de datos de un heap local ("HEAP")
+func (f *h5File) localHeap(addr uint64) ([]byte, error) {
+	header, err := f.slice(addr, 8+2*f.lengthSize+f.offsetSize)
+	if err != nil || !bytes.Equal(header[:4], []byte("HEAP")) {
+		return nil, fmt.Errorf("%w: heap local HDF5 inválido", ErrMalformed)
+	}
+	size := leUint(header[8:], f.lengthSize)
+	dataAddr := leUint(header[8+2*f.lengthSize:], f.offsetSize)
+	return f.slice(dataAddr, int(size))
+}
+
+// btreeV1 recorre un B-tree v1 y llama a fn con cada hijo hoja y su clave anterior. Los
+// árboles de grupos (tipo 0) tienen claves de longitud lengthSize; los de bloques de datos
+// (tipo 1) claves de tamaño, máscara de filtros y desplazamientos de keySize bytes.
+func (f *h5File) btreeV1(addr uint64, keySize int, fn func(child uint64, key []byte) error) error {
+	return f.btreeNode(addr, keySize, 0, fn)
+}
+
+// btreeNode recorre un nodo de un B-tree v1
+func (f *h5File) btreeNode(addr uint64, keySize, depth int, fn func(child uint64, key []byte) error) error {
+	if depth > maxHDF5Depth {
+		return fmt.Errorf("%w: B-tree HDF5 demasiado profundo", ErrMalformed)
+	}
+	header, err := f.slice(addr, 8+2*f.offsetSize)
+	if err != nil || !bytes.Equal(header[:4], []byte("TREE")) {
+		return fmt.Errorf("%w: nodo B-tree HDF5 inválido", ErrMalformed)
+	}
+	nodeType, level := header[4], int(header[5])
+	entries := int(binary.LittleEndian.Uint16(header[6:]))
+	if nodeType == 0 {
+		keySize = f.lengthSize
+	}
+
+	stride := keySize + f.offsetSize
+	body, err := f.slice(addr+uint64(len(header)), entries*stride+keySize)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < entries; i++ {
+		key := body[i*stride : i*stride+keySize]
+		child := leUint(body[i*stride+keySize:], f.offsetSize)
+		if level > 0 {
+			err = f.btreeNode(child, keySize, depth+1, fn)
+		} else {
+			err = fn(child, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symbolNode lee las entradas de un nodo de tabla de símbolos ("SNOD")
+func (f *h5File) symbolNode(addr uint64, heap []byte) ([]h5Link, error) {
+	header, err := f.slice(addr, 8)
+	if err != nil || !bytes.Equal(header[:4], []byte("SNOD")) {
+		return nil, fmt.Errorf("%w: nodo de símbolos HDF5 inválido", ErrMalformed)
+	}
+	count := int(binary.LittleEndian.Uint16(header[6:]))
+	entrySize := 2*f.offsetSize + 24
+	body, err := f.slice(addr+8, count*entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]h5Link, 0, count)
+	for i := 0; i < count; i++ {
+		entry := body[i*entrySize:]
+		nameOffset := leUint(entry, f.offsetSize)
+		if nameOffset >= uint64(len(heap)) {
+			return nil, fmt.Errorf("%w: nombre fuera del heap local HDF5", ErrMalformed)
+		}
+		name := heap[nameOffset:]
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		links = append(links, h5Link{name: string(name), addr: leUint(entry[f.offsetSize:], f.offsetSize)})
+	}
+	return links, nil
+}
+
+// denseLinks indica si un mensaje de información de enlaces declara un heap fractal (grupos
+// con muchos miembros, no admitidos)
+func (f *h5File) denseLinks(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	off := 2
+	if data[1]&0x01 != 0 {
+		off += 8
+	}
+	if off+f.offsetSize > len(data) {
+		return false
+	}
+	return f.at(leUint(data[off:], f.offsetSize)) >= 0
+}
+
+// linkMessage lee un enlace duro de un grupo con enlaces compactos
+func (f *h5File) linkMessage(data []byte) (h5Link, bool) {
+	if len(data) < 2 || data[0] != 1 {
+		return h5Link{}, false
+	}
+	flags := data[1]
+	off := 2
+	linkType := byte(0)
+	if flags&0x08 != 0 {
+		linkType = data[off]
+		off++
+	}
+	if flags&0x04 != 0 {
+		off += 8
+	}
+	if flags&0x10 != 0 {
+		off++
+	}
+	lenSize := 1 << (flags & 0x03)
+	if off+lenSize > len(data) {
+		return h5Link{}, false
+	}
+	nameLen := int(leUint(data[off:], lenSize))
+	off += lenSize
+	if linkType != 0 || off+nameLen+f.offsetSize > len(data) {
+		// Enlaces simbólicos y externos
+		return h5Link{}, false
+	}
+	name := string(data[off : off+nameLen])
+	return h5Link{name: name, addr: leUint(data[off+nameLen:], f.offsetSize)}, true
+}
+
+// dataset lee un conjunto de datos numérico; los tipos no numéricos se omiten
+func (f *h5File) dataset(messages []h5Message, path string) error {
+	var dims []int
+	var dtype *h5Type
+	var layout, filters []byte
+	for _, m := range messages {
+		switch m.kind {
+		case h5MsgDataspace:
+			dims = f.dataspace(m.data)
+		case h5MsgDatatype:
+			dtype = readH5Type(m.data)
+		case h5MsgLayout:
+			layout = m.data
+		case h5MsgFilters:
+			filters = m.data
+		case h5MsgAttribute:
+			if name, value := f.stringAttribute(m.data); name == "MATLAB_class" && matlabNonNumeric[value] {
+				return nil
+			}
+		}
+	}
+	if dtype == nil || dims == nil || len(dims) > 2 {
+		return nil
+	}
+
+	total := 1
+	for _, d := range dims {
+		if d < 0 || d > maxBinaryValues {
+			return fmt.Errorf("%w: el conjunto %q declara una dimensión de %d valores", ErrUnsupportedFormat, path, uint64(d))
+		}
+		total *= d
+	}
+	if total == 0 {
+		return nil
+	}
+	if total > maxBinaryValues {
+		return fmt.Errorf("%w: el conjunto %q tiene demasiados valores (%d)", ErrUnsupportedFormat, path, total)
+	}
+	if f.values+total > maxBinaryValues {
+		return fmt.Errorf("%w: el archivo tiene más de %d valores", ErrUnsupportedFormat, maxBinaryValues)
+	}
+
+	raw, err := f.readLayout(layout, filters, dims, dtype.size)
+	if err != nil {
+		if isUnsupported(err) {
+			f.unsupported = append(f.unsupported, fmt.Sprintf("%s: %v", path, err))
+			return nil
+		}
+		return err
+	}
+	values, err := decodeNumbers(raw[:total*dtype.size], dtype.order, dtype.size, dtype.float, dtype.signed)
+	if err != nil {
+		return err
+	}
+	f.datasets = append(f.datasets, h5Dataset{path: path, dims: dims, values: values})
+	f.values += total
+	return nil
+}
+
+// isUnsupported indica si el error corresponde a una característica no admitida
+func isUnsupported(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), ErrUnsupportedFormat.Error())
+}
+
+// dataspace lee las dimensiones de un mensaje de espacio de datos (versiones 1 y 2)
+func (f *h5File) dataspace(data []byte) []int {
+	if len(data) < 4 {
+		return nil
+	}
+	version, rank := data[0], int(data[1])
+	off := 8
+	if version == 2 {
+		off = 4
+		switch data[3] {
+		case 0:
+			// Escalar
+			return []int{1}
+		case 2:
+			// Nulo: sin datos
+			return nil
+		}
+	}
+	if rank == 0 {
+		return []int{1}
+	}
+	if off+rank*f.lengthSize > len(data) {
+		return nil
+	}
+	dims := make([]int, rank)
+	for i := range dims {
+		dims[i] = int(leUint(data[off+i*f.lengthSize:], f.lengthSize))
+	}
+	return dims
+}
+
+// readH5Type interpreta un mensaje de tipo de dato; solo los enteros y flotantes son numéricos
+func readH5Type(data []byte) *h5Type {
+	if len(data) < 8 {
+		return nil
+	}
+	class := data[0] & 0x0F
+	bits := data[1]
+	t := &h5Type{size: int(binary.LittleEndian.Uint32(data[4:])), order: binary.LittleEndian}
+	if bits&0x01 != 0 {
+		t.order = binary.BigEndian
+	}
+	switch class {
+	case 0:
+		t.signed = bits&0x08 != 0
+	case 1:
+		t.float, t.signed = true, true
+		if t.size != 4 && t.size != 8 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if t.size != 1 && t.size != 2 && t.size != 4 && t.size != 8 {
+		return nil
+	}
+	return t
+}
+
+// stringAttribute devuelve el nombre y, si es texto de longitud fija, el valor de un atributo
+func (f *h5File) stringAttribute(data []byte) (string, string) {
+	if len(data) < 8 {
+		return "", ""
+	}
+	version := data[0]
+	nameSize := int(binary.LittleEndian.Uint16(data[2:]))
+	typeSize := int(binary.LittleEndian.Uint16(data[4:]))
+	spaceSize := int(binary.LittleEndian.Uint16(data[6:]))
+	off := 8
+	pad := func(n int) int { return n }
+	switch version {
+	case 1:
+		pad = func(n int) int { return (n + 7) / 8 * 8 }
+	case 3:
+		off = 9 // Codificación del nombre
+	}
+	if off+pad(nameSize)+pad(typeSize)+pad(spaceSize) > len(data) || nameSize == 0 {
+		return "", ""
+	}
+	name := strings.TrimRight(string(data[off:off+nameSize]), "\x00")
+	typ := data[off+pad(nameSize):]
+	value := data[off+pad(nameSize)+pad(typeSize)+pad(spaceSize):]
+	if len(typ) < 8 || typ[0]&0x0F != 3 {
+		return name, ""
+	}
+	size := int(binary.LittleEndian.Uint32(typ[4:]))
+	if size > len(value) {
+		size = len(value)
+	}
+	return name, strings.TrimRight(string(value[:size]), "\x00 ")
+}
+
+// readLayout obtiene los bytes del conjunto de datos según su mensaje de distribución
+func (f *h5File) readLayout(layout, filters []byte, dims []int, elemSize int) ([]byte, error) {
+	total := elemSize
+	for _, d := range dims {
+		total *= d
+	}
+	if len(layout) < 2 {
+		return nil, fmt.Errorf("%w: mensaje de distribución HDF5 truncado", ErrMalformed)
+	}
+
+	version := layout[0]
+	if version < 3 {
+		return nil, fmt.Errorf("%w: distribución HDF5 versión %d", ErrUnsupportedFormat, version)
+	}
+	class, body := layout[1], layout[2:]
+	switch class {
+	case 0:
+		// Compacta: los datos están en el propio mensaje
+		if len(body) < 2 {
+			return nil, fmt.Errorf("%w: datos compactos HDF5 truncados", ErrMalformed)
+		}
+		size := int(binary.LittleEndian.Uint16(body))
+		if size < total || 2+size > len(body) {
+			return nil, fmt.Errorf("%w: datos compactos HDF5 truncados", ErrMalformed)
+		}
+		return body[2 : 2+size], nil
+	case 1:
+		// Contigua
+		if len(body) < f.offsetSize {
+			return nil, fmt.Errorf("%w: distribución contigua HDF5 truncada", ErrMalformed)
+		}
+		addr := leUint(body, f.offsetSize)
+		if f.at(addr) < 0 {
+			return nil, fmt.Errorf("%w: conjunto sin datos escritos", ErrUnsupportedFormat)
+		}
+		return f.slice(addr, total)
+	case 2:
+		if version != 3 {
+			return nil, fmt.Errorf("%w: índice de bloques HDF5 versión %d", ErrUnsupportedFormat, version)
+		}
+		return f.readChunked(body, filters, dims, elemSize)
+	}
+	return nil, fmt.Errorf("%w: distribución HDF5 clase %d", ErrUnsupportedFormat, class)
+}
+
+// readChunked arma un conjunto de datos guardado por bloques indexados con un B-tree v1
+func (f *h5File) readChunked(body, filters []byte, dims []int, elemSize int) ([]byte, error) {
+	if len(body) < 1+f.offsetSize {
+		return nil, fmt.Errorf("%w: distribución por bloques HDF5 truncada", ErrMalformed)
+	}
+	rank := int(body[0]) // Incluye la dimensión del tamaño de elemento
+	if rank != len(dims)+1 || len(body) < 1+f.offsetSize+4*rank {
+		return nil, fmt.Errorf("%w: distribución por bloques HDF5 inconsistente", ErrMalformed)
+	}
+	btree := leUint(body[1:], f.offsetSize)
+	if f.at(btree) < 0 {
+		return nil, fmt.Errorf("%w: conjunto sin datos escritos", ErrUnsupportedFormat)
+	}
+	chunk := make([]int, rank-1)
+	for i := range chunk {
+		chunk[i] = int(binary.LittleEndian.Uint32(body[1+f.offsetSize+4*i:]))
+		if chunk[i] <= 0 {
+			return nil, fmt.Errorf("%w: bloque HDF5 de tamaño nulo", ErrMalformed)
+		}
+	}
+	pipeline, err := readFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkElems := 1
+	for _, c := range chunk {
+		if c > maxDecompressedSize/elemSize/chunkElems {
+			return nil, fmt.Errorf("%w: bloque HDF5 de más de %d bytes", ErrUnsupportedFormat, maxDecompressedSize)
+		}
+		chunkElems *= c
+	}
+	total := elemSize
+	for _, d := range dims {
+		total *= d
+	}
+
+	// Primero se recorre el índice sin leer los bloques: el conjunto debe quedar cubierto
+	// por bloques presentes en el archivo antes de reservar memoria para él
+	type chunkRef struct {
+		child  uint64
+		size   int
+		mask   uint32
+		origin []int
+	}
+	var chunks []chunkRef
+	seen := make(map[[2]int]bool)
+	covered, available := 0, 0
+	keySize := 8 + 8*rank
+	err = f.btreeV1(btree, keySize, func(child uint64, key []byte) error {
+		ref := chunkRef{
+			child:  child,
+			size:   int(binary.LittleEndian.Uint32(key)),
+			mask:   binary.LittleEndian.Uint32(key[4:]),
+			origin: make([]int, len(dims)),
+		}
+		var cell [2]int
+		elems := 1
+		for i := range ref.origin {
+			offset := binary.LittleEndian.Uint64(key[8+8*i:])
+			if offset >= uint64(dims[i]) || offset%uint64(chunk[i]) != 0 {
+				return fmt.Errorf("%w: bloque HDF5 fuera del conjunto", ErrMalformed)
+			}
+			ref.origin[i] = int(offset)
+			cell[i] = ref.origin[i] / chunk[i]
+			elems *= min(chunk[i], dims[i]-ref.origin[i])
+		}
+		if seen[cell] {
+			return fmt.Errorf("%w: bloque HDF5 repetido", ErrMalformed)
+		}
+		seen[cell] = true
+		if _, err := f.slice(child, ref.size); err != nil {
+			return err
+		}
+		covered += elems
+		if filtered(pipeline, ref.mask) {
+			available += maxDecompressedSize
+		} else {
+			available += ref.size
+		}
+		chunks = append(chunks, ref)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if covered != total/elemSize {
+		return nil, fmt.Errorf("%w: los bloques HDF5 cubren %d de %d valores", ErrMalformed, covered, total/elemSize)
+	}
+	if available < total {
+		return nil, fmt.Errorf("%w: los bloques HDF5 tienen menos datos que el conjunto", ErrMalformed)
+	}
+
+	out := make([]byte, total)
+	for _, ref := range chunks {
+		raw, err := f.slice(ref.child, ref.size)
+		if err != nil {
+			return nil, err
+		}
+		raw, err = applyFilters(raw, pipeline, ref.mask, elemSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) < chunkElems*elemSize {
+			return nil, fmt.Errorf("%w: bloque HDF5 de %d bytes, se esperaban %d", ErrMalformed, len(raw), chunkElems*elemSize)
+		}
+		copyChunk(out, raw, dims, chunk, ref.origin, elemSize)
+	}
+	return out, nil
+}
+
+// filtered indica si algún filtro de la cadena se aplicó al bloque y puede cambiar su tamaño
+func filtered(pipeline []int, mask uint32) bool {
+	for i, id := range pipeline {
+		if mask&(1<<uint(i)) == 0 && id == h5FilterDeflate {
+			return true
+		}
+	}
+	return false
+}
+
+// copyChunk copia un bloque (de una o dos dimensiones) a su lugar en el arreglo completo,
+// recortando los bloques del borde
+func copyChunk(out, raw []byte, dims, chunk, origin []int, elemSize int) {
+	if len(dims) == 1 {
+		n := min(chunk[0], dims[0]-origin[0])
+		if n > 0 {
+			copy(out[origin[0]*elemSize:], raw[:n*elemSize])
+		}
+		return
+	}
+	rows := min(chunk[0], dims[0]-origin[0])
+	cols := min(chunk[1], dims[1]-origin[1])
+	for i := 0; i < rows; i++ {
+		dst := ((origin[0]+i)*dims[1] + origin[1]) * elemSize
+		src := i * chunk[1] * elemSize
+		copy(out[dst:dst+cols*elemSize], raw[src:src+cols*elemSize])
+	}
+}
+
+// readFilters lee los identificadores de la cadena de filtros (versiones 1 y 2)
+func readFilters(data []byte) ([]int, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: cadena de filtros HDF5 truncada", ErrMalformed)
+	}
+	version, count := data[0], int(data[1])
+	off := 2
+	if version == 1 {
+		off = 8
+	}
+	ids := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		if off+6 > len(data) {
+			return nil, fmt.Errorf("%w: cadena de filtros HDF5 truncada", ErrMalformed)
+		}
+		id := int(binary.LittleEndian.Uint16(data[off:]))
+		off += 2
+		nameLen := 0
+		if version == 1 || id >= 256 {
+			nameLen = int(binary.LittleEndian.Uint16(data[off:]))
+			off += 2
+		}
+		if off+4 > len(data) {
+			return nil, fmt.Errorf("%w: cadena de filtros HDF5 truncada", ErrMalformed)
+		}
+		values := int(binary.LittleEndian.Uint16(data[off+2:]))
+		off += 4
+		if version == 1 {
+			off += (nameLen+7)/8*8 + 4*values
+			if values%2 == 1 {
+				off += 4
+			}
+		} else {
+			off += nameLen + 4*values
+		}
+		switch id {
+		case h5FilterDeflate, h5FilterShuffle, h5FilterFletcher32:
+		default:
+			return nil, fmt.Errorf("%w: filtro HDF5 %d", ErrUnsupportedFormat, id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// applyFilters deshace la cadena de filtros de un bloque en orden inverso; el bit i de
+// mask indica que el filtro i no se aplicó a este bloque
+func applyFilters(raw []byte, pipeline []int, mask uint32, elemSize int) ([]byte, error) {
+	for i := len(pipeline) - 1; i >= 0; i-- {
+		if mask&(1<<uint(i)) != 0 {
+			continue
+		}
+		switch pipeline[i] {
+		case h5FilterDeflate:
+			out, err := inflate(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bloque HDF5 comprimido ilegible: %v", ErrMalformed, err)
+			}
+			raw = out
+		case h5FilterShuffle:
+			raw = unshuffle(raw, elemSize)
+		case h5FilterFletcher32:
+			if len(raw) < 4 {
+				return nil, fmt.Errorf("%w: bloque HDF5 sin suma de control", ErrMalformed)
+			}
+			raw = raw[:len(raw)-4]
+		}
+	}
+	return raw, nil
+}
+
+// unshuffle deshace el filtro shuffle: el bloque guarda primero el byte 0 de todos los
+// elementos, luego el byte 1, etc.
+func unshuffle(raw []byte, elemSize int) []byte {
+	if elemSize <= 1 {
+		return raw
+	}
+	n := len(raw) / elemSize
+	out := make([]byte, len(raw))
+	for b := 0; b < elemSize; b++ {
+		for i := 0; i < n; i++ {
+			out[i*elemSize+b] = raw[b*n+i]
+		}
+	}
+	copy(out[n*elemSize:], raw[n*elemSize:])
+	return out
+}
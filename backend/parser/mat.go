@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Tipos de elemento de MAT v5
+const (
+	miINT8       = 1
+	miUINT8      = 2
+	miINT16      = 3
+	miUINT16     = 4
+	miINT32      = 5
+	miUINT32     = 6
+	miSINGLE     = 7
+	miDOUBLE     = 9
+	miINT64      = 12
+	miUINT64     = 13
+	miMATRIX     = 14
+	miCOMPRESSED = 15
+)
+
+// Clases de arreglo de MAT v5
+const (
+	mxSTRUCT = 2
+	mxDOUBLE = 6
+	mxUINT64 = 15
+)
+
+// Profundidad máxima de estructuras anidadas
+const maxMATDepth = 8
+
+// Tamaño de la cabecera de un archivo MAT v5
+const matHeaderSize = 128
+
+// matElement es un elemento de datos de MAT v5
+type matElement struct {
+	kind int
+	data []byte
+}
+
+// matReader recorre los elementos de un archivo MAT v5
+type matReader struct {
+	order  binary.ByteOrder
+	report *Report
+}
+
+// parseMAT lee un archivo de MATLAB. Los archivos v5 (save -v6/-v7) guardan cada variable
+// como un elemento miMATRIX, opcionalmente comprimido; los v7.3 son archivos HDF5. Cada
+// vector numérico es una columna con el nombre de la variable; las matrices aportan una
+// columna por cada vector a lo largo de su dimensión más larga (datos_1, datos_2...), los
+// campos de estructuras se nombran estructura.campo y los escalares pasan a los metadatos.
+func parseMAT(data []byte, opts Options, report *Report) (*Series, error) {
+	if bytes.HasPrefix(data, matV73Header) {
+		report.Metadata["Header"] = matHeaderText(data)
+		return parseHDF5(data, opts, report)
+	}
+	if len(data) < matHeaderSize {
+		return nil, fmt.Errorf("%w: cabecera MAT incompleta", ErrMalformed)
+	}
+
+	r := &matReader{report: report}
+	switch string(data[126:128]) {
+	case "IM":
+		r.order = binary.LittleEndian
+	case "MI":
+		r.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("%w: indicador de orden de bytes MAT desconocido", ErrMalformed)
+	}
+	report.Metadata["Header"] = matHeaderText(data)
+
+	var vectors []vector
+	elements, err := r.elements(data[matHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+	for _, el := range elements {
+		if el.kind == miCOMPRESSED {
+			inflated, err := inflate(el.data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: variable comprimida ilegible: %v", ErrMalformed, err)
+			}
+			inner, err := r.elements(inflated)
+			if err != nil {
+				return nil, err
+			}
+			if len(inner) == 0 {
+				continue
+			}
+			el = inner[0]
+		}
+		if el.kind != miMATRIX {
+			continue
+		}
+		found, err := r.matrix(el.data, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, found...)
+	}
+	return seriesFromVectors(vectors, opts, report)
+}
+
+// matHeaderText devuelve el texto descriptivo de la cabecera MAT
+func matHeaderText(data []byte) string {
+	n := min(len(data), 116)
+	return strings.TrimRight(string(data[:n]), "\x00 ")
+}
+
+// elements separa una secuencia de elementos; los elementos normales se alinean a 8 bytes
+// y los de formato corto (tamaño ≤ 4) ocupan 8 bytes en total
+func (r *matReader) elements(data []byte) ([]matElement, error) {
+	var out []matElement
+	for off := 0; off+8 <= len(data); {
+		tag := r.order.Uint32(data[off:])
+		if small := tag >> 16; small != 0 {
+			kind, size := int(tag&0xFFFF), int(small)
+			if size > 4 {
+				return nil, fmt.Errorf("%w: elemento corto de %d bytes", ErrMalformed, size)
+			}
+			out = append(out, matElement{kind: kind, data: data[off+4 : off+4+size]})
+			off += 8
+			continue
+		}
+
+		kind, size := int(tag), int(r.order.Uint32(data[off+4:]))
+		start, end := off+8, off+8+size
+		if size < 0 || end > len(data) {
+			return nil, fmt.Errorf("%w: elemento de %d bytes fuera del archivo", ErrMalformed, size)
+		}
+		out = append(out, matElement{kind: kind, data: data[start:end]})
+		if kind == miCOMPRESSED {
+			off = end
+		} else {
+			off = start + (size+7)/8*8
+		}
+	}
+	return out, nil
+}
+
+// matrix lee un miMATRIX y devuelve sus vectores numéricos (recorriendo estructuras). Los
+// campos de una estructura no tienen nombre propio y usan fieldName.
+func (r *matReader) matrix(data []byte, fieldName string, depth int) ([]vector, error) {
+	parts, err := r.elements(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) < 3 || len(parts[0].data) < 4 {
+		return nil, fmt.Errorf("%w: variable MAT sin banderas, dimensiones o nombre", ErrMalformed)
+	}
+
+	flags := r.order.Uint32(parts[0].data)
+	class, complex := int(flags&0xFF), flags&0x0800 != 0
+	dims, err := r.numbers(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	name := string(parts[2].data)
+	if name == "" {
+		name = fieldName
+	}
+
+	switch {
+	case class == mxSTRUCT:
+		return r.structFields(parts[3:], name, dims, depth)
+	case class < mxDOUBLE || class > mxUINT64 || len(dims) != 2:
+		// Texto, celdas, dispersas, objetos y arreglos de más de dos dimensiones
+		return nil, nil
+	case complex:
+		r.report.Metadata[name] = "complejo (omitido)"
+		return nil, nil
+	case len(parts) < 4:
+		return nil, fmt.Errorf("%w: la variable %q no tiene datos", ErrMalformed, name)
+	}
+
+	// Las dimensiones se comprueban antes de convertirlas a enteros para que no desborden
+	if !(dims[0] >= 0 && dims[1] >= 0) {
+		return nil, fmt.Errorf("%w: la variable %q tiene dimensiones inválidas", ErrMalformed, name)
+	}
+	if !(dims[0] <= maxBinaryValues && dims[1] <= maxBinaryValues && dims[0]*dims[1] <= maxBinaryValues) {
+		return nil, fmt.Errorf("%w: la variable %q tiene demasiados valores (%.0f)", ErrUnsupportedFormat, name, dims[0]*dims[1])
+	}
+	rows, cols := int(dims[0]), int(dims[1])
+	values, err := r.numbers(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != rows*cols {
+		return nil, fmt.Errorf("%w: la variable %q declara %d×%d valores y tiene %d", ErrMalformed, name, rows, cols, len(values))
+	}
+	switch {
+	case len(values) == 0:
+		return nil, nil
+	case len(values) == 1:
+		readScalar(r.report, name, values[0])
+		return nil, nil
+	}
+
+	// MATLAB guarda por columnas; splitMatrix espera orden de filas (cols × rows)
+	return splitMatrix(name, values, cols, rows), nil
+}
+
+// structFields lee los campos de una estructura 1×1 (nombre, longitud y lista de campos)
+func (r *matReader) structFields(parts []matElement, name string, dims []float64, depth int) ([]vector, error) {
+	if depth >= maxMATDepth || len(parts) < 2 || len(dims) != 2 || dims[0]*dims[1] != 1 {
+		return nil, nil
+	}
+	lengths, err := r.numbers(parts[0])
+	if err != nil || len(lengths) == 0 || lengths[0] <= 0 {
+		return nil, fmt.Errorf("%w: estructura %q sin longitud de nombres de campo", ErrMalformed, name)
+	}
+	width := int(lengths[0])
+	names := parts[1].data
+	fields := parts[2:]
+	var out []vector
+	for i := 0; i < len(fields) && (i+1)*width <= len(names); i++ {
+		if fields[i].kind != miMATRIX {
+			continue
+		}
+		field := strings.TrimRight(string(names[i*width:(i+1)*width]), "\x00")
+		found, err := r.matrix(fields[i].data, name+"."+field, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, found...)
+	}
+	return out, nil
+}
+
+// numbers decodifica un elemento numérico de cualquier tipo
+func (r *matReader) numbers(el matElement) ([]float64, error) {
+	switch el.kind {
+	case miINT8:
+		return decodeNumbers(el.data, r.order, 1, false, true)
+	case miUINT8:
+		return decodeNumbers(el.data, r.order, 1, false, false)
+	case miINT16:
+		return decodeNumbers(el.data, r.order, 2, false, true)
+	case miUINT16:
+		return decodeNumbers(el.data, r.order, 2, false, false)
+	case miINT32:
+		return decodeNumbers(el.data, r.order, 4, false, true)
+	case miUINT32:
+		return decodeNumbers(el.data, r.order, 4, false, false)
+	case miSINGLE:
+		return decodeNumbers(el.data, r.order, 4, true, true)
+	case miDOUBLE:
+		return decodeNumbers(el.data, r.order, 8, true, true)
+	case miINT64:
+		return decodeNumbers(el.data, r.order, 8, false, true)
+	case miUINT64:
+		return decodeNumbers(el.data, r.order, 8, false, false)
+	}
+	return nil, fmt.Errorf("%w: tipo de dato MAT %d", ErrUnsupportedFormat, el.kind)
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Prefijos SI admitidos delante de una unidad conocida
+var siPrefixes = map[string]float64{
+	"p": 1e-12,
+	"n": 1e-9,
+	"u": 1e-6,
+	"µ": 1e-6,
+	"μ": 1e-6,
+	"m": 1e-3,
+	"k": 1e3,
+	"M": 1e6,
+	"G": 1e9,
+}
+
+// Unidades base reconocidas (sin prefijo) y su forma normalizada
+var baseUnits = map[string]string{
+	"s":       "s",
+	"sec":     "s",
+	"seg":     "s",
+	"second":  "s",
+	"seconds": "s",
+	"Second":  "s",
+	"V":       "V",
+	"v":       "V",
+	"Volt":    "V",
+	"Volts":   "V",
+	"volt":    "V",
+	"A":       "A",
+	"Amp":     "A",
+	"Hz":      "Hz",
+	"W":       "W",
+	"Ω":       "Ω",
+	"ohm":     "Ω",
+	"Ohm":     "Ω",
+	"%":       "%",
+	"°C":      "°C",
+	"degC":    "°C",
+	"rad":     "rad",
+	"deg":     "deg",
+	"°":       "deg",
+	"Pa":      "Pa",
+	"N":       "N",
+	"m":       "m",
+}
+
+// parseValue convierte un campo numérico con separador decimal dado y sufijo de unidad
+// opcional ("1,5 mV", "2.3e-3s"). Devuelve el valor en la unidad base, la unidad tal como
+// aparece y si el campo era numérico.
+func parseValue(field string, decimal rune) (float64, string, bool) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, "", false
+	}
+	if v, ok := parseNonFinite(field); ok {
+		return v, "", true
+	}
+
+	n := scanNumber(field, decimal)
+	if n == 0 {
+		return 0, "", false
+	}
+	number := normalizeNumber(field[:n], decimal)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	unit := strings.TrimSpace(field[n:])
+	if unit == "" {
+		return value, "", true
+	}
+	scale, _, ok := unitScale(unit)
+	if !ok {
+		return 0, "", false
+	}
+	return value * scale, unit, true
+}
+
+// parseNonFinite reconoce NaN e infinitos escritos como texto
+func parseNonFinite(field string) (float64, bool) {
+	switch strings.ToLower(field) {
+	case "nan", "-nan", "+nan":
+		return math.NaN(), true
+	case "inf", "+inf", "infinity", "+infinity":
+		return math.Inf(1), true
+	case "-inf", "-infinity":
+		return math.Inf(-1), true
+	}
+	return 0, false
+}
+
+// scanNumber devuelve la longitud del prefijo numérico de s: signo, dígitos con separador
+// decimal y de miles opcional, y exponente
+func scanNumber(s string, decimal rune) int {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	seenDecimal := false
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == decimal && !seenDecimal:
+			seenDecimal = true
+		case decimal == ',' && c == '.' && !seenDecimal && digits > 0:
+			// Separador de miles en notación europea (1.234,5)
+		default:
+			goto exponent
+		}
+		i++
+	}
+exponent:
+	if digits == 0 {
+		return 0
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		k := j
+		for k < len(s) && s[k] >= '0' && s[k] <= '9' {
+			k++
+		}
+		if k > j {
+			i = k
+		}
+	}
+	return i
+}
+
+// normalizeNumber convierte el texto numérico a la sintaxis de strconv
+func normalizeNumber(s string, decimal rune) string {
+	if decimal == ',' {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return s
+}
+
+// unitScale interpreta una unidad con prefijo SI opcional ("ms", "mV", "kHz") y devuelve
+// el factor a la unidad base y la unidad base normalizada
+func unitScale(unit string) (float64, string, bool) {
+	unit = strings.TrimSpace(unit)
+	if base, ok := baseUnits[unit]; ok {
+		return 1, base, true
+	}
+	r, size := utf8.DecodeRuneInString(unit)
+	if size < len(unit) {
+		if scale, ok := siPrefixes[string(r)]; ok {
+			if base, ok := baseUnits[unit[size:]]; ok {
+				return scale, base, true
+			}
+		}
+	}
+	return 1, "", false
+}
+
+// isTextField indica si un campo contiene letras y no es un número con unidad
+func isTextField(field string, decimal rune) bool {
+	if _, _, ok := parseValue(field, decimal); ok {
+		return false
+	}
+	for _, r := range field {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
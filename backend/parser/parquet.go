@@ -0,0 +1,770 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Tipos físicos de Parquet
+const (
+	pqBoolean   = 0
+	pqInt32     = 1
+	pqInt64     = 2
+	pqInt96     = 3
+	pqFloat     = 4
+	pqDouble    = 5
+	pqByteArray = 6
+)
+
+// Repetición de un campo del esquema
+const (
+	pqRequired = 0
+	pqOptional = 1
+)
+
+// Códecs de compresión de Parquet
+const (
+	pqUncompressed = 0
+	pqSnappy       = 1
+	pqGzip         = 2
+)
+
+// Nombres de los códecs para los mensajes de error
+var pqCodecNames = map[int64]string{3: "LZO", 4: "Brotli", 5: "LZ4", 6: "ZSTD", 7: "LZ4_RAW"}
+
+// Tipos de página
+const (
+	pqDataPage       = 0
+	pqDictionaryPage = 2
+	pqDataPageV2     = 3
+)
+
+// Codificaciones de valores
+const (
+	pqPlain           = 0
+	pqPlainDictionary = 2
+	pqRLEDictionary   = 8
+	pqByteStreamSplit = 9
+)
+
+// Tipos convertidos (anotaciones del esquema) que cambian la escala de los valores
+const (
+	pqConvertedDecimal         = 5
+	pqConvertedTimestampMillis = 9
+	pqConvertedTimestampMicros = 10
+)
+
+// pqColumn es una columna hoja del esquema
+type pqColumn struct {
+	name      string
+	physical  int64
+	optional  bool
+	scale     float64 // Divisor de los decimales y de las marcas de tiempo (a segundos)
+	timestamp bool
+}
+
+// parseParquet lee un archivo Parquet del pipeline de registro. Las columnas numéricas de
+// primer nivel (enteros, flotantes, decimales y marcas de tiempo, que se convierten a
+// segundos desde la primera fila) son las columnas de la serie en el orden del esquema; las
+// de texto o anidadas se omiten. Se admiten páginas v1 y v2 sin comprimir, Snappy o gzip
+// con codificación plana, por diccionario o byte stream split.
+func parseParquet(data []byte, opts Options, report *Report) (*Series, error) {
+	n := len(data)
+	if n < 12 || !bytes.Equal(data[n-4:], parquetMagic) {
+		return nil, fmt.Errorf("%w: el archivo Parquet no termina con la firma PAR1", ErrMalformed)
+	}
+	footerSize := int(binary.LittleEndian.Uint32(data[n-8:]))
+	if footerSize <= 0 || footerSize > n-12 {
+		return nil, fmt.Errorf("%w: pie de Parquet inválido", ErrMalformed)
+	}
+	meta, err := newThriftReader(data[n-8-footerSize : n-8]).readStruct(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadatos de Parquet ilegibles: %v", ErrMalformed, err)
+	}
+
+	if createdBy := meta.str(6); createdBy != "" {
+		report.Metadata["Created By"] = createdBy
+	}
+	for _, kv := range meta.list(5) {
+		if s, ok := kv.(thriftStruct); ok && !strings.HasPrefix(s.str(1), "ARROW:") && len(s.str(2)) < 200 {
+			report.Metadata[s.str(1)] = s.str(2)
+		}
+	}
+
+	columns, leaves := parquetSchema(meta.list(2))
+	if len(columns) == 0 {
+		return nil, ErrNoData
+	}
+	values := make([][]float64, len(leaves))
+
+	for _, rg := range meta.list(4) {
+		rowGroup, _ := rg.(thriftStruct)
+		chunks := rowGroup.list(1)
+		if len(chunks) != len(leaves) {
+			return nil, fmt.Errorf("%w: el grupo de filas tiene %d columnas y el esquema %d", ErrMalformed, len(chunks), len(leaves))
+		}
+		for i, leaf := range leaves {
+			if leaf < 0 {
+				continue
+			}
+			chunk, _ := chunks[i].(thriftStruct)
+			col, err := readParquetChunk(data, chunk.strct(3), columns[leaf])
+			if err != nil {
+				return nil, fmt.Errorf("columna %q: %w", columns[leaf].name, err)
+			}
+			values[i] = append(values[i], col...)
+			if len(values[i]) > maxBinaryValues {
+				return nil, fmt.Errorf("%w: la columna %q tiene demasiados valores", ErrUnsupportedFormat, columns[leaf].name)
+			}
+		}
+	}
+
+	var vectors []vector
+	for i, leaf := range leaves {
+		if leaf < 0 {
+			continue
+		}
+		c := columns[leaf]
+		v := vector{name: c.name, values: values[i]}
+		if c.timestamp && len(v.values) > 0 {
+			// Se resta el origen antes de escalar para no perder resolución
+			origin := v.values[0]
+			for k := range v.values {
+				v.values[k] = (v.values[k] - origin) / c.scale
+			}
+			v.unit = "s"
+			report.Metadata["Time Origin"] = fmt.Sprintf("%.6f", origin/c.scale)
+		}
+		vectors = append(vectors, v)
+	}
+	return seriesFromVectors(vectors, opts, report)
+}
+
+// parquetSchema devuelve las columnas numéricas de primer nivel y, para cada hoja del
+// esquema (en el orden de los bloques de columna), el índice de su columna o -1 si se omite
+func parquetSchema(elements []interface{}) ([]pqColumn, []int) {
+	var columns []pqColumn
+	var leaves []int
+	if len(elements) == 0 {
+		return nil, nil
+	}
+
+	// El primer elemento es la raíz; los grupos anidados se recorren para contar sus hojas
+	var walk func(i, depth int) int
+	walk = func(i, depth int) int {
+		el, _ := elements[i].(thriftStruct)
+		children := int(el.int(5))
+		if children > 0 {
+			next := i + 1
+			for c := 0; c < children && next < len(elements); c++ {
+				next = walk(next, depth+1)
+			}
+			return next
+		}
+
+		col, ok := parquetColumn(el)
+		if !ok || depth > 1 {
+			leaves = append(leaves, -1)
+		} else {
+			leaves = append(leaves, len(columns))
+			columns = append(columns, col)
+		}
+		return i + 1
+	}
+	walk(0, 0)
+	return columns, leaves
+}
+
+// parquetColumn interpreta una hoja del esquema; solo las numéricas son columnas
+func parquetColumn(el thriftStruct) (pqColumn, bool) {
+	col := pqColumn{name: el.str(4), physical: el.int(1), optional: el.int(3) == pqOptional, scale: 1}
+	if el.int(3) > pqOptional {
+		return col, false
+	}
+	switch col.physical {
+	case pqInt32, pqInt64, pqFloat, pqDouble:
+	default:
+		return col, false
+	}
+
+	switch el.int(6) {
+	case pqConvertedDecimal:
+		col.scale = math.Pow(10, float64(el.int(7)))
+	case pqConvertedTimestampMillis:
+		col.scale, col.timestamp = 1e3, true
+	case pqConvertedTimestampMicros:
+		col.scale, col.timestamp = 1e6, true
+	}
+	// Tipo lógico TIMESTAMP (campo 8) con unidad MILLIS (1), MICROS (2) o NANOS (3)
+	if ts := el.strct(10).strct(8); ts != nil {
+		unit := ts.strct(2)
+		switch {
+		case unit.strct(1) != nil:
+			col.scale = 1e3
+		case unit.strct(2) != nil:
+			col.scale = 1e6
+		case unit.strct(3) != nil:
+			col.scale = 1e9
+		}
+		col.timestamp = true
+	}
+	return col, true
+}
+
+// readParquetChunk lee todas las páginas de un bloque de columna
+func readParquetChunk(data []byte, meta thriftStruct, col pqColumn) ([]float64, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("%w: bloque de columna sin metadatos (¿archivo externo?)", ErrUnsupportedFormat)
+	}
+	codec := meta.int(4)
+	if name, ok := pqCodecNames[codec]; ok {
+		return nil, fmt.Errorf("%w: compresión %s (se admiten sin comprimir, Snappy y gzip)", ErrUnsupportedFormat, name)
+	}
+	numValues := meta.int(5)
+	if numValues < 0 || numValues > maxBinaryValues {
+		return nil, fmt.Errorf("%w: el bloque de columna declara %d valores", ErrUnsupportedFormat, numValues)
+	}
+	start := meta.int(9)
+	if dict := meta.int(11); dict > 0 && dict < start {
+		start = dict
+	}
+	end := start + meta.int(7)
+	if start < 4 || end > int64(len(data)) || start > end {
+		return nil, fmt.Errorf("%w: bloque de columna fuera del archivo", ErrMalformed)
+	}
+
+	var dictionary []float64
+	out := make([]float64, 0, numValues)
+	for off := int(start); off < int(end) && int64(len(out)) < numValues; {
+		r := newThriftReader(data[off:end])
+		header, err := r.readStruct(0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cabecera de página ilegible: %v", ErrMalformed, err)
+		}
+		body := off + r.pos
+		size := int(header.int(3))
+		if size < 0 || body+size > int(end) {
+			return nil, fmt.Errorf("%w: página fuera del bloque de columna", ErrMalformed)
+		}
+		page := data[body : body+size]
+		off = body + size
+
+		switch header.int(1) {
+		case pqDictionaryPage:
+			raw, err := decompress(page, codec, int(header.int(2)))
+			if err != nil {
+				return nil, err
+			}
+			dictionary, err = plainValues(raw, col.physical, int(header.strct(7).int(1)))
+			if err != nil {
+				return nil, err
+			}
+		case pqDataPage:
+			raw, err := decompress(page, codec, int(header.int(2)))
+			if err != nil {
+				return nil, err
+			}
+			h := header.strct(5)
+			count, err := pageCount(h, len(out), numValues)
+			if err != nil {
+				return nil, err
+			}
+			values, err := dataPageValues(raw, h.int(2), count, col, dictionary, -1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, values...)
+		case pqDataPageV2:
+			h := header.strct(8)
+			count, err := pageCount(h, len(out), numValues)
+			if err != nil {
+				return nil, err
+			}
+			repLen, defLen := int(h.int(6)), int(h.int(5))
+			if repLen < 0 || defLen < 0 || repLen+defLen > len(page) {
+				return nil, fmt.Errorf("%w: niveles de página v2 fuera de la página", ErrMalformed)
+			}
+			levels := page[:repLen+defLen]
+			payload := page[repLen+defLen:]
+			if compressed, ok := h.boolField(7); !ok || compressed {
+				payload, err = decompress(payload, codec, int(header.int(2))-repLen-defLen)
+				if err != nil {
+					return nil, err
+				}
+			}
+			raw := append(append([]byte(nil), levels[repLen:]...), payload...)
+			values, err := dataPageValues(raw, h.int(4), count, col, dictionary, defLen)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, values...)
+		}
+	}
+
+	// Las marcas de tiempo se escalan después de restar el origen
+	if !col.timestamp {
+		for i := range out {
+			out[i] /= col.scale
+		}
+	}
+	return out, nil
+}
+
+// pageCount devuelve la cantidad de valores de una página de datos, que no puede superar
+// los que le quedan al bloque de columna después de los read ya leídos
+func pageCount(h thriftStruct, read int, total int64) (int, error) {
+	count := h.int(1)
+	if count < 0 || int64(read)+count > total {
+		return 0, fmt.Errorf("%w: la página declara %d valores y al bloque de columna le quedan %d", ErrMalformed, count, total-int64(read))
+	}
+	return int(count), nil
+}
+
+// dataPageValues decodifica los valores de una página de datos. Los nulos de las columnas
+// opcionales se devuelven como NaN. defLen es la longitud de los niveles de definición en
+// las páginas v2 (-1 en las v1, donde van precedidos por su longitud).
+func dataPageValues(raw []byte, encoding int64, count int, col pqColumn, dictionary []float64, defLen int) ([]float64, error) {
+	defined := make([]bool, count)
+	present := count
+	if col.optional {
+		if defLen < 0 {
+			if len(raw) < 4 {
+				return nil, fmt.Errorf("%w: página sin niveles de definición", ErrMalformed)
+			}
+			defLen = int(binary.LittleEndian.Uint32(raw))
+			raw = raw[4:]
+		}
+		if defLen > len(raw) {
+			return nil, fmt.Errorf("%w: niveles de definición fuera de la página", ErrMalformed)
+		}
+		levels, err := decodeHybrid(raw[:defLen], 1, count)
+		if err != nil {
+			return nil, err
+		}
+		present = 0
+		for i, l := range levels {
+			defined[i] = l == 1
+			if defined[i] {
+				present++
+			}
+		}
+		raw = raw[defLen:]
+	} else {
+		for i := range defined {
+			defined[i] = true
+		}
+	}
+
+	var values []float64
+	var err error
+	switch encoding {
+	case pqPlain:
+		values, err = plainValues(raw, col.physical, present)
+	case pqPlainDictionary, pqRLEDictionary:
+		if dictionary == nil || len(raw) < 1 {
+			return nil, fmt.Errorf("%w: página por diccionario sin diccionario", ErrMalformed)
+		}
+		var indices []int
+		indices, err = decodeHybrid(raw[1:], int(raw[0]), present)
+		values = make([]float64, len(indices))
+		for i, idx := range indices {
+			if idx >= len(dictionary) {
+				return nil, fmt.Errorf("%w: índice de diccionario fuera de rango", ErrMalformed)
+			}
+			values[i] = dictionary[idx]
+		}
+	case pqByteStreamSplit:
+		values, err = byteStreamSplit(raw, col.physical, present)
+	default:
+		return nil, fmt.Errorf("%w: codificación Parquet %d", ErrUnsupportedFormat, encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, count)
+	k := 0
+	for i := range out {
+		if defined[i] && k < len(values) {
+			out[i] = values[k]
+			k++
+		} else {
+			out[i] = math.NaN()
+		}
+	}
+	return out, nil
+}
+
+// plainValues decodifica n valores con la codificación plana
+func plainValues(raw []byte, physical int64, n int) ([]float64, error) {
+	size := 4
+	if physical == pqInt64 || physical == pqDouble {
+		size = 8
+	}
+	if n*size > len(raw) || n < 0 {
+		return nil, fmt.Errorf("%w: página con %d bytes para %d valores", ErrMalformed, len(raw), n)
+	}
+	return decodeNumbers(raw[:n*size], binary.LittleEndian, size, physical == pqFloat || physical == pqDouble, true)
+}
+
+// byteStreamSplit decodifica flotantes guardados byte a byte en flujos separados
+func byteStreamSplit(raw []byte, physical int64, n int) ([]float64, error) {
+	size := 4
+	if physical == pqInt64 || physical == pqDouble {
+		size = 8
+	}
+	if n*size > len(raw) {
+		return nil, fmt.Errorf("%w: página byte stream split truncada", ErrMalformed)
+	}
+	return plainValues(unshuffle(raw[:n*size], size), physical, n)
+}
+
+// decodeHybrid decodifica n valores con la codificación híbrida RLE/bit-packing de Parquet
+func decodeHybrid(raw []byte, width, n int) ([]int, error) {
+	out := make([]int, 0, n)
+	if width == 0 {
+		return make([]int, n), nil
+	}
+	if width > 32 {
+		return nil, fmt.Errorf("%w: ancho de bits %d", ErrMalformed, width)
+	}
+	byteWidth := (width + 7) / 8
+	for off := 0; len(out) < n; {
+		header, size := binary.Uvarint(raw[off:])
+		if size <= 0 {
+			return nil, fmt.Errorf("%w: niveles RLE truncados", ErrMalformed)
+		}
+		off += size
+		if header&1 == 0 {
+			// Repetición de un valor
+			count := int(header >> 1)
+			if off+byteWidth > len(raw) {
+				return nil, fmt.Errorf("%w: niveles RLE truncados", ErrMalformed)
+			}
+			v := int(leUint(raw[off:], byteWidth))
+			off += byteWidth
+			for i := 0; i < count && len(out) < n; i++ {
+				out = append(out, v)
+			}
+			continue
+		}
+		// Grupos de 8 valores empaquetados en bits
+		groups := int(header >> 1)
+		bytesLen := groups * width
+		if off+bytesLen > len(raw) {
+			bytesLen = len(raw) - off
+		}
+		packed := raw[off : off+bytesLen]
+		off += bytesLen
+		for i := 0; i < groups*8 && len(out) < n; i++ {
+			bit := i * width
+			if (bit+width+7)/8 > len(packed) {
+				return nil, fmt.Errorf("%w: valores empaquetados truncados", ErrMalformed)
+			}
+			v := 0
+			for b := 0; b < width; b++ {
+				if packed[(bit+b)/8]&(1<<uint((bit+b)%8)) != 0 {
+					v |= 1 << uint(b)
+				}
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// decompress descomprime una página con el códec del bloque de columna
+func decompress(page []byte, codec int64, size int) ([]byte, error) {
+	switch codec {
+	case pqUncompressed:
+		return page, nil
+	case pqSnappy:
+		return snappyDecode(page)
+	case pqGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, fmt.Errorf("%w: página gzip ilegible: %v", ErrMalformed, err)
+		}
+		defer zr.Close()
+		out, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: página gzip ilegible: %v", ErrMalformed, err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: códec Parquet %d", ErrUnsupportedFormat, codec)
+}
+
+// snappyDecode descomprime un bloque Snappy (formato sin marcos)
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > maxDecompressedSize {
+		return nil, fmt.Errorf("%w: bloque Snappy inválido", ErrMalformed)
+	}
+	dst := make([]byte, 0, length)
+	for s := n; s < len(src); {
+		tag := src[s]
+		var literal, copyLen, offset int
+		switch tag & 0x03 {
+		case 0:
+			literal = int(tag>>2) + 1
+			s++
+			if extra := int(tag>>2) - 59; extra > 0 {
+				if s+extra > len(src) {
+					return nil, fmt.Errorf("%w: bloque Snappy truncado", ErrMalformed)
+				}
+				literal = int(leUint(src[s:], extra)) + 1
+				s += extra
+			}
+			if literal <= 0 || s+literal > len(src) {
+				return nil, fmt.Errorf("%w: bloque Snappy truncado", ErrMalformed)
+			}
+			dst = append(dst, src[s:s+literal]...)
+			s += literal
+			continue
+		case 1:
+			if s+2 > len(src) {
+				return nil, fmt.Errorf("%w: bloque Snappy truncado", ErrMalformed)
+			}
+			copyLen = 4 + int(tag>>2)&0x07
+			offset = int(tag&0xE0)<<3 | int(src[s+1])
+			s += 2
+		case 2:
+			if s+3 > len(src) {
+				return nil, fmt.Errorf("%w: bloque Snappy truncado", ErrMalformed)
+			}
+			copyLen = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[s+1:]))
+			s += 3
+		case 3:
+			if s+5 > len(src) {
+				return nil, fmt.Errorf("%w: bloque Snappy truncado", ErrMalformed)
+			}
+			copyLen = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[s+1:]))
+			s += 5
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, fmt.Errorf("%w: referencia Snappy inválida", ErrMalformed)
+		}
+		// La copia puede solaparse con lo que se está escribiendo
+		for i := 0; i < copyLen; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != length {
+		return nil, fmt.Errorf("%w: bloque Snappy de longitud inesperada", ErrMalformed)
+	}
+	return dst, nil
+}
+
+// thriftStruct es una estructura Thrift decodificada: identificador de campo → valor
+// (int64, bool, float64, []byte, []interface{} o thriftStruct)
+type thriftStruct map[int16]interface{}
+
+// int devuelve un campo entero (0 si no está)
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+// str devuelve un campo binario como texto
+func (s thriftStruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+// list devuelve un campo lista
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// strct devuelve un campo estructura (nil si no está)
+func (s thriftStruct) strct(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// boolField devuelve un campo booleano y si está presente
+func (s thriftStruct) boolField(id int16) (bool, bool) {
+	v, ok := s[id].(bool)
+	return v, ok
+}
+
+// Tipos del protocolo compacto de Thrift
+const (
+	thriftTypeTrue   = 1
+	thriftTypeFalse  = 2
+	thriftTypeByte   = 3
+	thriftTypeI16    = 4
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeDouble = 7
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeSet    = 10
+	thriftTypeMap    = 11
+	thriftTypeStruct = 12
+)
+
+// Profundidad máxima de anidamiento de estructuras Thrift
+const maxThriftDepth = 32
+
+var errThriftTruncated = errors.New("datos Thrift truncados")
+
+// thriftReader decodifica el protocolo compacto de Thrift usado por los metadatos de Parquet
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{data: data}
+}
+
+// readStruct lee una estructura hasta su marca de fin
+func (r *thriftReader) readStruct(depth int) (thriftStruct, error) {
+	if depth > maxThriftDepth {
+		return nil, errors.New("estructura Thrift demasiado anidada")
+	}
+	s := make(thriftStruct)
+	var last int16
+	for {
+		if r.pos >= len(r.data) {
+			return nil, errThriftTruncated
+		}
+		b := r.data[r.pos]
+		r.pos++
+		if b == 0 {
+			return s, nil
+		}
+		kind := b & 0x0F
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(zigzag(v))
+		}
+		last = id
+
+		if kind == thriftTypeTrue || kind == thriftTypeFalse {
+			s[id] = kind == thriftTypeTrue
+			continue
+		}
+		v, err := r.value(kind, depth)
+		if err != nil {
+			return nil, err
+		}
+		s[id] = v
+	}
+}
+
+// value lee un valor del tipo dado
+func (r *thriftReader) value(kind byte, depth int) (interface{}, error) {
+	switch kind {
+	case thriftTypeTrue, thriftTypeFalse:
+		// Dentro de listas los booleanos ocupan un byte
+		if r.pos >= len(r.data) {
+			return nil, errThriftTruncated
+		}
+		r.pos++
+		return r.data[r.pos-1] == thriftTypeTrue, nil
+	case thriftTypeByte:
+		if r.pos >= len(r.data) {
+			return nil, errThriftTruncated
+		}
+		r.pos++
+		return int64(int8(r.data[r.pos-1])), nil
+	case thriftTypeI16, thriftTypeI32, thriftTypeI64:
+		v, err := r.varint()
+		return zigzag(v), err
+	case thriftTypeDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, errThriftTruncated
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos-8:])), nil
+	case thriftTypeBinary:
+		n, err := r.varint()
+		if err != nil || n > uint64(len(r.data)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		r.pos += int(n)
+		return r.data[r.pos-int(n) : r.pos], nil
+	case thriftTypeList, thriftTypeSet:
+		if r.pos >= len(r.data) {
+			return nil, errThriftTruncated
+		}
+		header := r.data[r.pos]
+		r.pos++
+		size, elem := uint64(header>>4), header&0x0F
+		if size == 15 {
+			var err error
+			if size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(r.data)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			v, err := r.value(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case thriftTypeMap:
+		size, err := r.varint()
+		if err != nil || size > uint64(len(r.data)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		if r.pos >= len(r.data) {
+			return nil, errThriftTruncated
+		}
+		types := r.data[r.pos]
+		r.pos++
+		for i := uint64(0); i < 2*size; i++ {
+			kind := types >> 4
+			if i%2 == 1 {
+				kind = types & 0x0F
+			}
+			if _, err := r.value(kind, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftTypeStruct:
+		return r.readStruct(depth + 1)
+	}
+	return nil, fmt.Errorf("tipo Thrift %d desconocido", kind)
+}
+
+// varint lee un entero de longitud variable
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+// zigzag decodifica un entero con signo en codificación zigzag
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
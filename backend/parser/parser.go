@@ -0,0 +1,612 @@
+// Package parser lee las series de tiempo de los archivos subidos (CSV con cualquier
+// delimitador habitual, separador decimal punto o coma, unidades y marcas BOM, además de las
+// exportaciones de osciloscopios Rigol, Tektronix y Keysight y de LTspice, y los formatos
+// binarios WAV, MATLAB, HDF5 y Parquet) y devuelve un reporte estructurado de la lectura.
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultSamplingPeriod es el período de muestreo supuesto si no puede determinarse
+const DefaultSamplingPeriod = 0.001
+
+// MaxRejectedRows es la cantidad máxima de filas rechazadas detalladas en el reporte
+const MaxRejectedRows = 100
+
+// Roles de las columnas leídas
+const (
+	RoleTime   = "time"
+	RoleOutput = "output"
+	RoleInput  = "input"
+)
+
+// Origen del período de muestreo informado en el reporte
+const (
+	SamplingFromMetadata = "metadata"
+	SamplingFromData     = "data"
+	SamplingDefault      = "default"
+)
+
+var (
+	// ErrNoData indica que el archivo no contiene filas numéricas utilizables
+	ErrNoData = errors.New("el archivo no contiene filas numéricas de tiempo y salida")
+	// ErrInvalidColumns indica una asignación de columnas imposible para el archivo
+	ErrInvalidColumns = errors.New("asignación de columnas inválida")
+	// ErrInvalidOptions indica opciones de lectura incoherentes
+	ErrInvalidOptions = errors.New("opciones de lectura inválidas")
+)
+
+// Claves del preámbulo que declaran el período de muestreo
+var samplingPeriodKeys = []string{"sampling period", "sample period", "sample interval", "sampling interval", "periodo de muestreo", "período de muestreo", "xinc"}
+
+// Nombres de cabecera reconocidos para cada columna
+var (
+	timeNames   = []string{"time", "tiempo", "t", "x", "x-axis", "seconds", "segundos"}
+	outputNames = []string{"output", "salida", "y", "vout", "v(out)"}
+)
+
+// Cabeceras con unidad: "Tiempo (ms)", "Voltaje [V]" o "t/s"
+var headerUnitPattern = regexp.MustCompile(`^(.*?)\s*(?:[\(\[]\s*([^\)\]]+?)\s*[\)\]]|/\s*(\S+))\s*$`)
+
+// ColumnRef identifica una columna por índice (base cero) o por nombre de cabecera.
+// En JSON se escribe como número o como texto.
+type ColumnRef struct {
+	Index int
+	Name  string
+}
+
+// MarshalJSON escribe la referencia como número o como texto
+func (c ColumnRef) MarshalJSON() ([]byte, error) {
+	if c.Name != "" {
+		return json.Marshal(c.Name)
+	}
+	return json.Marshal(c.Index)
+}
+
+// UnmarshalJSON acepta un índice numérico o un nombre de cabecera
+func (c *ColumnRef) UnmarshalJSON(data []byte) error {
+	var index int
+	if err := json.Unmarshal(data, &index); err == nil {
+		*c = ColumnRef{Index: index}
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return errors.New("la columna debe ser un índice o un nombre de cabecera")
+	}
+	*c = ColumnRef{Name: name}
+	return nil
+}
+
+// String describe la referencia para los mensajes de error
+func (c ColumnRef) String() string {
+	if c.Name != "" {
+		return strconv.Quote(c.Name)
+	}
+	return strconv.Itoa(c.Index)
+}
+
+// ColumnMapping asigna explícitamente las columnas del archivo. Las no indicadas se
+// detectan por el nombre de la cabecera (tiempo en la 0 y salida en la 1 por defecto);
+// la entrada solo se lee si se indica.
+type ColumnMapping struct {
+	Time   *ColumnRef `json:"time,omitempty"`
+	Output *ColumnRef `json:"output,omitempty"`
+	Input  *ColumnRef `json:"input,omitempty"`
+}
+
+// Options configura la lectura; los valores vacíos se detectan automáticamente
+type Options struct {
+	Format           string        `json:"format,omitempty"`            // csv, rigol, tektronix, keysight, ltspice, wav, mat, hdf5 o parquet
+	Delimiter        string        `json:"delimiter,omitempty"`         // ";", ",", "\t" (o "tab"), " " (o "space")
+	DecimalSeparator string        `json:"decimal_separator,omitempty"` // "." o ","
+	Columns          ColumnMapping `json:"columns,omitempty"`
+}
+
+// Validate comprueba que las opciones sean coherentes
+func (o Options) Validate() error {
+	if !ValidFormat(o.Format) {
+		return errors.New("format debe ser csv, rigol, tektronix, keysight, ltspice, wav, mat, hdf5 o parquet")
+	}
+	if _, err := o.delimiter(); err != nil {
+		return err
+	}
+	if o.DecimalSeparator != "" && o.DecimalSeparator != "." && o.DecimalSeparator != "," {
+		return errors.New("decimal_separator debe ser \".\" o \",\"")
+	}
+	if d, _ := o.delimiter(); d == ',' && o.DecimalSeparator == "," {
+		return errors.New("la coma no puede ser a la vez delimitador y separador decimal")
+	}
+
+	seen := make(map[int]string)
+	for role, ref := range map[string]*ColumnRef{RoleTime: o.Columns.Time, RoleOutput: o.Columns.Output, RoleInput: o.Columns.Input} {
+		if ref == nil || ref.Name != "" {
+			continue
+		}
+		if ref.Index < 0 {
+			return fmt.Errorf("la columna de %s no puede ser negativa", role)
+		}
+		if other, ok := seen[ref.Index]; ok {
+			return fmt.Errorf("las columnas de %s y %s no pueden ser la misma", other, role)
+		}
+		seen[ref.Index] = role
+	}
+	return nil
+}
+
+// delimiter devuelve el delimitador indicado (0 para detectarlo)
+func (o Options) delimiter() (rune, error) {
+	switch o.Delimiter {
+	case "":
+		return 0, nil
+	case ";", ",":
+		return rune(o.Delimiter[0]), nil
+	case "\t", "tab":
+		return '\t', nil
+	case " ", "space":
+		return ' ', nil
+	}
+	return 0, errors.New("delimiter debe ser \";\", \",\", \"tab\" o \"space\"")
+}
+
+// Series es la serie de tiempo leída, en unidades base (segundos, voltios...)
+type Series struct {
+	Time           []float64
+	Output         []float64
+	Input          []float64 // Solo si se asignó una columna de entrada
+	SamplingPeriod float64
+}
+
+// ColumnInfo describe una columna leída
+type ColumnInfo struct {
+	Role     string  `json:"role"`
+	Index    int     `json:"index"`
+	Name     string  `json:"name,omitempty"`
+	Unit     string  `json:"unit,omitempty"`      // Unidad detectada en la cabecera o en los valores
+	BaseUnit string  `json:"base_unit,omitempty"` // Unidad a la que se convirtieron los valores
+	Scale    float64 `json:"scale"`               // Factor aplicado por el prefijo de la cabecera
+}
+
+// RejectedRow es una fila de datos descartada
+type RejectedRow struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Report resume la lectura de un archivo
+type Report struct {
+	Format               string            `json:"format"`
+	Instrument           *Instrument       `json:"instrument,omitempty"` // Metadatos del osciloscopio o simulador
+	Encoding             string            `json:"encoding,omitempty"`   // Codificación indicada por la BOM
+	Delimiter            string            `json:"delimiter,omitempty"`  // Solo en los formatos de texto
+	DecimalSeparator     string            `json:"decimal_separator,omitempty"`
+	HeaderLine           int               `json:"header_line,omitempty"`
+	Headers              []string          `json:"headers,omitempty"`
+	Columns              []ColumnInfo      `json:"columns"`
+	Metadata             map[string]string `json:"metadata,omitempty"` // Pares clave-valor del preámbulo
+	RowsAccepted         int               `json:"rows_accepted"`
+	RowsRejected         int               `json:"rows_rejected"`
+	Rejected             []RejectedRow     `json:"rejected,omitempty"`        // Primeras filas rechazadas
+	RowsNonFinite        int               `json:"rows_non_finite,omitempty"` // Filas rechazadas por valores NaN o infinitos
+	SamplingPeriod       float64           `json:"sampling_period"`
+	SamplingPeriodSource string            `json:"sampling_period_source"`
+
+	declaredPeriod float64 // Período declarado por un formato binario (0 si no lo declara)
+	assumedPeriod  bool    // El tiempo se reconstruyó con el período por defecto
+}
+
+// reject registra una fila rechazada
+func (r *Report) reject(line int, reason string) {
+	r.RowsRejected++
+	if len(r.Rejected) < MaxRejectedRows {
+		r.Rejected = append(r.Rejected, RejectedRow{Line: line, Reason: reason})
+	}
+}
+
+// column es una columna resuelta para la extracción de valores
+type column struct {
+	info  ColumnInfo
+	scale float64
+}
+
+// Parse lee un archivo con series de tiempo. El formato (CSV genérico, la exportación de un
+// osciloscopio o de LTspice, o un formato binario) se detecta por su firma o su preámbulo
+// salvo que se indique en las opciones. En el CSV genérico las líneas anteriores a la
+// primera fila numérica forman el preámbulo (pares clave-valor y cabecera); las filas de
+// datos que no pueden leerse se rechazan y se detallan en el reporte.
+func Parse(r io.Reader, opts Options) (*Series, *Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = detectBinaryFormat(data)
+	}
+	report := &Report{
+		Format:   format,
+		Metadata: make(map[string]string),
+	}
+
+	var series *Series
+	switch format {
+	case FormatWAV:
+		series, err = parseWAV(data, opts, report)
+	case FormatMAT:
+		series, err = parseMAT(data, opts, report)
+	case FormatHDF5:
+		series, err = parseHDF5(data, opts, report)
+	case FormatParquet:
+		series, err = parseParquet(data, opts, report)
+	default:
+		series, err = parseText(data, opts, report)
+	}
+	if err != nil {
+		return nil, report, err
+	}
+
+	series.SamplingPeriod, report.SamplingPeriodSource = samplingPeriod(report, series.Time)
+	report.SamplingPeriod = series.SamplingPeriod
+	return series, report, nil
+}
+
+// parseText lee los formatos de texto: CSV genérico y exportaciones de instrumentos
+func parseText(data []byte, opts Options, report *Report) (*Series, error) {
+	text, encoding := decodeText(data)
+	lines := splitLines(text)
+	report.Encoding = encoding
+	if report.Format == "" {
+		report.Format = detectFormat(lines)
+	}
+
+	switch report.Format {
+	case FormatRigol:
+		return parseRigol(lines, opts, report)
+	case FormatTektronix:
+		return parseTektronix(lines, opts, report)
+	case FormatKeysight:
+		return parseKeysight(lines, opts, report)
+	case FormatLTspice:
+		return parseLTspice(lines, opts, report)
+	}
+	return parseTable(lines, opts, report, tableHints{})
+}
+
+// tableHints es la información que un formato conoce de antemano sobre la tabla de datos
+type tableHints struct {
+	headers  []string      // Cabecera (si no está en una línea de la tabla)
+	units    []string      // Unidades por columna declaradas fuera de la cabecera
+	sequence *sequenceTime // La columna de tiempo es un índice de muestra
+}
+
+// sequenceTime convierte índices de muestra en tiempo: t = start + k·increment
+type sequenceTime struct {
+	start, increment float64
+}
+
+// parseTable lee una tabla delimitada. Las líneas vacías se ignoran, por lo que los
+// formatos pueden vaciar las líneas ya interpretadas conservando la numeración.
+func parseTable(lines []string, opts Options, report *Report, hints tableHints) (*Series, error) {
+	delimiterOpt, _ := opts.delimiter()
+	var decimalOpt rune
+	if opts.DecimalSeparator != "" {
+		decimalOpt = rune(opts.DecimalSeparator[0])
+	}
+	delimiter, decimal := sniffDialect(lines, delimiterOpt, decimalOpt)
+	report.Delimiter = delimiterName(delimiter)
+	report.DecimalSeparator = string(decimal)
+	if hints.headers != nil {
+		report.Headers = hints.headers
+	}
+
+	series := &Series{}
+	var columns []column
+	var err error
+	for i, line := range lines {
+		lineNumber := i + 1
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := splitFields(line, delimiter)
+
+		// Preámbulo: hasta la primera fila con al menos dos valores numéricos
+		if columns == nil {
+			if numericFields(fields, decimal) < 2 {
+				readPreambleLine(report, fields, lineNumber, decimal)
+				continue
+			}
+			if columns, err = resolveColumns(report.Headers, hints.units, len(fields), opts.Columns); err != nil {
+				return nil, err
+			}
+		}
+
+		values, reason, nonFinite := extractRow(fields, columns, decimal)
+		if reason != "" {
+			if nonFinite {
+				report.RowsNonFinite++
+			}
+			report.reject(lineNumber, reason)
+			continue
+		}
+		series.Time = append(series.Time, values[0])
+		series.Output = append(series.Output, values[1])
+		if len(columns) > 2 {
+			series.Input = append(series.Input, values[2])
+		}
+		report.RowsAccepted++
+
+		// Unidades escritas junto a los valores (solo si la cabecera no las declara)
+		if report.RowsAccepted == 1 {
+			for k := range columns {
+				if columns[k].info.Unit == "" {
+					_, unit, _ := parseValue(fields[columns[k].info.Index], decimal)
+					columns[k].info.Unit = unit
+					_, columns[k].info.BaseUnit, _ = unitScale(unit)
+				}
+			}
+		}
+	}
+
+	if seq := hints.sequence; seq != nil && len(columns) > 0 {
+		for i, k := range series.Time {
+			series.Time[i] = seq.start + k*seq.increment
+		}
+		columns[0].info.Unit = ""
+		columns[0].info.BaseUnit = "s"
+	}
+
+	for _, c := range columns {
+		report.Columns = append(report.Columns, c.info)
+	}
+	if report.RowsAccepted == 0 {
+		return nil, ErrNoData
+	}
+	return series, nil
+}
+
+// readPreambleLine interpreta una línea del preámbulo como cabecera o como par clave-valor
+func readPreambleLine(report *Report, fields []string, lineNumber int, decimal rune) {
+	if len(fields) == 0 {
+		return
+	}
+
+	// Cabecera: dos o más campos de texto (la última antes de los datos prevalece)
+	text := 0
+	for _, f := range fields {
+		if isTextField(f, decimal) {
+			text++
+		}
+	}
+	if len(fields) >= 2 && text == len(fields) {
+		report.Headers = fields
+		report.HeaderLine = lineNumber
+		return
+	}
+
+	// Par clave-valor ("Sampling Period,0.001" o "Sample Interval: 2e-6")
+	key, value := fields[0], strings.Join(fields[1:], " ")
+	if len(fields) == 1 {
+		parts := strings.SplitN(fields[0], ":", 2)
+		if len(parts) != 2 {
+			return
+		}
+		key, value = parts[0], parts[1]
+	}
+	key, value = strings.TrimSpace(strings.TrimSuffix(key, ":")), strings.TrimSpace(value)
+	if key != "" && value != "" {
+		report.Metadata[key] = value
+	}
+}
+
+// resolveColumns determina las columnas de tiempo, salida y (opcionalmente) entrada a partir
+// de la asignación explícita y de los nombres de cabecera
+func resolveColumns(headers, units []string, width int, mapping ColumnMapping) ([]column, error) {
+	find := func(ref *ColumnRef, role string) (int, error) {
+		if ref.Name == "" {
+			if ref.Index >= width {
+				return 0, fmt.Errorf("%w: la columna %d de %s no existe (el archivo tiene %d columnas)", ErrInvalidColumns, ref.Index, role, width)
+			}
+			return ref.Index, nil
+		}
+		for i, h := range headers {
+			label, _ := splitHeaderUnit(h)
+			if strings.EqualFold(label, strings.TrimSpace(ref.Name)) || strings.EqualFold(h, strings.TrimSpace(ref.Name)) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: no hay una cabecera %s para la columna de %s", ErrInvalidColumns, ref, role)
+	}
+	byName := func(names []string, exclude ...int) int {
+		for i, h := range headers {
+			label, _ := splitHeaderUnit(h)
+			if containsInt(exclude, i) {
+				continue
+			}
+			for _, n := range names {
+				if strings.EqualFold(label, n) || strings.EqualFold(strings.TrimSpace(h), n) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	input := -1
+	if mapping.Input != nil {
+		var err error
+		if input, err = find(mapping.Input, RoleInput); err != nil {
+			return nil, err
+		}
+	}
+
+	timeIdx := byName(timeNames, input)
+	if mapping.Time != nil {
+		var err error
+		if timeIdx, err = find(mapping.Time, RoleTime); err != nil {
+			return nil, err
+		}
+	} else if timeIdx < 0 {
+		timeIdx = 0
+	}
+
+	outputIdx := byName(outputNames, timeIdx, input)
+	if mapping.Output != nil {
+		var err error
+		if outputIdx, err = find(mapping.Output, RoleOutput); err != nil {
+			return nil, err
+		}
+	} else if outputIdx < 0 {
+		for i := 0; i < width; i++ {
+			if i != timeIdx && i != input {
+				outputIdx = i
+				break
+			}
+		}
+	}
+
+	if outputIdx < 0 || outputIdx == timeIdx || (input >= 0 && (input == timeIdx || input == outputIdx)) {
+		return nil, fmt.Errorf("%w: el tiempo, la salida y la entrada deben estar en columnas distintas", ErrInvalidColumns)
+	}
+
+	indices := []int{timeIdx, outputIdx}
+	roles := []string{RoleTime, RoleOutput}
+	if input >= 0 {
+		indices = append(indices, input)
+		roles = append(roles, RoleInput)
+	}
+
+	columns := make([]column, len(indices))
+	for k, idx := range indices {
+		c := column{info: ColumnInfo{Role: roles[k], Index: idx, Scale: 1}, scale: 1}
+		var unit string
+		if idx < len(headers) {
+			c.info.Name, unit = splitHeaderUnit(headers[idx])
+		}
+		if idx < len(units) && units[idx] != "" {
+			unit = units[idx]
+		}
+		if unit != "" {
+			scale, base, ok := unitScale(unit)
+			c.info.Unit = unit
+			if ok {
+				c.info.BaseUnit = base
+				c.info.Scale = scale
+				c.scale = scale
+			}
+		}
+		columns[k] = c
+	}
+	return columns, nil
+}
+
+// extractRow lee los valores de las columnas resueltas; si la fila no es válida devuelve el
+// motivo e indica si se debe a un valor no finito (NaN o infinito)
+func extractRow(fields []string, columns []column, decimal rune) ([]float64, string, bool) {
+	values := make([]float64, len(columns))
+	for k, c := range columns {
+		idx := c.info.Index
+		if idx >= len(fields) {
+			return nil, fmt.Sprintf("falta la columna %d (%s); la fila tiene %d columnas", idx, c.info.Role, len(fields)), false
+		}
+		v, unit, ok := parseValue(fields[idx], decimal)
+		if !ok {
+			return nil, fmt.Sprintf("valor no numérico en la columna %d (%s): %q", idx, c.info.Role, fields[idx]), false
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Sprintf("valor no finito en la columna %d (%s): %q", idx, c.info.Role, fields[idx]), true
+		}
+		if unit == "" {
+			v *= c.scale
+		}
+		values[k] = v
+	}
+	return values, "", false
+}
+
+// splitHeaderUnit separa el nombre de una cabecera y su unidad
+func splitHeaderUnit(header string) (string, string) {
+	header = strings.TrimSpace(header)
+	m := headerUnitPattern.FindStringSubmatch(header)
+	if m == nil || m[1] == "" {
+		return header, ""
+	}
+	unit := m[2]
+	if unit == "" {
+		unit = m[3]
+	}
+	// Los paréntesis que no encierran una unidad forman parte del nombre: V(out), I(R1)
+	if _, _, ok := unitScale(unit); !ok {
+		return header, ""
+	}
+	return m[1], unit
+}
+
+// samplingPeriod obtiene el período de muestreo declarado por el instrumento o en el
+// preámbulo o, si no se declara, la mediana de los incrementos positivos del tiempo
+func samplingPeriod(report *Report, t []float64) (float64, string) {
+	if report.Instrument != nil && report.Instrument.SampleInterval > 0 {
+		return report.Instrument.SampleInterval, SamplingFromMetadata
+	}
+	if report.declaredPeriod > 0 {
+		return report.declaredPeriod, SamplingFromMetadata
+	}
+	if report.assumedPeriod {
+		return DefaultSamplingPeriod, SamplingDefault
+	}
+	decimal := '.'
+	if report.DecimalSeparator == "," {
+		decimal = ','
+	}
+	for key, value := range report.Metadata {
+		lower := strings.ToLower(key)
+		for _, k := range samplingPeriodKeys {
+			if strings.Contains(lower, k) {
+				if v, _, ok := parseValue(value, decimal); ok && v > 0 && !math.IsInf(v, 0) {
+					return v, SamplingFromMetadata
+				}
+			}
+		}
+	}
+	if dt := medianStep(t); dt > 0 {
+		return dt, SamplingFromData
+	}
+	return DefaultSamplingPeriod, SamplingDefault
+}
+
+// medianStep devuelve la mediana de los incrementos positivos de t (0 si no hay)
+func medianStep(t []float64) float64 {
+	var steps []float64
+	for i := 1; i < len(t); i++ {
+		if d := t[i] - t[i-1]; d > 0 {
+			steps = append(steps, d)
+		}
+	}
+	if len(steps) == 0 {
+		return 0
+	}
+	sort.Float64s(steps)
+	return steps[len(steps)/2]
+}
+
+// containsInt indica si v está en values
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
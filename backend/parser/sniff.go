@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Delimitadores candidatos, en orden de preferencia ante empates
+var candidateDelimiters = []rune{';', '\t', ',', ' '}
+
+// Líneas examinadas para detectar el dialecto
+const sniffLines = 200
+
+// decodeText elimina la marca de orden de bytes (BOM) y decodifica UTF-16 si la hay
+func decodeText(data []byte) (string, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), "utf-8"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false), "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true), "utf-16be"
+	}
+	return string(data), ""
+}
+
+// decodeUTF16 decodifica texto UTF-16 con el orden de bytes indicado
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// splitLines separa el texto en líneas admitiendo finales \n, \r\n y \r
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.Split(text, "\n")
+}
+
+// splitFields separa una línea con el delimitador dado, respetando comillas. El espacio
+// como delimitador agrupa espacios consecutivos.
+func splitFields(line string, delimiter rune) []string {
+	var fields []string
+	if delimiter == ' ' {
+		fields = strings.Fields(line)
+	} else {
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = delimiter
+		r.LazyQuotes = true
+		r.FieldsPerRecord = -1
+		record, err := r.Read()
+		if err != nil {
+			record = strings.Split(line, string(delimiter))
+		}
+		fields = record
+	}
+
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), `"'`)
+	}
+	// Un delimitador final deja un campo vacío que no es una columna
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
+
+// sniffDialect elige el delimitador y el separador decimal que convierten en numéricas
+// (al menos dos columnas) más líneas de la muestra
+func sniffDialect(lines []string, delimiter, decimal rune) (rune, rune) {
+	delimiters := candidateDelimiters
+	if delimiter != 0 {
+		delimiters = []rune{delimiter}
+	}
+
+	var sample []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			sample = append(sample, line)
+		}
+		if len(sample) == sniffLines {
+			break
+		}
+	}
+
+	bestDelimiter, bestDecimal, bestScore := delimiters[0], '.', -1
+	for _, d := range delimiters {
+		decimals := []rune{'.', ','}
+		if decimal != 0 {
+			decimals = []rune{decimal}
+		}
+		for _, dec := range decimals {
+			if d == ',' && dec == ',' {
+				continue
+			}
+			score := 0
+			for _, line := range sample {
+				if numericFields(splitFields(line, d), dec) >= 2 {
+					score++
+				}
+			}
+			if score > bestScore {
+				bestDelimiter, bestDecimal, bestScore = d, dec, score
+			}
+		}
+	}
+	if decimal != 0 {
+		bestDecimal = decimal
+	}
+	return bestDelimiter, bestDecimal
+}
+
+// numericFields cuenta los campos numéricos de una fila; devuelve 0 si alguno no lo es
+func numericFields(fields []string, decimal rune) int {
+	count := 0
+	for _, f := range fields {
+		if _, _, ok := parseValue(f, decimal); !ok {
+			return 0
+		}
+		count++
+	}
+	return count
+}
+
+// delimiterName devuelve un nombre legible del delimitador para el reporte
+func delimiterName(d rune) string {
+	switch d {
+	case '\t':
+		return "tab"
+	case ' ':
+		return "space"
+	}
+	return string(d)
+}
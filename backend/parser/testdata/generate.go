@@ -0,0 +1,926 @@
+//go:build ignore
+
+// generate escribe los archivos de muestra de los formatos binarios usados por las pruebas
+// del paquete parser. Se escriben byte a byte a partir de las especificaciones (RIFF/WAVE,
+// MAT-File Level 5, HDF5 y Parquet con Thrift compacto) sin usar el propio parser.
+//
+//	cd parser/testdata && go run generate.go
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"log"
+	"math"
+	"os"
+)
+
+// Señal común: 100 muestras cada 10 ms de la respuesta de primer orden con τ = 0.2 s, un
+// escalón en la muestra 25 y una escalera de tres niveles
+const (
+	samples = 100
+	period  = 0.01
+)
+
+func timeAt(i int) float64 { return float64(i) * period }
+
+func response(i int) float64 { return 1 - math.Exp(-timeAt(i)/0.2) }
+
+func step(i int) float64 {
+	if i >= 25 {
+		return 1
+	}
+	return 0
+}
+
+func staircase(i int) float64 {
+	switch {
+	case i < 25:
+		return 0
+	case i < 50:
+		return 0.5
+	}
+	return 1
+}
+
+func signal(f func(int) float64) []float64 {
+	out := make([]float64, samples)
+	for i := range out {
+		out[i] = f(i)
+	}
+	return out
+}
+
+func main() {
+	files := map[string][]byte{
+		"pcm16.wav":          wavPCM16(),
+		"float32.wav":        wavFloat32(),
+		"compressed.mat":     matCompressed(),
+		"struct.mat":         matStruct(),
+		"contiguous.h5":      hdf5Contiguous(),
+		"chunked.h5":         hdf5Chunked(),
+		"plain.parquet":      parquetPlain(),
+		"dictionary.parquet": parquetDictionary(),
+		"snappy.parquet":     parquetSnappy(),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+var le = binary.LittleEndian
+
+func u16(v int) []byte    { return le.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte    { return le.AppendUint32(nil, uint32(v)) }
+func u64(v uint64) []byte { return le.AppendUint64(nil, v) }
+
+func f64s(values []float64) []byte {
+	var out []byte
+	for _, v := range values {
+		out = le.AppendUint64(out, math.Float64bits(v))
+	}
+	return out
+}
+
+func f32s(values []float64) []byte {
+	var out []byte
+	for _, v := range values {
+		out = le.AppendUint32(out, math.Float32bits(float32(v)))
+	}
+	return out
+}
+
+func pad8(b []byte) []byte {
+	for len(b)%8 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func zlibCompress(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// ---------------------------------------------------------------------------------------
+// WAV
+
+func riffChunk(id string, body []byte) []byte {
+	out := append([]byte(id), u32(len(body))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), u32(len(body))...), body...)
+}
+
+// wavPCM16: dos canales PCM de 16 bits a 100 Hz (respuesta y entrada constante de 0.5) con
+// una lista INFO de longitud impar
+func wavPCM16() []byte {
+	const rate, channels = 100, 2
+	fmtBody := append(u16(1), u16(channels)...)
+	fmtBody = append(fmtBody, u32(rate)...)
+	fmtBody = append(fmtBody, u32(rate*channels*2)...)
+	fmtBody = append(fmtBody, u16(channels*2)...)
+	fmtBody = append(fmtBody, u16(16)...)
+
+	var data []byte
+	for i := 0; i < samples; i++ {
+		data = append(data, u16(int(int16(math.Round(response(i)*32767))))...)
+		data = append(data, u16(16384)...)
+	}
+	info := append([]byte("INFO"), riffChunk("INAM", []byte("prueba\x00"))...)
+	info = append(info, riffChunk("ISFT", []byte("generate.go\x00"))...)
+	return riff(riffChunk("fmt ", fmtBody), riffChunk("LIST", info), riffChunk("data", data))
+}
+
+// wavFloat32: un canal de punto flotante de 32 bits a 100 Hz con WAVE_FORMAT_EXTENSIBLE
+func wavFloat32() []byte {
+	const rate = 100
+	fmtBody := append(u16(0xFFFE), u16(1)...)
+	fmtBody = append(fmtBody, u32(rate)...)
+	fmtBody = append(fmtBody, u32(rate*4)...)
+	fmtBody = append(fmtBody, u16(4)...)
+	fmtBody = append(fmtBody, u16(32)...)
+	fmtBody = append(fmtBody, u16(22)...)  // Tamaño de la extensión
+	fmtBody = append(fmtBody, u16(32)...)  // Bits válidos
+	fmtBody = append(fmtBody, u32(0x4)...) // Máscara de canales: frontal central
+	// KSDATAFORMAT_SUBTYPE_IEEE_FLOAT
+	fmtBody = append(fmtBody, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+	fact := riffChunk("fact", u32(samples))
+	return riff(riffChunk("fmt ", fmtBody), fact, riffChunk("data", f32s(signal(response))))
+}
+
+// ---------------------------------------------------------------------------------------
+// MAT v5
+
+const (
+	miINT8       = 1
+	miUINT8      = 2
+	miINT32      = 5
+	miUINT32     = 6
+	miSINGLE     = 7
+	miDOUBLE     = 9
+	miMATRIX     = 14
+	miCOMPRESSED = 15
+
+	mxSTRUCT = 2
+	mxDOUBLE = 6
+	mxSINGLE = 7
+)
+
+func matHeader() []byte {
+	text := []byte("MATLAB 5.0 MAT-file, Platform: GLNXA64, Created on: Sat Oct 17 12:00:00 2026")
+	for len(text) < 116 {
+		text = append(text, ' ')
+	}
+	text = append(text, make([]byte, 8)...) // Sin datos de subsistema
+	text = append(text, u16(0x0100)...)
+	return append(text, 'I', 'M')
+}
+
+// matElement escribe un elemento; los de hasta 4 bytes usan el formato corto
+func matElement(kind int, data []byte) []byte {
+	if len(data) > 0 && len(data) <= 4 {
+		out := u32(len(data)<<16 | kind)
+		return append(out, pad4(data)...)
+	}
+	return pad8(append(append(u32(kind), u32(len(data))...), data...))
+}
+
+func pad4(b []byte) []byte {
+	out := append([]byte(nil), b...)
+	for len(out) < 4 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// matMatrix arma un miMATRIX numérico de rows × cols con los datos ya codificados
+func matMatrix(name string, class, rows, cols, dataType int, data []byte) []byte {
+	body := matElement(miUINT32, append(u32(class), u32(0)...))
+	body = append(body, matElement(miINT32, append(u32(rows), u32(cols)...))...)
+	body = append(body, matElement(miINT8, []byte(name))...)
+	body = append(body, matElement(dataType, data)...)
+	return append(append(u32(miMATRIX), u32(len(body))...), body...)
+}
+
+func compressed(element []byte) []byte {
+	z := zlibCompress(element)
+	return append(append(u32(miCOMPRESSED), u32(len(z))...), z...)
+}
+
+// matCompressed: variables comprimidas t y y (100×1), la entrada u como double guardado en
+// miUINT8 (como hace MATLAB con los valores enteros) y el escalar Ts
+func matCompressed() []byte {
+	out := matHeader()
+	out = append(out, compressed(matMatrix("t", mxDOUBLE, samples, 1, miDOUBLE, f64s(signal(timeAt))))...)
+	out = append(out, compressed(matMatrix("y", mxDOUBLE, samples, 1, miDOUBLE, f64s(signal(response))))...)
+	u := make([]byte, samples)
+	for i := range u {
+		u[i] = byte(step(i))
+	}
+	out = append(out, compressed(matMatrix("u", mxDOUBLE, samples, 1, miUINT8, u))...)
+	out = append(out, compressed(matMatrix("Ts", mxDOUBLE, 1, 1, miDOUBLE, f64s([]float64{period})))...)
+	return out
+}
+
+// matStruct: estructura 1×1 sin comprimir con los campos time (1×100), output (single) y fs
+func matStruct() []byte {
+	fields := []string{"time", "output", "fs"}
+	const width = 32
+	names := make([]byte, width*len(fields))
+	for i, f := range fields {
+		copy(names[i*width:], f)
+	}
+
+	body := matElement(miUINT32, append(u32(mxSTRUCT), u32(0)...))
+	body = append(body, matElement(miINT32, append(u32(1), u32(1)...))...)
+	body = append(body, matElement(miINT8, []byte("scope"))...)
+	body = append(body, matElement(miINT32, u32(width))...)
+	body = append(body, matElement(miINT8, names)...)
+	body = append(body, matMatrix("", mxDOUBLE, 1, samples, miDOUBLE, f64s(signal(timeAt)))...)
+	body = append(body, matMatrix("", mxSINGLE, samples, 1, miSINGLE, f32s(signal(response)))...)
+	body = append(body, matMatrix("", mxDOUBLE, 1, 1, miDOUBLE, f64s([]float64{1 / period}))...)
+	return append(matHeader(), append(append(u32(miMATRIX), u32(len(body))...), body...)...)
+}
+
+// ---------------------------------------------------------------------------------------
+// HDF5: superbloque 0, cabeceras de objeto v1, grupos con tabla de símbolos y direcciones y
+// longitudes de 8 bytes, como los escribe la biblioteca con sus opciones por defecto
+
+const undefined = math.MaxUint64
+
+type h5Writer struct {
+	buf []byte
+}
+
+// alloc agrega un bloque alineado a 8 bytes y devuelve su dirección
+func (w *h5Writer) alloc(b []byte) uint64 {
+	w.buf = pad8(w.buf)
+	addr := uint64(len(w.buf))
+	w.buf = append(w.buf, b...)
+	return addr
+}
+
+type h5Msg struct {
+	kind int
+	data []byte
+}
+
+// objectHeader escribe una cabecera de objeto versión 1
+func (w *h5Writer) objectHeader(messages ...h5Msg) uint64 {
+	var body []byte
+	for _, m := range messages {
+		data := pad8(append([]byte(nil), m.data...))
+		body = append(body, u16(m.kind)...)
+		body = append(body, u16(len(data))...)
+		body = append(body, 0, 0, 0, 0) // Banderas y reservado
+		body = append(body, data...)
+	}
+	header := []byte{1, 0}
+	header = append(header, u16(len(messages))...)
+	header = append(header, u32(1)...) // Referencias
+	header = append(header, u32(len(body))...)
+	header = append(header, 0, 0, 0, 0) // Alineación
+	return w.alloc(append(header, body...))
+}
+
+type h5Entry struct {
+	name string
+	addr uint64
+}
+
+// group escribe un grupo con tabla de símbolos (heap local, nodo SNOD y B-tree de un nodo)
+func (w *h5Writer) group(entries []h5Entry) (header, btree, heap uint64) {
+	// Los miembros del nodo van ordenados por nombre
+	for i := 1; i < len(entries); i++ {
+		for j := i; j > 0 && entries[j].name < entries[j-1].name; j-- {
+			entries[j], entries[j-1] = entries[j-1], entries[j]
+		}
+	}
+	segment := make([]byte, 8) // El desplazamiento 0 es el nombre vacío
+	offsets := make([]int, len(entries))
+	for i, e := range entries {
+		offsets[i] = len(segment)
+		segment = pad8(append(append(segment, e.name...), 0))
+	}
+	segmentAddr := w.alloc(segment)
+	heapHeader := append([]byte("HEAP"), 0, 0, 0, 0)
+	heapHeader = append(heapHeader, u64(uint64(len(segment)))...)
+	heapHeader = append(heapHeader, u64(undefined)...) // Sin bloques libres
+	heapHeader = append(heapHeader, u64(segmentAddr)...)
+	heap = w.alloc(heapHeader)
+
+	const leafK = 4
+	snod := append([]byte("SNOD"), 1, 0)
+	snod = append(snod, u16(len(entries))...)
+	for i, e := range entries {
+		snod = append(snod, u64(uint64(offsets[i]))...)
+		snod = append(snod, u64(e.addr)...)
+		snod = append(snod, make([]byte, 24)...) // Sin caché
+	}
+	snod = append(snod, make([]byte, (2*leafK-len(entries))*40)...)
+	node := w.alloc(snod)
+
+	tree := append([]byte("TREE"), 0, 0)
+	tree = append(tree, u16(1)...)
+	tree = append(tree, u64(undefined)...)
+	tree = append(tree, u64(undefined)...)
+	tree = append(tree, u64(0)...)
+	tree = append(tree, u64(node)...)
+	tree = append(tree, u64(uint64(offsets[len(offsets)-1]))...)
+	btree = w.alloc(tree)
+
+	table := append(u64(btree), u64(heap)...)
+	header = w.objectHeader(h5Msg{0x11, table})
+	return header, btree, heap
+}
+
+func h5Dataspace(dims ...int) []byte {
+	out := []byte{1, byte(len(dims)), 0, 0, 0, 0, 0, 0}
+	for _, d := range dims {
+		out = append(out, u64(uint64(d))...)
+	}
+	return out
+}
+
+func h5Double() []byte {
+	out := []byte{0x11, 0x20, 0x3F, 0x00}
+	out = append(out, u32(8)...)
+	out = append(out, u16(0)...)  // Desplazamiento de bits
+	out = append(out, u16(64)...) // Precisión
+	out = append(out, 52, 11, 0, 52)
+	return append(out, u32(1023)...)
+}
+
+func h5Int32() []byte {
+	out := []byte{0x10, 0x08, 0x00, 0x00}
+	out = append(out, u32(4)...)
+	out = append(out, u16(0)...)
+	return append(out, u16(32)...)
+}
+
+// Valor de relleno versión 2 sin valor definido
+var h5FillValue = h5Msg{0x05, []byte{2, 2, 2, 0}}
+
+func (w *h5Writer) contiguous(dtype, data []byte, dims ...int) uint64 {
+	addr := w.alloc(data)
+	layout := append([]byte{3, 1}, u64(addr)...)
+	layout = append(layout, u64(uint64(len(data)))...)
+	return w.objectHeader(h5Msg{0x01, h5Dataspace(dims...)}, h5Msg{0x03, dtype}, h5FillValue, h5Msg{0x08, layout})
+}
+
+func (w *h5Writer) compact(dtype, data []byte) uint64 {
+	layout := append([]byte{3, 0}, u16(len(data))...)
+	layout = append(layout, data...)
+	return w.objectHeader(h5Msg{0x01, h5Dataspace()}, h5Msg{0x03, dtype}, h5FillValue, h5Msg{0x08, layout})
+}
+
+// chunked escribe un conjunto de una dimensión por bloques de chunk elementos de 8 bytes.
+// Con filters aplica shuffle y deflate, salvo deflate en el último bloque (bit 1 de la
+// máscara), como hace la biblioteca cuando comprimir no reduce el bloque.
+func (w *h5Writer) chunked(values []float64, chunk int, filters bool) uint64 {
+	const elemSize = 8
+	type stored struct {
+		addr   uint64
+		size   int
+		mask   int
+		offset int
+	}
+	var chunks []stored
+	for start := 0; start < len(values); start += chunk {
+		block := make([]float64, chunk)
+		copy(block, values[start:])
+		raw := f64s(block)
+		mask := 0
+		if filters {
+			raw = shuffle(raw, elemSize)
+			if start+chunk < len(values) {
+				raw = zlibCompress(raw)
+			} else {
+				mask = 1 << 1
+			}
+		}
+		chunks = append(chunks, stored{addr: w.alloc(raw), size: len(raw), mask: mask, offset: start})
+	}
+
+	tree := append([]byte("TREE"), 1, 0)
+	tree = append(tree, u16(len(chunks))...)
+	tree = append(tree, u64(undefined)...)
+	tree = append(tree, u64(undefined)...)
+	for _, c := range chunks {
+		tree = append(tree, u32(c.size)...)
+		tree = append(tree, u32(c.mask)...)
+		tree = append(tree, u64(uint64(c.offset))...)
+		tree = append(tree, u64(0)...)
+		tree = append(tree, u64(c.addr)...)
+	}
+	tree = append(tree, u32(0)...)
+	tree = append(tree, u32(0)...)
+	tree = append(tree, u64(uint64(len(values)))...)
+	tree = append(tree, u64(0)...)
+	btree := w.alloc(tree)
+
+	layout := []byte{3, 2, 2}
+	layout = append(layout, u64(btree)...)
+	layout = append(layout, u32(chunk)...)
+	layout = append(layout, u32(elemSize)...)
+	messages := []h5Msg{{0x01, h5Dataspace(len(values))}, {0x03, h5Double()}, {0x05, []byte{2, 2, 2, 0}}, {0x08, layout}}
+	if filters {
+		pipeline := []byte{1, 2, 0, 0, 0, 0, 0, 0}
+		pipeline = append(pipeline, h5Filter(2, "shuffle", elemSize)...)
+		pipeline = append(pipeline, h5Filter(1, "deflate", 6)...)
+		messages = append(messages, h5Msg{0x0B, pipeline})
+	}
+	return w.objectHeader(messages...)
+}
+
+// h5Filter describe un filtro de la cadena versión 1 con un valor de cliente
+func h5Filter(id int, name string, value int) []byte {
+	padded := pad8(append([]byte(name), 0))
+	out := append(u16(id), u16(len(padded))...)
+	out = append(out, u16(0)...) // Banderas
+	out = append(out, u16(1)...) // Valores de cliente
+	out = append(out, padded...)
+	out = append(out, u32(value)...)
+	return append(out, 0, 0, 0, 0) // Relleno por cantidad impar de valores
+}
+
+func shuffle(raw []byte, size int) []byte {
+	n := len(raw) / size
+	out := make([]byte, len(raw))
+	for i := 0; i < n; i++ {
+		for b := 0; b < size; b++ {
+			out[b*n+i] = raw[i*size+b]
+		}
+	}
+	return out
+}
+
+// finish escribe el superbloque versión 0 al inicio del archivo
+func (w *h5Writer) finish(root, btree, heap uint64) []byte {
+	sb := append([]byte{0x89, 'H', 'D', 'F', '\r', '\n', 0x1a, '\n'}, 0, 0, 0, 0, 0, 8, 8, 0)
+	sb = append(sb, u16(4)...)  // K de las hojas de grupos
+	sb = append(sb, u16(16)...) // K de los nodos internos de grupos
+	sb = append(sb, u32(0)...)
+	sb = append(sb, u64(0)...)                  // Dirección base
+	sb = append(sb, u64(undefined)...)          // Espacio libre
+	sb = append(sb, u64(uint64(len(w.buf)))...) // Fin del archivo
+	sb = append(sb, u64(undefined)...)          // Controlador
+	sb = append(sb, u64(0)...)                  // Nombre de la raíz en el heap
+	sb = append(sb, u64(root)...)               // Cabecera de objeto de la raíz
+	sb = append(sb, u32(1)...)                  // Caché: tabla de símbolos
+	sb = append(sb, u32(0)...)
+	sb = append(sb, u64(btree)...)
+	sb = append(sb, u64(heap)...)
+	copy(w.buf, sb)
+	return w.buf
+}
+
+const h5SuperblockSize = 96
+
+// hdf5Contiguous: /time y /output double contiguos y /input int32 en la raíz
+func hdf5Contiguous() []byte {
+	w := &h5Writer{buf: make([]byte, h5SuperblockSize)}
+	input := make([]byte, 0, 4*samples)
+	for i := 0; i < samples; i++ {
+		input = append(input, u32(int(step(i)))...)
+	}
+	entries := []h5Entry{
+		{"time", w.contiguous(h5Double(), f64s(signal(timeAt)), samples)},
+		{"output", w.contiguous(h5Double(), f64s(signal(response)), samples)},
+		{"input", w.contiguous(h5Int32(), input, samples)},
+	}
+	root, btree, heap := w.group(entries)
+	return w.finish(root, btree, heap)
+}
+
+// hdf5Chunked: grupo /scope con time por bloques de 40 sin filtros, output por bloques de 32
+// con shuffle y deflate y el escalar compacto dt
+func hdf5Chunked() []byte {
+	w := &h5Writer{buf: make([]byte, h5SuperblockSize)}
+	scope, _, _ := w.group([]h5Entry{
+		{"time", w.chunked(signal(timeAt), 40, false)},
+		{"output", w.chunked(signal(response), 32, true)},
+		{"dt", w.compact(h5Double(), f64s([]float64{period}))},
+	})
+	root, btree, heap := w.group([]h5Entry{{"scope", scope}})
+	return w.finish(root, btree, heap)
+}
+
+// ---------------------------------------------------------------------------------------
+// Parquet
+
+// Tipos del protocolo compacto de Thrift
+const (
+	tTrue   = 1
+	tFalse  = 2
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// thrift escribe el protocolo compacto de Thrift
+type thrift struct {
+	buf  []byte
+	last []int16
+}
+
+func (t *thrift) field(id int16, kind byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|kind)
+	} else {
+		t.buf = append(t.buf, kind)
+		t.buf = binary.AppendUvarint(t.buf, uint64(int64(id)<<1^int64(id)>>15))
+	}
+	*last = id
+}
+
+func (t *thrift) varint(v int64) { t.buf = binary.AppendUvarint(t.buf, uint64(v<<1^v>>63)) }
+
+func (t *thrift) i32(id int16, v int64) { t.field(id, tI32); t.varint(v) }
+func (t *thrift) i64(id int16, v int64) { t.field(id, tI64); t.varint(v) }
+
+func (t *thrift) str(id int16, s string) {
+	t.field(id, tBinary)
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thrift) boolean(id int16, v bool) {
+	if v {
+		t.field(id, tTrue)
+	} else {
+		t.field(id, tFalse)
+	}
+}
+
+func (t *thrift) list(id int16, elem byte, n int) {
+	t.field(id, tList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xF0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+// begin abre una estructura (campo si id > 0, elemento de lista si id == 0)
+func (t *thrift) begin(id int16) {
+	if id > 0 {
+		t.field(id, tStruct)
+	}
+	t.last = append(t.last, 0)
+}
+
+func (t *thrift) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func newThrift() *thrift { return &thrift{last: []int16{0}} }
+
+// Constantes de Parquet
+const (
+	pqInt64  = 2
+	pqFloat  = 4
+	pqDouble = 5
+
+	pqRequired = 0
+	pqOptional = 1
+
+	pqUncompressed = 0
+	pqSnappy       = 1
+
+	pqDataPage       = 0
+	pqDictionaryPage = 2
+	pqDataPageV2     = 3
+
+	pqPlain           = 0
+	pqPlainDictionary = 2
+	pqRLE             = 3
+	pqRLEDictionary   = 8
+
+	pqTimestampMillis = 9
+)
+
+type pqField struct {
+	name       string
+	physical   int64
+	repetition int64
+	converted  int64 // 0 si no tiene
+}
+
+// pqPage es una página ya codificada (sin comprimir) con su cabecera
+type pqPage struct {
+	kind     int64
+	values   int
+	encoding int64
+	data     []byte
+	v2       bool // Página de datos v2 sin niveles y sin comprimir
+}
+
+type pqChunk struct {
+	field pqField
+	pages []pqPage
+}
+
+type parquetWriter struct {
+	buf       []byte
+	codec     int64
+	rowGroups [][]pqChunk
+	rows      []int
+	meta      [][]chunkMeta
+}
+
+type chunkMeta struct {
+	dataOffset, dictOffset int64
+	size, rawSize          int64
+	values                 int64
+	encodings              []int64
+}
+
+func (w *parquetWriter) writeChunk(c pqChunk) chunkMeta {
+	m := chunkMeta{dictOffset: -1}
+	start := len(w.buf)
+	encodings := map[int64]bool{}
+	for _, p := range c.pages {
+		payload := p.data
+		if w.codec == pqSnappy && !p.v2 {
+			payload = snappyEncode(p.data)
+		}
+		h := newThrift()
+		h.i32(1, p.kind)
+		h.i32(2, int64(len(p.data)))
+		h.i32(3, int64(len(payload)))
+		switch {
+		case p.kind == pqDictionaryPage:
+			h.begin(7)
+			h.i32(1, int64(p.values))
+			h.i32(2, p.encoding)
+			h.end()
+		case p.v2:
+			h.begin(8)
+			h.i32(1, int64(p.values))
+			h.i32(2, 0)
+			h.i32(3, int64(p.values))
+			h.i32(4, p.encoding)
+			h.i32(5, 0)
+			h.i32(6, 0)
+			h.boolean(7, false)
+			h.end()
+		default:
+			h.begin(5)
+			h.i32(1, int64(p.values))
+			h.i32(2, p.encoding)
+			h.i32(3, pqRLE)
+			h.i32(4, pqRLE)
+			h.end()
+		}
+		h.buf = append(h.buf, 0)
+
+		offset := int64(len(w.buf))
+		if p.kind == pqDictionaryPage {
+			m.dictOffset = offset
+		} else {
+			if m.dataOffset == 0 {
+				m.dataOffset = offset
+			}
+			m.values += int64(p.values)
+		}
+		encodings[p.encoding] = true
+		m.rawSize += int64(len(h.buf) + len(p.data))
+		w.buf = append(w.buf, h.buf...)
+		w.buf = append(w.buf, payload...)
+	}
+	encodings[pqRLE] = true
+	for _, e := range []int64{pqPlain, pqPlainDictionary, pqRLE, pqRLEDictionary} {
+		if encodings[e] {
+			m.encodings = append(m.encodings, e)
+		}
+	}
+	m.size = int64(len(w.buf) - start)
+	return m
+}
+
+func (w *parquetWriter) addRowGroup(rows int, chunks ...pqChunk) {
+	w.rowGroups = append(w.rowGroups, chunks)
+	w.rows = append(w.rows, rows)
+}
+
+func (w *parquetWriter) bytes() []byte {
+	w.buf = []byte("PAR1")
+	for _, chunks := range w.rowGroups {
+		metas := make([]chunkMeta, len(chunks))
+		for i, c := range chunks {
+			metas[i] = w.writeChunk(c)
+		}
+		w.meta = append(w.meta, metas)
+	}
+
+	fields := make([]pqField, len(w.rowGroups[0]))
+	for i, c := range w.rowGroups[0] {
+		fields[i] = c.field
+	}
+	total := 0
+	for _, r := range w.rows {
+		total += r
+	}
+
+	t := newThrift()
+	t.i32(1, 1)
+	t.list(2, tStruct, len(fields)+1)
+	t.begin(0)
+	t.str(4, "schema")
+	t.i32(5, int64(len(fields)))
+	t.end()
+	for _, f := range fields {
+		t.begin(0)
+		t.i32(1, f.physical)
+		t.i32(3, f.repetition)
+		t.str(4, f.name)
+		if f.converted != 0 {
+			t.i32(6, f.converted)
+		}
+		t.end()
+	}
+	t.i64(3, int64(total))
+	t.list(4, tStruct, len(w.rowGroups))
+	for g, chunks := range w.rowGroups {
+		t.begin(0)
+		t.list(1, tStruct, len(chunks))
+		var groupSize int64
+		for i, c := range chunks {
+			m := w.meta[g][i]
+			groupSize += m.rawSize
+			t.begin(0)
+			t.i64(2, m.dataOffset)
+			t.begin(3)
+			t.i32(1, c.field.physical)
+			t.list(2, tI32, len(m.encodings))
+			for _, e := range m.encodings {
+				t.varint(e)
+			}
+			t.list(3, tBinary, 1)
+			t.buf = binary.AppendUvarint(t.buf, uint64(len(c.field.name)))
+			t.buf = append(t.buf, c.field.name...)
+			t.i32(4, w.codec)
+			t.i64(5, m.values)
+			t.i64(6, m.rawSize)
+			t.i64(7, m.size)
+			t.i64(9, m.dataOffset)
+			if m.dictOffset >= 0 {
+				t.i64(11, m.dictOffset)
+			}
+			t.end()
+			t.end()
+		}
+		t.i64(2, groupSize)
+		t.i64(3, int64(w.rows[g]))
+		t.end()
+	}
+	t.list(5, tStruct, 1)
+	t.begin(0)
+	t.str(1, "fuente")
+	t.str(2, "generate.go")
+	t.end()
+	t.str(6, "backend parser testdata")
+	t.buf = append(t.buf, 0)
+
+	out := append(w.buf, t.buf...)
+	out = append(out, u32(len(t.buf))...)
+	return append(out, "PAR1"...)
+}
+
+// parquetPlain: time como INT64 TIMESTAMP_MILLIS desde una fecha absoluta, output double y
+// input float opcional, sin comprimir y con codificación plana
+func parquetPlain() []byte {
+	var stamps []byte
+	for i := 0; i < samples; i++ {
+		stamps = le.AppendUint64(stamps, uint64(1760000000000+10*int64(i)))
+	}
+	// Niveles de definición: longitud y una corrida RLE de 100 unos
+	levels := binary.AppendUvarint(nil, samples<<1)
+	levels = append(levels, 1)
+	input := append(u32(len(levels)), levels...)
+	input = append(input, f32s(signal(step))...)
+
+	w := &parquetWriter{codec: pqUncompressed}
+	w.addRowGroup(samples,
+		pqChunk{pqField{"time", pqInt64, pqRequired, pqTimestampMillis}, []pqPage{{kind: pqDataPage, values: samples, encoding: pqPlain, data: stamps}}},
+		pqChunk{pqField{"output", pqDouble, pqRequired, 0}, []pqPage{{kind: pqDataPage, values: samples, encoding: pqPlain, data: f64s(signal(response))}}},
+		pqChunk{pqField{"input", pqFloat, pqOptional, 0}, []pqPage{{kind: pqDataPage, values: samples, encoding: pqPlain, data: input}}},
+	)
+	return w.bytes()
+}
+
+// parquetDictionary: t en una página v2 plana y la escalera y por diccionario de tres
+// valores, con índices de 2 bits empaquetados y en corridas RLE
+func parquetDictionary() []byte {
+	indices := make([]int, samples)
+	for i := range indices {
+		indices[i] = int(staircase(i) * 2)
+	}
+	// 32 índices empaquetados (4 grupos de 8) y dos corridas
+	data := []byte{2}
+	data = binary.AppendUvarint(data, 4<<1|1)
+	packed := make([]byte, 8)
+	for i := 0; i < 32; i++ {
+		packed[2*i/8] |= byte(indices[i]) << uint(2*i%8)
+	}
+	data = append(data, packed...)
+	data = binary.AppendUvarint(data, 18<<1)
+	data = append(data, byte(indices[32]))
+	data = binary.AppendUvarint(data, 50<<1)
+	data = append(data, byte(indices[50]))
+
+	w := &parquetWriter{codec: pqUncompressed}
+	w.addRowGroup(samples,
+		pqChunk{pqField{"t", pqDouble, pqRequired, 0}, []pqPage{{kind: pqDataPageV2, values: samples, encoding: pqPlain, data: f64s(signal(timeAt)), v2: true}}},
+		pqChunk{pqField{"y", pqDouble, pqRequired, 0}, []pqPage{
+			{kind: pqDictionaryPage, values: 3, encoding: pqPlainDictionary, data: f64s([]float64{0, 0.5, 1})},
+			{kind: pqDataPage, values: samples, encoding: pqRLEDictionary, data: data},
+		}},
+	)
+	return w.bytes()
+}
+
+// parquetSnappy: time y el escalón output en doubles comprimidos con Snappy, en dos grupos
+// de filas de 50
+func parquetSnappy() []byte {
+	t, y := signal(timeAt), signal(step)
+	w := &parquetWriter{codec: pqSnappy}
+	for start := 0; start < samples; start += 50 {
+		w.addRowGroup(50,
+			pqChunk{pqField{"time", pqDouble, pqRequired, 0}, []pqPage{{kind: pqDataPage, values: 50, encoding: pqPlain, data: f64s(t[start : start+50])}}},
+			pqChunk{pqField{"output", pqDouble, pqRequired, 0}, []pqPage{{kind: pqDataPage, values: 50, encoding: pqPlain, data: f64s(y[start : start+50])}}},
+		)
+	}
+	return w.bytes()
+}
+
+// snappyEncode comprime con Snappy buscando la coincidencia más larga (suficiente para
+// páginas pequeñas); usa copias de 1 y 2 bytes de desplazamiento, que pueden solaparse
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	literal := 0
+	for i := 0; i < len(src); {
+		bestLen, bestOff := 0, 0
+		for off := 1; off <= i && off < 1<<16; off++ {
+			n := 0
+			for i+n < len(src) && n < 64 && src[i+n] == src[i+n-off] {
+				n++
+			}
+			if n > bestLen {
+				bestLen, bestOff = n, off
+			}
+		}
+		if bestLen < 4 {
+			i++
+			continue
+		}
+		dst = snappyLiteral(dst, src[literal:i])
+		if bestLen <= 11 && bestOff < 2048 {
+			dst = append(dst, byte(1|(bestLen-4)<<2|bestOff>>8<<5), byte(bestOff))
+		} else {
+			dst = append(dst, byte(2|(bestLen-1)<<2))
+			dst = append(dst, u16(bestOff)...)
+		}
+		i += bestLen
+		literal = i
+	}
+	return snappyLiteral(dst, src[literal:])
+}
+
+func snappyLiteral(dst, lit []byte) []byte {
+	n := len(lit) - 1
+	switch {
+	case len(lit) == 0:
+		return dst
+	case n < 60:
+		dst = append(dst, byte(n<<2))
+	case n < 256:
+		dst = append(dst, 60<<2, byte(n))
+	default:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(dst, lit...)
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Códigos de formato de muestra de WAV
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// Nombres de los campos INFO de WAV copiados a los metadatos
+var wavInfoNames = map[string]string{
+	"INAM": "Title",
+	"ICMT": "Comment",
+	"ISFT": "Software",
+	"ICRD": "Date",
+	"IART": "Artist",
+}
+
+// wavFormat es el bloque "fmt " de un archivo WAV
+type wavFormat struct {
+	format        int
+	channels      int
+	sampleRate    int
+	blockAlign    int
+	bitsPerSample int
+}
+
+// wavChunks recorre los bloques RIFF del archivo y llama a fn con su identificador y contenido
+func wavChunks(data []byte, fn func(id string, body []byte) bool) {
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		end := off + 8 + size
+		if size < 0 || end > len(data) {
+			// Bloque truncado (habitual en el bloque de datos de grabaciones interrumpidas)
+			end = len(data)
+		}
+		if !fn(id, data[off+8:end]) {
+			return
+		}
+		off = end + size%2
+	}
+}
+
+// readWAVFormat lee y valida el bloque "fmt " de un archivo WAV
+func readWAVFormat(data []byte) (wavFormat, error) {
+	var f wavFormat
+	found := false
+	wavChunks(data, func(id string, body []byte) bool {
+		if id != "fmt " || len(body) < 16 {
+			return true
+		}
+		f = wavFormat{
+			format:        int(binary.LittleEndian.Uint16(body[0:2])),
+			channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+			sampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+			blockAlign:    int(binary.LittleEndian.Uint16(body[12:14])),
+			bitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+		}
+		// WAVE_FORMAT_EXTENSIBLE: el formato real está al inicio del GUID del subformato
+		if f.format == wavFormatExtensible && len(body) >= 26 {
+			f.format = int(binary.LittleEndian.Uint16(body[24:26]))
+		}
+		found = true
+		return false
+	})
+
+	switch {
+	case !found:
+		return f, fmt.Errorf("%w: el archivo WAV no tiene bloque fmt", ErrMalformed)
+	case f.channels < 1:
+		return f, fmt.Errorf("%w: el archivo WAV no declara canales", ErrMalformed)
+	case f.sampleRate <= 0:
+		return f, fmt.Errorf("%w: frecuencia de muestreo inválida (%d Hz)", ErrMalformed, f.sampleRate)
+	case f.format == wavFormatPCM && f.bitsPerSample != 8 && f.bitsPerSample != 16 && f.bitsPerSample != 24 && f.bitsPerSample != 32:
+		return f, fmt.Errorf("%w: PCM de %d bits", ErrUnsupportedFormat, f.bitsPerSample)
+	case f.format == wavFormatFloat && f.bitsPerSample != 32 && f.bitsPerSample != 64:
+		return f, fmt.Errorf("%w: punto flotante de %d bits", ErrUnsupportedFormat, f.bitsPerSample)
+	case f.format != wavFormatPCM && f.format != wavFormatFloat:
+		return f, fmt.Errorf("%w: codificación WAV %#x (solo PCM o punto flotante)", ErrUnsupportedFormat, f.format)
+	case f.blockAlign != f.channels*f.bitsPerSample/8:
+		return f, fmt.Errorf("%w: alineación de bloque %d inconsistente con %d canales de %d bits", ErrMalformed, f.blockAlign, f.channels, f.bitsPerSample)
+	}
+	return f, nil
+}
+
+// parseWAV lee un archivo WAV de una placa de adquisición. Cada canal es una columna
+// (CH1, CH2...) después del tiempo, que se reconstruye con la frecuencia de muestreo. Las
+// muestras PCM se normalizan a fondo de escala (±1).
+func parseWAV(data []byte, opts Options, report *Report) (*Series, error) {
+	f, err := readWAVFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []byte
+	wavChunks(data, func(id string, body []byte) bool {
+		switch id {
+		case "data":
+			samples = body
+		case "LIST":
+			if len(body) >= 4 && string(body[:4]) == "INFO" {
+				readWAVInfo(body[4:], report)
+			}
+		}
+		return true
+	})
+	if samples == nil {
+		return nil, fmt.Errorf("%w: el archivo WAV no tiene bloque de datos", ErrMalformed)
+	}
+
+	encoding := "pcm"
+	if f.format == wavFormatFloat {
+		encoding = "float"
+	}
+	report.Metadata["Sample Rate"] = fmt.Sprintf("%d Hz", f.sampleRate)
+	report.Metadata["Channels"] = fmt.Sprint(f.channels)
+	report.Metadata["Bits Per Sample"] = fmt.Sprint(f.bitsPerSample)
+	report.Metadata["Encoding"] = encoding
+	report.declaredPeriod = 1 / float64(f.sampleRate)
+
+	frames := len(samples) / f.blockAlign
+	if frames*f.channels > maxBinaryValues {
+		return nil, fmt.Errorf("%w: el archivo WAV tiene demasiadas muestras (%d)", ErrUnsupportedFormat, frames)
+	}
+	vectors := make([]vector, f.channels)
+	for ch := range vectors {
+		vectors[ch] = vector{name: fmt.Sprintf("CH%d", ch+1), values: make([]float64, frames)}
+	}
+	size := f.bitsPerSample / 8
+	for i := 0; i < frames; i++ {
+		for ch := range vectors {
+			off := i*f.blockAlign + ch*size
+			vectors[ch].values[i] = wavSample(samples[off:off+size], f.format)
+		}
+	}
+	return seriesFromVectors(vectors, opts, report)
+}
+
+// wavSample convierte una muestra a float64; las muestras PCM se escalan a fondo de escala
+func wavSample(b []byte, format int) float64 {
+	if format == wavFormatFloat {
+		if len(b) == 4 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	switch len(b) {
+	case 1:
+		// PCM de 8 bits sin signo
+		return (float64(b[0]) - 128) / 128
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 3:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// readWAVInfo copia los campos de texto de la lista INFO a los metadatos
+func readWAVInfo(body []byte, report *Report) {
+	for off := 0; off+8 <= len(body); {
+		id := string(body[off : off+4])
+		size := int(binary.LittleEndian.Uint32(body[off+4 : off+8]))
+		if off+8+size > len(body) {
+			return
+		}
+		if name, ok := wavInfoNames[id]; ok {
+			report.Metadata[name] = strings.TrimRight(string(body[off+8:off+8+size]), "\x00 ")
+		}
+		off += 8 + size + size%2
+	}
+}
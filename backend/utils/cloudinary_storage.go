@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -33,18 +32,22 @@ func NewCloudinaryStorage() *CloudinaryStorage {
 	}
 }
 
-// UploadFile sube un archivo a Cloudinary
-func (cs *CloudinaryStorage) UploadFile(file multipart.File, header *multipart.FileHeader) (string, error) {
+// Put sube un archivo a Cloudinary y devuelve su URL segura
+func (cs *CloudinaryStorage) Put(filename string, file io.Reader) (string, error) {
 	// Verificar credenciales
 	if cs.CloudName == "" || cs.APIKey == "" || cs.APISecret == "" {
 		return "", fmt.Errorf("faltan credenciales de Cloudinary, asegúrate de configurar las variables de entorno CLOUDINARY_CLOUD_NAME, CLOUDINARY_API_KEY y CLOUDINARY_API_SECRET")
 	}
 
 	// Verificar la extensión del archivo
-	ext := filepath.Ext(header.Filename)
-	if strings.ToLower(ext) != ".csv" {
-		return "", fmt.Errorf("solo se permiten archivos CSV")
+	if err := validateUploadFilename(filename); err != nil {
+		return "", err
 	}
+	file, err := validateUploadContent(filename, file)
+	if err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(filename)
 
 	// Inicializar Cloudinary
 	cld, err := cloudinary.NewFromParams(cs.CloudName, cs.APIKey, cs.APISecret)
@@ -54,7 +57,7 @@ func (cs *CloudinaryStorage) UploadFile(file multipart.File, header *multipart.F
 
 	// Generar nombre único basado en timestamp y nombre original
 	timestamp := time.Now().Unix()
-	filenameWithoutExt := strings.TrimSuffix(header.Filename, ext)
+	filenameWithoutExt := strings.TrimSuffix(filename, ext)
 
 	// Usar solo el nombre de archivo en el publicID (sin carpeta)
 	baseFilename := fmt.Sprintf("%d_%s", timestamp, filenameWithoutExt)
@@ -77,14 +80,19 @@ func (cs *CloudinaryStorage) UploadFile(file multipart.File, header *multipart.F
 	return uploadResult.SecureURL, nil
 }
 
-// GetFile obtiene un archivo de Cloudinary
-func (cs *CloudinaryStorage) GetFile(url string) (io.ReadCloser, error) {
+// Get obtiene un archivo de Cloudinary
+func (cs *CloudinaryStorage) Get(url string) (io.ReadCloser, error) {
 	// Obtener archivo directamente a través de la URL
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener archivo de Cloudinary: %v", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, url)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
 		return nil, fmt.Errorf("error al obtener archivo, código de estado: %d", resp.StatusCode)
@@ -93,8 +101,32 @@ func (cs *CloudinaryStorage) GetFile(url string) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-// DeleteFile elimina un archivo de Cloudinary
-func (cs *CloudinaryStorage) DeleteFile(url string) error {
+// Stat obtiene la información de un archivo de Cloudinary mediante una petición HEAD
+func (cs *CloudinaryStorage) Stat(url string) (*FileInfo, error) {
+	resp, err := http.Head(url)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar archivo de Cloudinary: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, url)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error al consultar archivo, código de estado: %d", resp.StatusCode)
+	}
+
+	info := &FileInfo{Ref: url, Size: resp.ContentLength}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+
+	return info, nil
+}
+
+// Delete elimina un archivo de Cloudinary
+func (cs *CloudinaryStorage) Delete(url string) error {
 	// Verificar credenciales
 	if cs.CloudName == "" || cs.APIKey == "" || cs.APISecret == "" {
 		return fmt.Errorf("faltan credenciales de Cloudinary")
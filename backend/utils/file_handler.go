@@ -56,11 +56,20 @@ func (h *FileHandler) SaveFile(file multipart.File, header *multipart.FileHeader
 		return "", errors.New("el archivo es demasiado grande (máximo 10MB)")
 	}
 
+	return h.SaveReader(header.Filename, file)
+}
+
+// SaveReader guarda el contenido de un reader con un nombre aleatorio y devuelve su ruta
+func (h *FileHandler) SaveReader(originalFilename string, file io.Reader) (string, error) {
 	// Verificar la extensión del archivo
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext != ".csv" {
-		return "", errors.New("solo se permiten archivos CSV")
+	if err := validateUploadFilename(originalFilename); err != nil {
+		return "", err
 	}
+	file, err := validateUploadContent(originalFilename, file)
+	if err != nil {
+		return "", err
+	}
+	ext := strings.ToLower(filepath.Ext(originalFilename))
 
 	// Generar un nombre de archivo único
 	filename, err := generateSecureFilename(ext)
@@ -78,11 +87,16 @@ func (h *FileHandler) SaveFile(file multipart.File, header *multipart.FileHeader
 	}
 	defer dst.Close()
 
-	// Copiar el contenido del archivo subido
-	if _, err = io.Copy(dst, file); err != nil {
+	// Copiar el contenido del archivo subido, sin superar el tamaño máximo
+	written, err := io.Copy(dst, io.LimitReader(file, h.MaxSize+1))
+	if err != nil {
 		os.Remove(filePath) // Limpiar en caso de error
 		return "", errors.New("error al guardar el archivo")
 	}
+	if written > h.MaxSize {
+		os.Remove(filePath)
+		return "", errors.New("el archivo es demasiado grande (máximo 10MB)")
+	}
 
 	return filePath, nil
 }
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage guarda los archivos en el disco local usando FileHandler
+type LocalStorage struct {
+	handler *FileHandler
+}
+
+// NewLocalStorage crea una nueva instancia de LocalStorage (directorio UPLOAD_DIR)
+func NewLocalStorage() *LocalStorage {
+	return &LocalStorage{handler: NewFileHandler()}
+}
+
+// Put guarda el archivo en el directorio de uploads y devuelve su ruta
+func (ls *LocalStorage) Put(filename string, r io.Reader) (string, error) {
+	return ls.handler.SaveReader(filename, r)
+}
+
+// Get abre un archivo guardado
+func (ls *LocalStorage) Get(ref string) (io.ReadCloser, error) {
+	path, err := ls.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir archivo local: %v", err)
+	}
+
+	return file, nil
+}
+
+// Delete elimina un archivo guardado
+func (ls *LocalStorage) Delete(ref string) error {
+	path, err := ls.resolve(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+		}
+		return fmt.Errorf("error al eliminar archivo local: %v", err)
+	}
+
+	return nil
+}
+
+// Stat obtiene la información de un archivo guardado
+func (ls *LocalStorage) Stat(ref string) (*FileInfo, error) {
+	path, err := ls.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar archivo local: %v", err)
+	}
+
+	return &FileInfo{Ref: ref, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// resolve valida que la referencia apunte a un archivo dentro del directorio de uploads
+func (ls *LocalStorage) resolve(ref string) (string, error) {
+	baseDir, err := filepath.Abs(ls.handler.UploadDir)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := filepath.Abs(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(path, baseDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("ruta fuera del directorio de uploads: %s", ref)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage guarda los archivos en un bucket compatible con S3 (AWS S3, MinIO, etc.)
+type S3Storage struct {
+	Endpoint  string // p. ej. https://s3.amazonaws.com o http://minio:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // Prefijo de las claves dentro del bucket
+	PathStyle bool   // true: endpoint/bucket/clave (MinIO); false: bucket.endpoint/clave
+	Client    *http.Client
+}
+
+// NewS3Storage crea una nueva instancia de S3Storage a partir de variables de entorno
+func NewS3Storage() (*S3Storage, error) {
+	s3 := &S3Storage{
+		Endpoint:  strings.TrimSuffix(os.Getenv("S3_ENDPOINT"), "/"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		Prefix:    strings.Trim(os.Getenv("S3_PREFIX"), "/"),
+		PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		Client:    &http.Client{Timeout: 60 * time.Second},
+	}
+
+	if s3.Endpoint == "" {
+		s3.Endpoint = "https://s3.amazonaws.com"
+	}
+	if s3.Region == "" {
+		s3.Region = "us-east-1"
+	}
+
+	if s3.Bucket == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+		return nil, fmt.Errorf("faltan credenciales de S3, asegúrate de configurar las variables de entorno S3_BUCKET, S3_ACCESS_KEY y S3_SECRET_KEY")
+	}
+
+	return s3, nil
+}
+
+// Put sube un archivo al bucket y devuelve una referencia s3://bucket/clave
+func (s *S3Storage) Put(filename string, r io.Reader) (string, error) {
+	if err := validateUploadFilename(filename); err != nil {
+		return "", err
+	}
+	r, err := validateUploadContent(filename, r)
+	if err != nil {
+		return "", err
+	}
+
+	// Los archivos son pequeños (MaxFileSize), se leen completos para firmar el contenido
+	body, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("error al leer el archivo: %v", err)
+	}
+	if len(body) > MaxFileSize {
+		return "", errors.New("el archivo es demasiado grande (máximo 10MB)")
+	}
+
+	name, err := generateSecureFilename(strings.ToLower(filepath.Ext(filename)))
+	if err != nil {
+		return "", errors.New("error al generar nombre de archivo")
+	}
+
+	key := name
+	if s.Prefix != "" {
+		key = s.Prefix + "/" + name
+	}
+
+	resp, err := s.do(http.MethodPut, key, body)
+	if err != nil {
+		return "", fmt.Errorf("error al subir archivo a S3: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error al subir archivo a S3, código de estado: %d", resp.StatusCode)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, key), nil
+}
+
+// Get obtiene un archivo del bucket
+func (s *S3Storage) Get(ref string) (io.ReadCloser, error) {
+	key, err := s.keyFromRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener archivo de S3: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error al obtener archivo de S3, código de estado: %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// Delete elimina un archivo del bucket
+func (s *S3Storage) Delete(ref string) error {
+	key, err := s.keyFromRef(ref)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("error al eliminar archivo de S3: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error al eliminar archivo de S3, código de estado: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Stat obtiene la información de un archivo del bucket
+func (s *S3Storage) Stat(ref string) (*FileInfo, error) {
+	key, err := s.keyFromRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodHead, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar archivo de S3: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error al consultar archivo de S3, código de estado: %d", resp.StatusCode)
+	}
+
+	info := &FileInfo{Ref: ref, Size: resp.ContentLength}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+
+	return info, nil
+}
+
+// keyFromRef extrae la clave del objeto de una referencia s3://bucket/clave
+func (s *S3Storage) keyFromRef(ref string) (string, error) {
+	prefix := fmt.Sprintf("s3://%s/", s.Bucket)
+	if !strings.HasPrefix(ref, prefix) || len(ref) == len(prefix) {
+		return "", fmt.Errorf("referencia de S3 inválida: %s", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// do construye, firma (AWS Signature V4) y envía una petición sobre un objeto
+func (s *S3Storage) do(method, key string, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint de S3 inválido: %v", err)
+	}
+
+	host := endpoint.Host
+	path := "/" + awsURIEncode(key, false)
+	if s.PathStyle {
+		path = "/" + awsURIEncode(s.Bucket, true) + path
+	} else {
+		host = s.Bucket + "." + host
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s://%s%s", endpoint.Scheme, host, path), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = path
+	req.ContentLength = int64(len(body))
+
+	s.sign(req, host, path, body, time.Now().UTC())
+
+	return s.Client.Do(req)
+}
+
+// sign agrega las cabeceras de autenticación AWS Signature V4
+func (s *S3Storage) sign(req *http.Request, host, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Cabeceras canónicas, ordenadas y en minúsculas
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"", // Sin parámetros de consulta
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	// Derivar la clave de firma
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// awsURIEncode codifica una ruta según las reglas de AWS (solo caracteres no reservados sin codificar)
+func awsURIEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9'),
+			b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return encoded.String()
+}
+
+// sha256Hex calcula el hash SHA-256 en hexadecimal
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 calcula un HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backend/parser"
+)
+
+// ErrFileNotFound indica que el archivo solicitado no existe en el almacenamiento
+var ErrFileNotFound = errors.New("archivo no encontrado")
+
+// FileInfo describe un archivo guardado en el almacenamiento
+type FileInfo struct {
+	Ref     string    // Referencia guardada en Document.FilePath
+	Size    int64     // Tamaño en bytes (-1 si el backend no lo informa)
+	ModTime time.Time // Fecha de última modificación, si está disponible
+}
+
+// Storage abstrae el backend donde se guardan los archivos subidos.
+// La referencia que devuelve Put es la que se guarda en Document.FilePath
+// y la que reciben Get, Delete y Stat.
+type Storage interface {
+	Put(filename string, r io.Reader) (string, error)
+	Get(ref string) (io.ReadCloser, error)
+	Delete(ref string) error
+	Stat(ref string) (*FileInfo, error)
+}
+
+// Drivers de almacenamiento disponibles (variable de entorno STORAGE_DRIVER)
+const (
+	StorageDriverCloudinary = "cloudinary"
+	StorageDriverLocal      = "local"
+	StorageDriverS3         = "s3"
+)
+
+// NewStorage crea el backend de almacenamiento configurado en STORAGE_DRIVER.
+// Por compatibilidad, si no se indica ninguno se usa Cloudinary.
+func NewStorage() (Storage, error) {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+
+	switch driver {
+	case "", StorageDriverCloudinary:
+		return NewCloudinaryStorage(), nil
+	case StorageDriverLocal:
+		return NewLocalStorage(), nil
+	case StorageDriverS3:
+		return NewS3Storage()
+	default:
+		return nil, fmt.Errorf("driver de almacenamiento desconocido: %s", driver)
+	}
+}
+
+// validateUploadFilename verifica que el archivo tenga una extensión permitida
+func validateUploadFilename(filename string) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !parser.SupportedExtension(ext) {
+		return fmt.Errorf("tipo de archivo no permitido (se admiten %s)", parser.SupportedExtensions())
+	}
+	return nil
+}
+
+// validateUploadContent comprueba que el contenido corresponda a la extensión (firma de
+// WAV, MAT, HDF5 o Parquet; texto para CSV/TXT) y devuelve un reader con el archivo completo
+func validateUploadContent(filename string, r io.Reader) (io.Reader, error) {
+	head, err := io.ReadAll(io.LimitReader(r, parser.SignatureLength))
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo: %v", err)
+	}
+	if err := parser.CheckSignature(filename, head); err != nil {
+		return nil, err
+	}
+	return io.MultiReader(bytes.NewReader(head), r), nil
+}